@@ -103,3 +103,20 @@ func ValidateMsg(pb proto.Message) error {
 	}
 	return nil
 }
+
+// logMsg returns the message in a form fit for logging. Block responses are
+// reduced to the height and hash of their block, which can be megabytes.
+func logMsg(pb proto.Message) interface{} {
+	msg, ok := pb.(*bcproto.BlockResponse)
+	if !ok {
+		return pb
+	}
+	if msg.Block == nil {
+		return "BlockResponse{nil}"
+	}
+	header, err := types.HeaderFromProto(&msg.Block.Header)
+	if err != nil {
+		return fmt.Sprintf("BlockResponse{H:%v}", msg.Block.Header.Height)
+	}
+	return fmt.Sprintf("BlockResponse{H:%v Hash:%v}", header.Height, header.Hash().Hex())
+}
@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"encoding/hex"
+	"fmt"
 	"math"
 	"math/big"
 	"testing"
@@ -81,6 +82,23 @@ func TestBcStatusResponseMessageValidateBasic(t *testing.T) {
 	}
 }
 
+func TestLogMsgOmitsBlockBody(t *testing.T) {
+	block := types.NewBlock(&types.Header{Height: 3}, []*types.Transaction{TestTx}, nil, nil, trie.NewStackTrie(nil))
+	bpb, err := block.ToProto()
+	require.NoError(t, err)
+	msg := &bcproto.BlockResponse{Block: bpb}
+
+	logged := fmt.Sprint(logMsg(msg))
+	assert.Equal(t, fmt.Sprintf("BlockResponse{H:3 Hash:%v}", block.Hash().Hex()), logged)
+	assert.NotContains(t, logged, "Hello World!")
+	assert.Contains(t, msg.String(), "Hello World!")
+	assert.Equal(t, "BlockResponse{nil}", logMsg(&bcproto.BlockResponse{}))
+
+	// Other messages are logged as they are.
+	request := &bcproto.BlockRequest{Height: 1}
+	assert.Equal(t, request, logMsg(request))
+}
+
 // nolint:lll // ignore line length in tests
 func TestBlockchainMessageVectors(t *testing.T) {
 	block := types.NewBlock(&types.Header{Height: 3}, []*types.Transaction{TestTx}, nil, nil, trie.NewStackTrie(nil))
@@ -40,6 +40,9 @@ type ChainConfig struct {
 type KaiconConfig struct {
 	Period uint64 `json:"period" yaml:"Period"` // Number of seconds between blocks to enforce
 	Epoch  uint64 `json:"epoch" yaml:"Epoch"`   // Epoch length to reset votes and checkpoint
+
+	MinBlockInterval uint64  `json:"minBlockInterval,omitempty" yaml:"MinBlockInterval"` // Minimum number of seconds between consecutive headers (0 = unchecked)
+	MinIntervalBlock *uint64 `json:"minIntervalBlock,omitempty" yaml:"MinIntervalBlock"` // MinBlockInterval switch block (nil = no fork, 0 = from genesis)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -74,6 +77,14 @@ func (c *ChainConfig) IsGalaxias(height *uint64) bool {
 	return isForked(c.GalaxiasBlock, height)
 }
 
+// IsMinBlockInterval returns whether the minimum block interval is enforced at the given height
+func (c *ChainConfig) IsMinBlockInterval(height *uint64) bool {
+	if c.Kaicon == nil || c.Kaicon.MinBlockInterval == 0 {
+		return false
+	}
+	return isForked(c.Kaicon.MinIntervalBlock, height)
+}
+
 // isForked returns whether a fork scheduled at block s is active at the given head block.
 func isForked(s, head *uint64) bool {
 	if s == nil || head == nil {
@@ -19,6 +19,8 @@
 package configs
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"path/filepath"
@@ -152,8 +154,49 @@ type ConsensusConfig struct {
 	// Reactor sleep duration parameters are in milliseconds
 	PeerGossipSleepDuration     time.Duration `mapstructure:"peer_gossip_sleep_duration"`
 	PeerQueryMaj23SleepDuration time.Duration `mapstructure:"peer_query_maj23_sleep_duration"`
+
+	// What to do with a peer message when the consensus message queue is full,
+	// one of PeerMsgQueueBlock, PeerMsgQueueDropOldest or PeerMsgQueueRejectNewest
+	PeerMsgQueueDropPolicy string `mapstructure:"peer_msg_queue_drop_policy"`
+
+	// Consensus is reported unhealthy if its height/round does not advance for this long
+	StallTimeout time.Duration `mapstructure:"stall_timeout"`
+
+	// Our round step is re-sent to a peer behind us whose height/round/step does not
+	// advance for this long. Zero disables it.
+	PeerStallTimeout time.Duration `mapstructure:"peer_stall_timeout"`
+
+	// Proposals for our round received longer than this after its propose timeout
+	// are dropped. Zero disables it.
+	LateProposalTimeout time.Duration `mapstructure:"late_proposal_timeout"`
+
+	// A peer more than PeerLagHeights behind us for longer than PeerLagTimeout is
+	// disconnected. A zero PeerLagTimeout disables it.
+	PeerLagHeights uint64        `mapstructure:"peer_lag_heights"`
+	PeerLagTimeout time.Duration `mapstructure:"peer_lag_timeout"`
+
+	// Attach the header of the block being committed to round step messages sent
+	// to peers that asked for it, so light followers need not fetch the block.
+	IsSendCommitHeaders bool `mapstructure:"is_send_commit_headers"`
+
+	// Ask every new peer to attach commit headers to its round step messages.
+	IsRequestCommitHeaders bool `mapstructure:"is_request_commit_headers"`
+
+	// Largest message accepted on each consensus channel, in bytes. Zero uses the
+	// default, the maximum size of a consensus message.
+	StateRecvMessageCapacity       int `mapstructure:"state_recv_message_capacity"`
+	DataRecvMessageCapacity        int `mapstructure:"data_recv_message_capacity"`
+	VoteRecvMessageCapacity        int `mapstructure:"vote_recv_message_capacity"`
+	VoteSetBitsRecvMessageCapacity int `mapstructure:"vote_set_bits_recv_message_capacity"`
 }
 
+// Drop policies applied to peer messages when the consensus message queue is full.
+const (
+	PeerMsgQueueBlock        = "block"         // wait for room in the queue
+	PeerMsgQueueDropOldest   = "drop_oldest"   // drop the oldest queued message
+	PeerMsgQueueRejectNewest = "reject_newest" // drop the incoming message
+)
+
 // DefaultConsensusConfig returns a default configuration for the consensus service
 func DefaultConsensusConfig() *ConsensusConfig {
 	return &ConsensusConfig{
@@ -169,6 +212,9 @@ func DefaultConsensusConfig() *ConsensusConfig {
 		CreateEmptyBlocksInterval:   3500 * time.Millisecond,
 		PeerGossipSleepDuration:     100 * time.Millisecond,
 		PeerQueryMaj23SleepDuration: 2000 * time.Millisecond,
+		PeerMsgQueueDropPolicy:      PeerMsgQueueBlock,
+		StallTimeout:                60 * time.Second,
+		PeerStallTimeout:            10 * time.Second,
 	}
 }
 
@@ -237,6 +283,71 @@ func (cfg *ConsensusConfig) PeerQueryMaj23Sleep() time.Duration {
 	return cfg.PeerQueryMaj23SleepDuration
 }
 
+// ValidateBasic performs basic validation (checking param bounds, etc.) and
+// returns an error if any check fails.
+func (cfg *ConsensusConfig) ValidateBasic() error {
+	if cfg.TimeoutPropose <= 0 {
+		return errors.New("timeout_propose must be positive")
+	}
+	if cfg.TimeoutProposeDelta < 0 {
+		return errors.New("timeout_propose_delta can't be negative")
+	}
+	if cfg.TimeoutPrevote <= 0 {
+		return errors.New("timeout_prevote must be positive")
+	}
+	if cfg.TimeoutPrevoteDelta < 0 {
+		return errors.New("timeout_prevote_delta can't be negative")
+	}
+	if cfg.TimeoutPrecommit <= 0 {
+		return errors.New("timeout_precommit must be positive")
+	}
+	if cfg.TimeoutPrecommitDelta < 0 {
+		return errors.New("timeout_precommit_delta can't be negative")
+	}
+	if cfg.TimeoutCommit < 0 {
+		return errors.New("timeout_commit can't be negative")
+	}
+	if cfg.CreateEmptyBlocksInterval < 0 {
+		return errors.New("create_empty_blocks_interval can't be negative")
+	}
+	if cfg.PeerGossipSleepDuration <= 0 {
+		return errors.New("peer_gossip_sleep_duration must be positive")
+	}
+	if cfg.PeerQueryMaj23SleepDuration <= 0 {
+		return errors.New("peer_query_maj23_sleep_duration must be positive")
+	}
+	if cfg.StallTimeout < 0 {
+		return errors.New("stall_timeout can't be negative")
+	}
+	if cfg.PeerStallTimeout < 0 {
+		return errors.New("peer_stall_timeout can't be negative")
+	}
+	if cfg.LateProposalTimeout < 0 {
+		return errors.New("late_proposal_timeout can't be negative")
+	}
+	if cfg.PeerLagTimeout < 0 {
+		return errors.New("peer_lag_timeout can't be negative")
+	}
+	if cfg.StateRecvMessageCapacity < 0 {
+		return errors.New("state_recv_message_capacity can't be negative")
+	}
+	if cfg.DataRecvMessageCapacity < 0 {
+		return errors.New("data_recv_message_capacity can't be negative")
+	}
+	if cfg.VoteRecvMessageCapacity < 0 {
+		return errors.New("vote_recv_message_capacity can't be negative")
+	}
+	if cfg.VoteSetBitsRecvMessageCapacity < 0 {
+		return errors.New("vote_set_bits_recv_message_capacity can't be negative")
+	}
+	switch cfg.PeerMsgQueueDropPolicy {
+	case "", PeerMsgQueueBlock, PeerMsgQueueDropOldest, PeerMsgQueueRejectNewest:
+	default:
+		return fmt.Errorf("unknown peer_msg_queue_drop_policy %q", cfg.PeerMsgQueueDropPolicy)
+	}
+	return nil
+}
+
 // ------------------------- Consensus Params ----------------------------
 type FastSyncConfig struct {
 	ServiceName   string        // log tag of blockchain reactor logs
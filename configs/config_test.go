@@ -0,0 +1,82 @@
+/*
+ *  Copyright 2018 KardiaChain
+ *  This file is part of the go-kardia library.
+ *
+ *  The go-kardia library is free software: you can redistribute it and/or modify
+ *  it under the terms of the GNU Lesser General Public License as published by
+ *  the Free Software Foundation, either version 3 of the License, or
+ *  (at your option) any later version.
+ *
+ *  The go-kardia library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ *  GNU Lesser General Public License for more details.
+ *
+ *  You should have received a copy of the GNU Lesser General Public License
+ *  along with the go-kardia library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultConsensusConfig(t *testing.T) {
+	cfg := DefaultConsensusConfig()
+	assert.NoError(t, cfg.ValidateBasic())
+	assert.Equal(t, 3000*time.Millisecond, cfg.TimeoutPropose)
+	assert.Equal(t, 100*time.Millisecond, cfg.PeerGossipSleep())
+	assert.Equal(t, 2000*time.Millisecond, cfg.PeerQueryMaj23Sleep())
+	assert.Equal(t, PeerMsgQueueBlock, cfg.PeerMsgQueueDropPolicy)
+
+	assert.NoError(t, TestConsensusConfig().ValidateBasic())
+}
+
+func TestConsensusConfigValidateBasic(t *testing.T) {
+	testCases := []struct {
+		field     string
+		modify    func(*ConsensusConfig)
+		expectErr bool
+	}{
+		{"TimeoutPropose", func(c *ConsensusConfig) { c.TimeoutPropose = 0 }, true},
+		{"TimeoutPropose", func(c *ConsensusConfig) { c.TimeoutPropose = -1 }, true},
+		{"TimeoutProposeDelta", func(c *ConsensusConfig) { c.TimeoutProposeDelta = 0 }, false},
+		{"TimeoutProposeDelta", func(c *ConsensusConfig) { c.TimeoutProposeDelta = -1 }, true},
+		{"TimeoutPrevote", func(c *ConsensusConfig) { c.TimeoutPrevote = 0 }, true},
+		{"TimeoutPrevoteDelta", func(c *ConsensusConfig) { c.TimeoutPrevoteDelta = -1 }, true},
+		{"TimeoutPrecommit", func(c *ConsensusConfig) { c.TimeoutPrecommit = 0 }, true},
+		{"TimeoutPrecommitDelta", func(c *ConsensusConfig) { c.TimeoutPrecommitDelta = -1 }, true},
+		{"TimeoutCommit", func(c *ConsensusConfig) { c.TimeoutCommit = 0 }, false},
+		{"TimeoutCommit", func(c *ConsensusConfig) { c.TimeoutCommit = -1 }, true},
+		{"CreateEmptyBlocksInterval", func(c *ConsensusConfig) { c.CreateEmptyBlocksInterval = -1 }, true},
+		{"PeerGossipSleepDuration", func(c *ConsensusConfig) { c.PeerGossipSleepDuration = 0 }, true},
+		{"PeerQueryMaj23SleepDuration", func(c *ConsensusConfig) { c.PeerQueryMaj23SleepDuration = 0 }, true},
+		{"StallTimeout", func(c *ConsensusConfig) { c.StallTimeout = -1 }, true},
+		{"PeerStallTimeout", func(c *ConsensusConfig) { c.PeerStallTimeout = 0 }, false},
+		{"PeerStallTimeout", func(c *ConsensusConfig) { c.PeerStallTimeout = -1 }, true},
+		{"LateProposalTimeout", func(c *ConsensusConfig) { c.LateProposalTimeout = time.Second }, false},
+		{"LateProposalTimeout", func(c *ConsensusConfig) { c.LateProposalTimeout = -1 }, true},
+		{"PeerLagTimeout", func(c *ConsensusConfig) { c.PeerLagTimeout = time.Minute }, false},
+		{"PeerLagTimeout", func(c *ConsensusConfig) { c.PeerLagTimeout = -1 }, true},
+		{"DataRecvMessageCapacity", func(c *ConsensusConfig) { c.DataRecvMessageCapacity = 4 << 20 }, false},
+		{"StateRecvMessageCapacity", func(c *ConsensusConfig) { c.StateRecvMessageCapacity = -1 }, true},
+		{"DataRecvMessageCapacity", func(c *ConsensusConfig) { c.DataRecvMessageCapacity = -1 }, true},
+		{"VoteRecvMessageCapacity", func(c *ConsensusConfig) { c.VoteRecvMessageCapacity = -1 }, true},
+		{"VoteSetBitsRecvMessageCapacity", func(c *ConsensusConfig) { c.VoteSetBitsRecvMessageCapacity = -1 }, true},
+		{"PeerMsgQueueDropPolicy", func(c *ConsensusConfig) { c.PeerMsgQueueDropPolicy = PeerMsgQueueDropOldest }, false},
+		{"PeerMsgQueueDropPolicy", func(c *ConsensusConfig) { c.PeerMsgQueueDropPolicy = "drop_all" }, true},
+	}
+	for _, tc := range testCases {
+		cfg := DefaultConsensusConfig()
+		tc.modify(cfg)
+		if tc.expectErr {
+			assert.Error(t, cfg.ValidateBasic(), tc.field)
+		} else {
+			assert.NoError(t, cfg.ValidateBasic(), tc.field)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ *  Copyright 2018 KardiaChain
+ *  This file is part of the go-kardia library.
+ *
+ *  The go-kardia library is free software: you can redistribute it and/or modify
+ *  it under the terms of the GNU Lesser General Public License as published by
+ *  the Free Software Foundation, either version 3 of the License, or
+ *  (at your option) any later version.
+ *
+ *  The go-kardia library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ *  GNU Lesser General Public License for more details.
+ *
+ *  You should have received a copy of the GNU Lesser General Public License
+ *  along with the go-kardia library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package consensus
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	"github.com/kardiachain/go-kardia/lib/p2p/conn"
+)
+
+// DisconnectReason categorizes why a peer was removed from the consensus manager.
+type DisconnectReason uint8
+
+const (
+	// DisconnectUnknown is used for reasons that cannot be categorized.
+	DisconnectUnknown DisconnectReason = iota
+	// DisconnectShutdown is used when a peer is stopped gracefully.
+	DisconnectShutdown
+	// DisconnectTimeout is used when a peer stopped responding in time.
+	DisconnectTimeout
+	// DisconnectConnectionClosed is used when the underlying connection was closed.
+	DisconnectConnectionClosed
+	// DisconnectProtocolError is used when a peer misbehaved or sent invalid data.
+	DisconnectProtocolError
+)
+
+// String implements fmt.Stringer.
+func (r DisconnectReason) String() string {
+	switch r {
+	case DisconnectShutdown:
+		return "shutdown"
+	case DisconnectTimeout:
+		return "timeout"
+	case DisconnectConnectionClosed:
+		return "connection_closed"
+	case DisconnectProtocolError:
+		return "protocol_error"
+	default:
+		return "unknown"
+	}
+}
+
+// disconnectReasonOf maps the reason passed to RemovePeer to a DisconnectReason.
+func disconnectReasonOf(reason interface{}) DisconnectReason {
+	if reason == nil {
+		return DisconnectShutdown
+	}
+	err, ok := reason.(error)
+	if !ok {
+		return DisconnectUnknown
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, conn.ErrPongTimeout):
+		return DisconnectTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return DisconnectTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, net.ErrClosed):
+		return DisconnectConnectionClosed
+	default:
+		return DisconnectProtocolError
+	}
+}
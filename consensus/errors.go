@@ -19,4 +19,10 @@ var (
 	ErrConsensusMgrNotRunning   = errors.New("consensus manager is not running")
 	ErrInvalidStep              = errors.New("invalid step")
 	ErrWrongLastCommitRound     = errors.New("invalid last commit round")
+	ErrInvalidProposalSignature = errors.New("error invalid proposal signature")
+	ErrTooManyBlockParts        = errors.New("too many block parts")
+	ErrInvalidProposalPOLSize   = errors.New("invalid ProposalPOL bit array size")
+	ErrWrongChannel             = errors.New("message received on the wrong channel")
+	ErrConflictingProposal      = errors.New("conflicting proposal")
+	ErrPeerLagging              = errors.New("peer lagging behind")
 )
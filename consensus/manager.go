@@ -19,8 +19,11 @@
 package consensus
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
+	"runtime/debug"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,8 +33,11 @@ import (
 	cstypes "github.com/kardiachain/go-kardia/consensus/types"
 	"github.com/kardiachain/go-kardia/kai/state/cstate"
 	cmn "github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/common/lru"
+	"github.com/kardiachain/go-kardia/lib/crypto"
 	kevents "github.com/kardiachain/go-kardia/lib/events"
 	"github.com/kardiachain/go-kardia/lib/log"
+	"github.com/kardiachain/go-kardia/lib/metrics"
 	"github.com/kardiachain/go-kardia/lib/p2p"
 	kcons "github.com/kardiachain/go-kardia/proto/kardiachain/consensus"
 	kproto "github.com/kardiachain/go-kardia/proto/kardiachain/types"
@@ -50,34 +56,123 @@ const (
 	votesToContributeToBecomeGoodPeer  = 10000
 
 	subscriber = "consensus-manager"
+
+	// newRoundStepResendInterval is how long an unchanged round step is
+	// withheld from peers before it is broadcast again.
+	newRoundStepResendInterval = time.Second
+
+	// seenProposalsSize is the number of recently queued proposals remembered.
+	seenProposalsSize = 128
+
+	// peerResetHeights is how many heights a peer must go back by before its
+	// state is dropped; smaller decreases are ignored like any stale round step.
+	peerResetHeights = 2
 )
 
+// peerMsgQueueDroppedCounter counts the peer messages dropped because the
+// consensus message queue was full.
+var peerMsgQueueDroppedCounter = metrics.NewRegisteredCounter("consensus/peermsgqueue/dropped", nil)
+
+// ConsensusService is the part of the consensus state that the manager follows
+// and feeds. It is implemented by ConsensusState.
+type ConsensusService interface {
+	// GetRoundState returns a copy of the current round state.
+	GetRoundState() *cstypes.RoundState
+	// Config returns the consensus configuration.
+	Config() *configs.ConsensusConfig
+	// EventSwitch fires the round steps, votes and valid blocks to broadcast.
+	EventSwitch() kevents.EventSwitch
+	// PeerMsgQueue receives the messages from peers.
+	PeerMsgQueue() chan msgInfo
+	// GetState returns a shallow copy of the latest block state.
+	GetState() cstate.LatestBlockState
+	// GetProposalSlot returns a consistent snapshot of the current slot.
+	GetProposalSlot() ProposalSlot
+	// PrivValidator returns the account signing our votes.
+	PrivValidator() types.PrivValidator
+	// SetPrivValidator sets the account signing our votes.
+	SetPrivValidator(priv types.PrivValidator)
+	// SetEventBus sets the bus the consensus events are published on.
+	SetEventBus(b *types.EventBus)
+	// BlockOperations returns the store of committed blocks.
+	BlockOperations() BaseBlockOperations
+	// LoadCommit loads the commit for a committed height.
+	LoadCommit(height uint64) *types.Commit
+	// ResetToState moves consensus to the state reached by fast sync.
+	ResetToState(state cstate.LatestBlockState, skipWAL bool)
+
+	// Start, Stop and Wait run the consensus state machine.
+	Start() error
+	Stop() error
+	Wait()
+}
+
+// ProposalSlot is the height and round being decided, which incoming proposals
+// are checked against.
+type ProposalSlot struct {
+	Height          uint64
+	Round           uint32
+	Proposer        *types.Validator // nil if there is no validator set yet
+	ProposeDeadline time.Time        // zero before the propose step is entered
+}
+
 // ConsensusManager defines a manager for the consensus service.
 type ConsensusManager struct {
-	p2p.BaseReactor // BaseService + p2p.Switch
-	conS            *ConsensusState
+	p2p.BaseReactor                  // BaseService + p2p.Switch
+	service         ConsensusService // the ConsensusState, unless replaced in tests
 	waitSync        bool
 	targetPending   int
 	mtx             sync.RWMutex
 	eventBus        *types.EventBus
+	chainID         string // chain the incoming proposal/vote signatures are verified against
+	metrics         *Metrics
+
+	progressMtx    sync.Mutex
+	progressHeight uint64    // height last seen by Healthy
+	progressRound  uint32    // round last seen by Healthy
+	progressTime   time.Time // when progressHeight/progressRound was first seen
+
+	nrsMtx  sync.Mutex
+	lastNRS *NewRoundStepMessage // last round step broadcast to all peers
+	nrsTime time.Time            // when lastNRS was broadcast
+
+	seenProposals *lru.Cache[cmn.Hash, struct{}] // hashes of proposals recently queued, from any peer
+
+	routinesMtx  sync.Mutex
+	peerRoutines map[p2p.ID]int // number of gossip routines running for each peer
 }
 
 // NewConsensusManager returns a new ConsensusManager with the given
 // consensusState.
-func NewConsensusManager(consensusState *ConsensusState, waitSync *configs.FastSyncConfig) *ConsensusManager {
+func NewConsensusManager(consensusState *ConsensusState, waitSync *configs.FastSyncConfig, options ...ManagerOption) *ConsensusManager {
 	conR := &ConsensusManager{
-		conS:          consensusState,
+		service:       consensusState,
 		waitSync:      waitSync.Enable,
 		targetPending: waitSync.TargetPending,
+		chainID:       consensusState.state.ChainID,
+		metrics:       NopMetrics(),
+		seenProposals: lru.NewCache[cmn.Hash, struct{}](seenProposalsSize),
+		peerRoutines:  make(map[p2p.ID]int),
 	}
 	conR.BaseReactor = *p2p.NewBaseReactor("Consensus", conR)
+	for _, option := range options {
+		option(conR)
+	}
 	return conR
 }
 
+// ManagerOption sets an optional parameter on the ConsensusManager.
+type ManagerOption func(*ConsensusManager)
+
+// WithMetrics sets the metrics.
+func WithMetrics(metrics *Metrics) ManagerOption {
+	return func(conR *ConsensusManager) { conR.metrics = metrics }
+}
+
 // SetEventBus sets event bus.
 func (conR *ConsensusManager) SetEventBus(b *types.EventBus) {
 	conR.eventBus = b
-	conR.conS.SetEventBus(b)
+	conR.service.SetEventBus(b)
 }
 
 // WaitSync returns whether the consensus reactor is in fast-sync mode.
@@ -87,27 +182,138 @@ func (conR *ConsensusManager) WaitSync() bool {
 	return conR.waitSync
 }
 
+// Healthy reports whether consensus is making progress. It returns false with
+// a reason if we are still syncing, if the height/round has not advanced within
+// the configured stall timeout, or if no peer is at our height.
+func (conR *ConsensusManager) Healthy() (bool, string) {
+	if conR.WaitSync() {
+		return false, "waiting for sync"
+	}
+	rs := conR.service.GetRoundState()
+
+	conR.progressMtx.Lock()
+	now := time.Now()
+	if rs.Height != conR.progressHeight || rs.Round != conR.progressRound {
+		conR.progressHeight, conR.progressRound, conR.progressTime = rs.Height, rs.Round, now
+	}
+	stalled := now.Sub(conR.progressTime)
+	conR.progressMtx.Unlock()
+
+	if timeout := conR.service.Config().StallTimeout; timeout > 0 && stalled > timeout {
+		return false, fmt.Sprintf("consensus stalled at height %d round %d for %v", rs.Height, rs.Round, stalled)
+	}
+
+	for _, peer := range conR.Switch.Peers().List() {
+		ps, ok := peer.Get(types.PeerStateKey).(*PeerState)
+		if ok && ps.GetHeight() >= rs.Height {
+			return true, ""
+		}
+	}
+	return false, fmt.Sprintf("no peers at height %d", rs.Height)
+}
+
+// PeersAtHeight returns the number of connected peers at the given height.
+func (conR *ConsensusManager) PeersAtHeight(height uint64) int {
+	n := 0
+	for _, peer := range conR.Switch.Peers().List() {
+		ps, ok := peer.Get(types.PeerStateKey).(*PeerState)
+		if ok && ps.GetHeight() == height {
+			n++
+		}
+	}
+	return n
+}
+
+// VoteSummary returns how many prevotes and precommits have been collected for
+// the current round, along with the round itself.
+func (conR *ConsensusManager) VoteSummary() (prevotes, precommits int, round uint32) {
+	rs := conR.service.GetRoundState()
+	if rs.Votes == nil {
+		return 0, 0, rs.Round
+	}
+	return countVotes(rs.Votes.Prevotes(rs.Round)), countVotes(rs.Votes.Precommits(rs.Round)), rs.Round
+}
+
+// countVotes returns the number of validators with a vote in votes.
+func countVotes(votes *types.VoteSet) int {
+	var (
+		bitArray = votes.BitArray()
+		n        int
+	)
+	for i := 0; i < bitArray.Size(); i++ {
+		if bitArray.GetIndex(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// PeerStateInfo is a snapshot of a peer's consensus round state.
+type PeerStateInfo struct {
+	NodeID     p2p.ID                  `json:"node_id"`
+	RoundState *cstypes.PeerRoundState `json:"round_state"`
+}
+
+// GetPeerStates returns the round state of every connected peer, sorted by
+// node ID so that consensus dumps are stable.
+func (conR *ConsensusManager) GetPeerStates() []PeerStateInfo {
+	peers := conR.Switch.Peers().List()
+	states := make([]PeerStateInfo, 0, len(peers))
+	for _, peer := range peers {
+		ps, ok := peer.Get(types.PeerStateKey).(*PeerState)
+		if !ok {
+			continue
+		}
+		states = append(states, PeerStateInfo{NodeID: peer.ID(), RoundState: ps.GetRoundState()})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].NodeID < states[j].NodeID
+	})
+	return states
+}
+
+// SetChainID sets the chain ID used to verify proposal and vote signatures.
+func (conR *ConsensusManager) SetChainID(chainID string) {
+	conR.mtx.Lock()
+	defer conR.mtx.Unlock()
+	conR.chainID = chainID
+}
+
+// ChainID returns the chain ID used to verify proposal and vote signatures.
+func (conR *ConsensusManager) ChainID() string {
+	conR.mtx.RLock()
+	defer conR.mtx.RUnlock()
+	return conR.chainID
+}
+
 func (conR *ConsensusManager) SetPrivValidator(priv types.PrivValidator) {
-	conR.conS.SetPrivValidator(priv)
+	conR.service.SetPrivValidator(priv)
 }
 
 func (conR *ConsensusManager) Validator() *types.Validator {
-	if _, val := conR.conS.Validators.GetByAddress(conR.conS.privValidator.GetAddress()); val != nil {
+	rs := conR.service.GetRoundState()
+	if _, val := rs.Validators.GetByAddress(conR.service.PrivValidator().GetAddress()); val != nil {
 		return val
 	}
 	return nil
 }
 
 func (conR *ConsensusManager) Validators() []*types.Validator {
-	return conR.conS.Validators.CurrentValidators()
+	return conR.service.GetRoundState().Validators.CurrentValidators()
 }
 
 func (conR *ConsensusManager) OnStart() error {
 	conR.Logger.Info("Consensus manager ", "waitSync", conR.WaitSync())
 	conR.subscribeToBroadcastEvents()
 
+	// Start the stall clock now rather than on the first Healthy probe.
+	rs := conR.service.GetRoundState()
+	conR.progressMtx.Lock()
+	conR.progressHeight, conR.progressRound, conR.progressTime = rs.Height, rs.Round, time.Now()
+	conR.progressMtx.Unlock()
+
 	if !conR.WaitSync() {
-		err := conR.conS.Start()
+		err := conR.service.Start()
 		if err != nil {
 			return err
 		}
@@ -119,11 +325,11 @@ func (conR *ConsensusManager) OnStart() error {
 
 func (conR *ConsensusManager) OnStop() {
 	conR.unsubscribeFromBroadcastEvents()
-	if err := conR.conS.Stop(); err != nil {
+	if err := conR.service.Stop(); err != nil {
 		conR.Logger.Error("Error stopping consensus state", "err", err)
 	}
 	if !conR.WaitSync() {
-		conR.conS.Wait()
+		conR.service.Wait()
 	}
 }
 
@@ -132,23 +338,13 @@ func (conR *ConsensusManager) OnStop() {
 func (conR *ConsensusManager) SwitchToConsensus(state cstate.LatestBlockState, skipWAL bool) {
 	conR.Logger.Info("Switching to consensus", "block height", state.LastBlockHeight, "skipWAL", skipWAL)
 
-	// We have no votes, so reconstruct LastCommit from SeenCommit.
-	if state.LastBlockHeight > 0 {
-		conR.conS.reconstructLastCommit(state)
-	}
-
-	// NOTE: The line below causes broadcastNewRoundStepRoutine() to broadcast a
-	// NewRoundStepMessage.
-	conR.conS.updateToState(state)
+	conR.service.ResetToState(state, skipWAL)
 
 	conR.mtx.Lock()
 	conR.waitSync = false
 	conR.mtx.Unlock()
 
-	if skipWAL {
-		conR.conS.doWALCatchup = false
-	}
-	err := conR.conS.Start()
+	err := conR.service.Start()
 	if err != nil {
 		panic(fmt.Sprintf(`Failed to start consensus state: %v
 
@@ -156,7 +352,7 @@ conS:
 %+v
 
 conR:
-%+v`, err, conR.conS, conR))
+%+v`, err, conR.service, conR))
 	}
 	conR.Logger.Info("Switched to consensus", "skipWAL", skipWAL)
 }
@@ -164,12 +360,13 @@ conR:
 // GetChannels implements Reactor
 func (conR *ConsensusManager) GetChannels() []*p2p.ChannelDescriptor {
 	// TODO optimize
+	config := conR.service.Config()
 	return []*p2p.ChannelDescriptor{
 		{
 			ID:                  StateChannel,
 			Priority:            8,
 			SendQueueCapacity:   64,
-			RecvMessageCapacity: maxMsgSize,
+			RecvMessageCapacity: recvMessageCapacity(config.StateRecvMessageCapacity),
 			RecvBufferCapacity:  4096,
 		},
 		{
@@ -177,25 +374,34 @@ func (conR *ConsensusManager) GetChannels() []*p2p.ChannelDescriptor {
 			Priority:            12,
 			SendQueueCapacity:   64,
 			RecvBufferCapacity:  8388608, // 8 Mbs
-			RecvMessageCapacity: maxMsgSize,
+			RecvMessageCapacity: recvMessageCapacity(config.DataRecvMessageCapacity),
 		},
 		{
 			ID:                  VoteChannel,
 			Priority:            10,
 			SendQueueCapacity:   64,
 			RecvBufferCapacity:  524288, // 512 Kbs
-			RecvMessageCapacity: maxMsgSize,
+			RecvMessageCapacity: recvMessageCapacity(config.VoteRecvMessageCapacity),
 		},
 		{
 			ID:                  VoteSetBitsChannel,
 			Priority:            5,
 			SendQueueCapacity:   8,
 			RecvBufferCapacity:  4096,
-			RecvMessageCapacity: maxMsgSize,
+			RecvMessageCapacity: recvMessageCapacity(config.VoteSetBitsRecvMessageCapacity),
 		},
 	}
 }
 
+// recvMessageCapacity returns the configured receive message capacity of a
+// channel, or maxMsgSize if it is not set.
+func recvMessageCapacity(configured int) int {
+	if configured <= 0 {
+		return maxMsgSize
+	}
+	return configured
+}
+
 // InitPeer implements Reactor by creating a state for the peer.
 func (conR *ConsensusManager) InitPeer(peer p2p.Peer) p2p.Peer {
 	peerState := NewPeerState(peer).SetLogger(conR.Logger)
@@ -217,22 +423,69 @@ func (conR *ConsensusManager) AddPeer(peer p2p.Peer) {
 	}
 
 	// Begin routines for this peer.
-	go conR.gossipDataRoutine(peer, peerState)
-	go conR.gossipVotesRoutine(peer, peerState)
-	go conR.queryMaj23Routine(peer, peerState)
+	conR.startPeerRoutine(peer, peerState, conR.gossipDataRoutine)
+	conR.startPeerRoutine(peer, peerState, conR.gossipVotesRoutine)
+	conR.startPeerRoutine(peer, peerState, conR.queryMaj23Routine)
 
 	// Send our state to peer.
 	// If we're fast_syncing, broadcast a RoundStepMessage later upon SwitchToConsensus().
 	if !conR.WaitSync() {
 		conR.sendNewRoundStepMessage(peer)
 	}
+	if conR.service.Config().IsRequestCommitHeaders {
+		conR.sendMsg(peer, StateChannel, &NewRoundStepRequestMessage{CommitHeaders: true})
+	}
 }
 
 // RemovePeer cleans up peer state regarding to ConsensusReactor.
 func (conR *ConsensusManager) RemovePeer(p p2p.Peer, reason interface{}) {
+	conR.Logger.Info("Removing peer", "peer", p.ID(), "reason", disconnectReasonOf(reason), "err", reason)
 	p.Set(types.PeerStateKey, struct{}{})
 }
 
+// startPeerRoutine runs routine for the peer in a new goroutine, counted in
+// GoroutineCount until it returns.
+func (conR *ConsensusManager) startPeerRoutine(peer p2p.Peer, ps *PeerState, routine func(p2p.Peer, *PeerState)) {
+	id := peer.ID()
+	conR.routinesMtx.Lock()
+	conR.peerRoutines[id]++
+	conR.routinesMtx.Unlock()
+	go func() {
+		defer func() {
+			conR.routinesMtx.Lock()
+			defer conR.routinesMtx.Unlock()
+			if conR.peerRoutines[id]--; conR.peerRoutines[id] <= 0 {
+				delete(conR.peerRoutines, id)
+			}
+		}()
+		routine(peer, ps)
+	}()
+}
+
+// GoroutineCount returns the number of gossip routines running for peers.
+// They return once their peer is stopped, so this drops to zero after all
+// peers are removed.
+func (conR *ConsensusManager) GoroutineCount() int {
+	conR.routinesMtx.Lock()
+	defer conR.routinesMtx.Unlock()
+	n := 0
+	for _, count := range conR.peerRoutines {
+		n += count
+	}
+	return n
+}
+
+// recoverPeer recovers from a panic in a routine serving peer, stopping the
+// peer instead of letting a misbehaving peer take the node down. It must be
+// deferred directly.
+func (conR *ConsensusManager) recoverPeer(peer p2p.Peer, routine string) {
+	if r := recover(); r != nil {
+		conR.Logger.Error("Consensus routine panicked", "routine", routine, "peer", peer.ID(),
+			"err", r, "stack", string(debug.Stack()))
+		conR.Switch.StopPeerForError(peer, fmt.Errorf("%s panicked: %v", routine, r))
+	}
+}
+
 // Receive implements Reactor
 // NOTE: We process these messages even when we're fast_syncing.
 // Messages affect either a peer state or the consensus state.
@@ -240,6 +493,8 @@ func (conR *ConsensusManager) RemovePeer(p p2p.Peer, reason interface{}) {
 // proposals, block parts, and votes are ordered by the receiveRoutine
 // NOTE: blocks on consensus state for proposals, block parts, and votes
 func (conR *ConsensusManager) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
+	defer conR.recoverPeer(src, "Receive")
+
 	if !conR.IsRunning() {
 		conR.Logger.Debug("Receive", "src", src, "chId", chID, "bytes", msgBytes)
 		return
@@ -252,13 +507,22 @@ func (conR *ConsensusManager) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 		return
 	}
 
+	logger := conR.Logger.New(append([]interface{}{"peer", src.ID()}, msgLogContext(msg)...)...)
 	if err = msg.ValidateBasic(); err != nil {
-		conR.Logger.Error("peer sent us invalid msg", "peer", src, "msg", msg, "err", err)
+		logger.Error("peer sent us invalid msg", "msg", msg, "err", err)
+		conR.Switch.StopPeerForError(src, err)
+		return
+	}
+
+	if expected, ok := msgChannel(msg); ok && expected != chID {
+		err := fmt.Errorf("%w: %v on channel %#x, expected %#x", ErrWrongChannel, messageType(msg), chID, expected)
+		logger.Error("peer sent us a message on the wrong channel", "err", err)
 		conR.Switch.StopPeerForError(src, err)
 		return
 	}
 
-	conR.Logger.Debug("Receive", "src", src, "chId", chID, "msg", msg)
+	logger.Debug("Receive", "chId", chID, "msg", msg)
+	conR.metrics.PeerReceiveMessages.With("peer_id", string(src.ID()), "message_type", messageType(msg)).Add(1)
 
 	// Get peer states
 	ps, ok := src.Get(types.PeerStateKey).(*PeerState)
@@ -268,39 +532,323 @@ func (conR *ConsensusManager) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 
 	switch chID {
 	case StateChannel:
-		switch msg := msg.(type) {
-		case *NewRoundStepMessage:
-			cs := conR.conS
-			cs.mtx.Lock()
-			initialHeight := cs.state.InitialHeight
-			cs.mtx.Unlock()
-
-			if err := msg.ValidateHeight(initialHeight); err != nil {
-				conR.Logger.Warn("peer sent us an invalid msg", "msg", msg, "err", err)
-				return
-			}
+		conR.receiveStateMessage(logger, src, ps, msg)
+	case DataChannel:
+		conR.receiveDataMessage(logger, src, ps, msg)
+	case VoteChannel:
+		conR.receiveVoteMessage(logger, src, ps, msg)
+	case VoteSetBitsChannel:
+		conR.receiveVoteSetBitsMessage(logger, src, ps, msg)
+	default:
+		logger.Error(fmt.Sprintf("Unknown chId %X", chID))
+	}
+}
+
+// receiveStateMessage handles messages received on the StateChannel.
+func (conR *ConsensusManager) receiveStateMessage(logger log.Logger, src p2p.Peer, ps *PeerState, msg Message) {
+	switch msg := msg.(type) {
+	case *NewRoundStepMessage:
+		// Chatty peers repeat their round step, only the elapsed time changing.
+		if ps.seenRoundStep(msg) {
+			return
+		}
+		initialHeight := conR.service.GetState().InitialHeight
+		rs := conR.service.GetRoundState()
+		height, valSize, lastCommitSize := rs.Height, rs.Validators.Size(), rs.LastCommit.Size()
+
+		if err := msg.ValidateHeight(initialHeight); err != nil {
+			logger.Warn("peer sent us an invalid msg", "msg", msg, "err", err)
+			return
+		}
+
+		if err := ps.ApplyNewRoundStepMessage(msg); err != nil {
+			logger.Warn("peer sent us an invalid msg", "msg", msg, "err", err)
+			return
+		}
+		// Size the vote bit arrays reset by a new round, so that the votes
+		// the peer announces from now on are tracked.
+		ps.EnsureVoteBitArrays(height, valSize)
+		ps.EnsureVoteBitArrays(height-1, lastCommitSize)
+		ps.rememberRoundStep(msg)
+	case *NewRoundStepRequestMessage:
+		ps.SetCommitHeaders(msg.CommitHeaders)
+		// Our round step is sent once we switch to consensus.
+		if !conR.WaitSync() {
+			conR.sendNewRoundStepMessage(src)
+		}
+	case *NewValidBlockMessage:
+		if err := conR.checkBlockPartsHeader(msg.BlockPartsHeader); err != nil {
+			logger.Error("peer sent us oversized block parts header", "msg", msg, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		ps.ApplyNewValidBlockMessage(msg)
+	case *HasVoteMessage:
+		if err := ps.ApplyHasVoteMessage(msg); err != nil {
+			logger.Error("peer sent us invalid HasVote", "msg", msg, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+	case *VoteSetMaj23Message:
+		rs := conR.service.GetRoundState()
+		height, votes := rs.Height, rs.Votes
+		if height != msg.Height {
+			return
+		}
+		// Peer claims to have a maj23 for some BlockID at H,R,S,
+		err := votes.SetPeerMaj23(msg.Round, msg.Type, ps.peer.ID(), msg.BlockID)
+		if err != nil {
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		// Respond with a VoteSetBitsMessage showing which votes we have.
+		// (and consequently shows which we don't have)
+		var ourVotes *cmn.BitArray
+		switch msg.Type {
+		case kproto.PrevoteType:
+			ourVotes = votes.Prevotes(msg.Round).BitArrayByBlockID(msg.BlockID)
+		case kproto.PrecommitType:
+			ourVotes = votes.Precommits(msg.Round).BitArrayByBlockID(msg.BlockID)
+		default:
+			panic("Bad VoteSetBitsMessage field Type. Forgot to add a check in ValidateBasic?")
+		}
+		src.TrySend(VoteSetBitsChannel, MustEncode(&VoteSetBitsMessage{
+			Height:  msg.Height,
+			Round:   msg.Round,
+			Type:    msg.Type,
+			BlockID: msg.BlockID,
+			Votes:   ourVotes,
+		}))
+	default:
+		logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
+	}
+}
 
-			ps.ApplyNewRoundStepMessage(msg)
-		case *NewValidBlockMessage:
-			ps.ApplyNewValidBlockMessage(msg)
-		case *HasVoteMessage:
-			ps.ApplyHasVoteMessage(msg)
-		case *VoteSetMaj23Message:
-			cs := conR.conS
-			cs.mtx.Lock()
-			height, votes := cs.Height, cs.Votes
-			cs.mtx.Unlock()
-			if height != msg.Height {
-				return
+// receiveDataMessage handles messages received on the DataChannel.
+func (conR *ConsensusManager) receiveDataMessage(logger log.Logger, src p2p.Peer, ps *PeerState, msg Message) {
+	switch msg := msg.(type) {
+	case *ProposalMessage:
+		if err := conR.checkBlockPartsHeader(msg.Proposal.POLBlockID.PartsHeader); err != nil {
+			logger.Error("peer sent us oversized proposal", "proposal", msg.Proposal, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		// The state ignores proposals for other slots, and the signature of
+		// one replayed from another round can't be checked against its proposer.
+		// All checks use the same snapshot, so that a proposal for one slot is
+		// never checked against the proposer of the next.
+		slot := conR.service.GetProposalSlot()
+		if !isCurrentSlot(msg.Proposal, slot) {
+			logger.Debug("Dropping proposal for another height or round", "proposal", msg.Proposal)
+			return
+		}
+		// Late proposals may be due to the network, don't punish the peer.
+		if conR.isLateProposal(msg.Proposal, slot, time.Now()) {
+			logger.Info("Dropping late proposal", "proposal", msg.Proposal)
+			return
+		}
+		if err := conR.verifyProposalSignature(msg.Proposal, slot); err != nil {
+			logger.Error("peer sent us invalid proposal", "proposal", msg.Proposal, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		// Each proposal is queued once per peer. A second, validly signed
+		// proposal for the same height and round means the proposer signed
+		// two, which the peer relaying them is not to blame for.
+		isNew, err := ps.recordReceivedProposal(msg.Proposal)
+		if err != nil {
+			logger.Error("Dropping conflicting proposal", "proposal", msg.Proposal, "err", err)
+			return
+		}
+		if !isNew {
+			logger.Debug("Dropping duplicate proposal", "proposal", msg.Proposal)
+			return
+		}
+		ps.SetHasProposal(msg.Proposal)
+		// Proposals relayed by several peers are queued once. One rejected by
+		// a full queue is forgotten, so that a later relay can still queue it.
+		if conR.seenProposal(msg.Proposal) {
+			logger.Debug("Dropping proposal already received from another peer", "proposal", msg.Proposal)
+			return
+		}
+		if !conR.queuePeerMsg(msgInfo{msg, src.ID()}) {
+			conR.forgetProposal(msg.Proposal)
+		}
+	case *ProposalPOLMessage:
+		if err := conR.checkProposalPOLSize(msg); err != nil {
+			logger.Error("peer sent us invalid ProposalPOL", "msg", msg, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		ps.ApplyProposalPOLMessage(msg)
+	case *BlockPartMessage:
+		if max := conR.maxBlockPartsCount(); msg.Part.Index >= max {
+			err := fmt.Errorf("%w: part index %d, max: %d", ErrTooManyBlockParts, msg.Part.Index, max)
+			logger.Error("peer sent us out of bounds block part", "msg", msg, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		ps.SetHasProposalBlockPart(msg.Height, msg.Round, int(msg.Part.Index))
+		//conR.Metrics.BlockParts.With("peer_id", string(src.ID())).Add(1)
+		conR.queuePeerMsg(msgInfo{msg, src.ID()})
+	default:
+		logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
+	}
+}
+
+// queuePeerMsg hands a peer message over to the consensus state. If the queue
+// is full, the configured drop policy decides whether to wait for room, drop
+// the oldest queued message or reject the new one. Returns whether mi was queued.
+func (conR *ConsensusManager) queuePeerMsg(mi msgInfo) bool {
+	queue := conR.service.PeerMsgQueue()
+	switch conR.service.Config().PeerMsgQueueDropPolicy {
+	case configs.PeerMsgQueueDropOldest:
+		for {
+			select {
+			case queue <- mi:
+				return true
+			default:
 			}
-			// Peer claims to have a maj23 for some BlockID at H,R,S,
-			err := votes.SetPeerMaj23(msg.Round, msg.Type, ps.peer.ID(), msg.BlockID)
-			if err != nil {
-				conR.Switch.StopPeerForError(src, err)
-				return
+			select {
+			case dropped := <-queue:
+				peerMsgQueueDroppedCounter.Inc(1)
+				conR.Logger.Debug("Peer message queue is full, dropped oldest message", "msg", dropped.Msg, "peer", dropped.PeerID)
+			default:
 			}
-			// Respond with a VoteSetBitsMessage showing which votes we have.
-			// (and consequently shows which we don't have)
+		}
+	case configs.PeerMsgQueueRejectNewest:
+		select {
+		case queue <- mi:
+			return true
+		default:
+			peerMsgQueueDroppedCounter.Inc(1)
+			conR.Logger.Debug("Peer message queue is full, rejected message", "msg", mi.Msg, "peer", mi.PeerID)
+			return false
+		}
+	default:
+		queue <- mi
+		return true
+	}
+}
+
+// seenProposal reports whether the proposal was already seen, remembering it
+// otherwise.
+func (conR *ConsensusManager) seenProposal(proposal *types.Proposal) bool {
+	hash, ok := proposalHash(proposal)
+	if !ok {
+		return false
+	}
+	return conR.seenProposals.ContainsOrAdd(hash, struct{}{})
+}
+
+// forgetProposal removes the proposal from the seen proposals.
+func (conR *ConsensusManager) forgetProposal(proposal *types.Proposal) {
+	if hash, ok := proposalHash(proposal); ok {
+		conR.seenProposals.Remove(hash)
+	}
+}
+
+// proposalHash returns the hash the proposal is remembered by.
+func proposalHash(proposal *types.Proposal) (cmn.Hash, bool) {
+	bz, err := proposal.ToProto().Marshal()
+	if err != nil {
+		return cmn.Hash{}, false
+	}
+	return crypto.Keccak256Hash(bz), true
+}
+
+// isCurrentSlot reports whether the proposal is for the height and round of
+// slot.
+func isCurrentSlot(proposal *types.Proposal, slot ProposalSlot) bool {
+	return proposal.Height == slot.Height && proposal.Round == slot.Round
+}
+
+// isLateProposal reports whether a proposal for the slot arrives more than
+// LateProposalTimeout after the propose step of its round timed out.
+func (conR *ConsensusManager) isLateProposal(proposal *types.Proposal, slot ProposalSlot, now time.Time) bool {
+	timeout := conR.service.Config().LateProposalTimeout
+	if timeout <= 0 {
+		return false
+	}
+	if !isCurrentSlot(proposal, slot) || slot.ProposeDeadline.IsZero() {
+		return false
+	}
+	return now.After(slot.ProposeDeadline.Add(timeout))
+}
+
+// checkProposalPOLSize makes sure the POL bit array of a message for our
+// height has one bit per validator, so that it can safely replace the peer's.
+// POLs for other heights can't be checked and are bounded by MaxVotesCount.
+func (conR *ConsensusManager) checkProposalPOLSize(msg *ProposalPOLMessage) error {
+	rs := conR.service.GetRoundState()
+	height, valSize := rs.Height, rs.Validators.Size()
+
+	size := msg.ProposalPOL.Size()
+	if msg.Height == height && size != valSize {
+		return fmt.Errorf("%w: got %d, expected %d", ErrInvalidProposalPOLSize, size, valSize)
+	}
+	if size > types.MaxVotesCount {
+		return fmt.Errorf("%w: got %d, max %d", ErrInvalidProposalPOLSize, size, types.MaxVotesCount)
+	}
+	return nil
+}
+
+// maxBlockPartsCount returns the number of parts a block may be split into
+// under the current consensus params.
+func (conR *ConsensusManager) maxBlockPartsCount() uint32 {
+	maxBytes := conR.service.GetState().ConsensusParams.Block.MaxBytes
+	if maxBytes <= 0 || maxBytes > types.MaxBlockSizeBytes {
+		maxBytes = types.MaxBlockSizeBytes
+	}
+	return uint32(maxBytes/types.BlockPartSizeBytes) + 1
+}
+
+// checkBlockPartsHeader rejects part set headers declaring more parts than a
+// block may have, before any part set is allocated for them.
+func (conR *ConsensusManager) checkBlockPartsHeader(header types.PartSetHeader) error {
+	if max := conR.maxBlockPartsCount(); header.Total > max {
+		return fmt.Errorf("%w: %d, max: %d", ErrTooManyBlockParts, header.Total, max)
+	}
+	return nil
+}
+
+// receiveVoteMessage handles messages received on the VoteChannel.
+func (conR *ConsensusManager) receiveVoteMessage(logger log.Logger, src p2p.Peer, ps *PeerState, msg Message) {
+	switch msg := msg.(type) {
+	case *VoteMessage:
+		rs := conR.service.GetRoundState()
+		height, valSize, lastCommitSize := rs.Height, rs.Validators.Size(), rs.LastCommit.Size()
+		// Only votes for our height and the last commit are of any use to
+		// the consensus state, don't queue stale or future ones.
+		if vote := msg.Vote; vote.Height != height && vote.Height+1 != height {
+			logger.Debug("Dropping vote out of height window", "height", height, "vote", vote)
+			return
+		}
+		if err := conR.verifyVoteSignature(msg.Vote); err != nil {
+			logger.Error("peer sent us invalid vote", "vote", msg.Vote, "err", err)
+			conR.Switch.StopPeerForError(src, err)
+			return
+		}
+		ps.EnsureVoteBitArrays(height, valSize)
+		ps.EnsureVoteBitArrays(height-1, lastCommitSize)
+		ps.SetHasVote(msg.Vote)
+
+		conR.queuePeerMsg(msgInfo{msg, src.ID()})
+
+	default:
+		// don't punish (leave room for soft upgrades)
+		logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
+	}
+}
+
+// receiveVoteSetBitsMessage handles messages received on the VoteSetBitsChannel.
+func (conR *ConsensusManager) receiveVoteSetBitsMessage(logger log.Logger, src p2p.Peer, ps *PeerState, msg Message) {
+	switch msg := msg.(type) {
+	case *VoteSetBitsMessage:
+		rs := conR.service.GetRoundState()
+		height, votes := rs.Height, rs.Votes
+
+		if height == msg.Height {
 			var ourVotes *cmn.BitArray
 			switch msg.Type {
 			case kproto.PrevoteType:
@@ -310,108 +858,150 @@ func (conR *ConsensusManager) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 			default:
 				panic("Bad VoteSetBitsMessage field Type. Forgot to add a check in ValidateBasic?")
 			}
-			src.TrySend(VoteSetBitsChannel, MustEncode(&VoteSetBitsMessage{
-				Height:  msg.Height,
-				Round:   msg.Round,
-				Type:    msg.Type,
-				BlockID: msg.BlockID,
-				Votes:   ourVotes,
-			}))
-		default:
-			conR.Logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
-		}
-	case DataChannel:
-		switch msg := msg.(type) {
-		case *ProposalMessage:
-			ps.SetHasProposal(msg.Proposal)
-			conR.conS.peerMsgQueue <- msgInfo{msg, src.ID()}
-		case *ProposalPOLMessage:
-			ps.ApplyProposalPOLMessage(msg)
-		case *BlockPartMessage:
-			ps.SetHasProposalBlockPart(msg.Height, msg.Round, int(msg.Part.Index))
-			//conR.Metrics.BlockParts.With("peer_id", string(src.ID())).Add(1)
-			conR.conS.peerMsgQueue <- msgInfo{msg, src.ID()}
-		default:
-			conR.Logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
-		}
-	case VoteChannel:
-		switch msg := msg.(type) {
-		case *VoteMessage:
-			cs := conR.conS
-			cs.mtx.RLock()
-			height, valSize, lastCommitSize := cs.Height, cs.Validators.Size(), cs.LastCommit.Size()
-			cs.mtx.RUnlock()
-			ps.EnsureVoteBitArrays(height, valSize)
-			ps.EnsureVoteBitArrays(height-1, lastCommitSize)
-			ps.SetHasVote(msg.Vote)
-
-			cs.peerMsgQueue <- msgInfo{msg, src.ID()}
-
-		default:
-			// don't punish (leave room for soft upgrades)
-			conR.Logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
-		}
-	case VoteSetBitsChannel:
-		switch msg := msg.(type) {
-		case *VoteSetBitsMessage:
-			cs := conR.conS
-			cs.mtx.Lock()
-			height, votes := cs.Height, cs.Votes
-			cs.mtx.Unlock()
-
-			if height == msg.Height {
-				var ourVotes *cmn.BitArray
-				switch msg.Type {
-				case kproto.PrevoteType:
-					ourVotes = votes.Prevotes(msg.Round).BitArrayByBlockID(msg.BlockID)
-				case kproto.PrecommitType:
-					ourVotes = votes.Precommits(msg.Round).BitArrayByBlockID(msg.BlockID)
-				default:
-					panic("Bad VoteSetBitsMessage field Type. Forgot to add a check in ValidateBasic?")
-				}
-				ps.ApplyVoteSetBitsMessage(msg, ourVotes)
-			} else {
-				ps.ApplyVoteSetBitsMessage(msg, nil)
-			}
-		default:
-			// don't punish (leave room for soft upgrades)
-			conR.Logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
+			ps.ApplyVoteSetBitsMessage(msg, ourVotes)
+		} else {
+			ps.ApplyVoteSetBitsMessage(msg, nil)
 		}
 	default:
-		conR.Logger.Error(fmt.Sprintf("Unknown chId %X", chID))
+		// don't punish (leave room for soft upgrades)
+		logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
+	}
+}
+
+// verifyProposalSignature checks that a proposal for the slot was signed by
+// its proposer on our chain. Proposals for any other height/round are left for
+// the consensus state to discard.
+func (conR *ConsensusManager) verifyProposalSignature(proposal *types.Proposal, slot ProposalSlot) error {
+	proposer := slot.Proposer
+	if !isCurrentSlot(proposal, slot) || proposer == nil {
+		return nil
+	}
+	signBytes, err := types.ProposalSignBytes(conR.ChainID(), proposal.ToProto())
+	if err != nil {
+		return err
 	}
+	if !types.VerifySignature(proposer.Address, crypto.Keccak256(signBytes), proposal.Signature) {
+		return ErrInvalidProposalSignature
+	}
+	return nil
+}
+
+// verifyVoteSignature checks that a vote for our current or last height was
+// signed by the validator at its index on our chain.
+func (conR *ConsensusManager) verifyVoteSignature(vote *types.Vote) error {
+	rs := conR.service.GetRoundState()
+	height, validators, lastValidators := rs.Height, rs.Validators, rs.LastValidators
+
+	var valSet *types.ValidatorSet
+	switch vote.Height {
+	case height:
+		valSet = validators
+	case height - 1:
+		valSet = lastValidators
+	}
+	if valSet == nil {
+		return nil
+	}
+	address, val := valSet.GetByIndex(vote.ValidatorIndex)
+	if val == nil {
+		return types.ErrVoteInvalidValidatorIndex
+	}
+	signBytes := types.VoteSignBytes(conR.ChainID(), vote.ToProto())
+	if !types.VerifySignature(address, crypto.Keccak256(signBytes), vote.Signature) {
+		return types.ErrVoteInvalidSignature
+	}
+	return nil
 }
 
 // subscribeToBroadcastEvents subscribes for new round steps, votes and
 // proposal heartbeats using internal pubsub defined on state to broadcast
 // them to peers upon receiving.
+// It is idempotent: any listener previously registered under subscriber is
+// removed first, so restarting the manager never double-broadcasts.
 func (conR *ConsensusManager) subscribeToBroadcastEvents() {
-	conR.conS.evsw.AddListenerForEvent(subscriber, types.EventNewRoundStep,
+	conR.unsubscribeFromBroadcastEvents()
+
+	if err := conR.service.EventSwitch().AddListenerForEvent(subscriber, types.EventNewRoundStep,
 		func(data kevents.EventData) {
 			conR.broadcastNewRoundStepMessages(data.(*cstypes.RoundState))
-		})
+		}); err != nil {
+		conR.Logger.Error("Error adding listener for events", "event", types.EventNewRoundStep, "err", err)
+	}
 
-	conR.conS.evsw.AddListenerForEvent(subscriber, types.EventVote,
+	if err := conR.service.EventSwitch().AddListenerForEvent(subscriber, types.EventVote,
 		func(data kevents.EventData) {
 			conR.broadcastHasVoteMessage(data.(*types.Vote))
-		})
+		}); err != nil {
+		conR.Logger.Error("Error adding listener for events", "event", types.EventVote, "err", err)
+	}
 
-	conR.conS.evsw.AddListenerForEvent(subscriber, types.EventValidBlock,
+	if err := conR.service.EventSwitch().AddListenerForEvent(subscriber, types.EventValidBlock,
 		func(data kevents.EventData) {
 			conR.broadcastNewValidBlockMessage(data.(*cstypes.RoundState))
-		})
+		}); err != nil {
+		conR.Logger.Error("Error adding listener for events", "event", types.EventValidBlock, "err", err)
+	}
 }
 
 func (conR *ConsensusManager) unsubscribeFromBroadcastEvents() {
-	conR.conS.evsw.RemoveListener(subscriber)
+	conR.service.EventSwitch().RemoveListener(subscriber)
 }
 
 // ------------ Broadcast messages ------------
 
 func (conR *ConsensusManager) broadcastNewRoundStepMessages(rs *cstypes.RoundState) {
 	nrsMsg := makeRoundStepMessage(rs)
+	if !conR.roundStepChanged(nrsMsg) {
+		return
+	}
 	conR.Logger.Trace("broadcastNewRoundStepMessage", "nrsMsg", nrsMsg, "height", rs.Height)
-	conR.Switch.Broadcast(StateChannel, MustEncode(nrsMsg))
+	header := conR.commitHeader(rs)
+	if header == nil {
+		conR.broadcast(StateChannel, nrsMsg)
+		return
+	}
+	// Peers asking for commit headers get their own copy of the message, all
+	// others the plain round step.
+	withHeader := *nrsMsg
+	withHeader.CommitHeader = header
+	withHeaderBytes, plainBytes := MustEncode(&withHeader), MustEncode(nrsMsg)
+	for _, peer := range conR.Switch.Peers().List() {
+		// This runs in the event switch callback, so a peer with a full send
+		// queue misses the message rather than blocking consensus.
+		if ps, ok := peer.Get(types.PeerStateKey).(*PeerState); ok && ps.wantsCommitHeaders() {
+			peer.TrySend(StateChannel, withHeaderBytes)
+		} else {
+			peer.TrySend(StateChannel, plainBytes)
+		}
+	}
+}
+
+// commitHeader returns the header to attach to round step messages for peers
+// asking for commit headers, or nil if there is none to send: sending them is
+// disabled, or we are not committing a block we hold.
+func (conR *ConsensusManager) commitHeader(rs *cstypes.RoundState) *types.Header {
+	if !conR.service.Config().IsSendCommitHeaders ||
+		rs.Step != cstypes.RoundStepCommit || rs.ProposalBlock == nil {
+		return nil
+	}
+	return rs.ProposalBlock.Header()
+}
+
+// roundStepChanged records nrsMsg as the last broadcast round step and reports
+// whether it is worth sending. A message that only differs from the previous
+// one in elapsed time is suppressed until newRoundStepResendInterval passes.
+func (conR *ConsensusManager) roundStepChanged(nrsMsg *NewRoundStepMessage) bool {
+	conR.nrsMtx.Lock()
+	defer conR.nrsMtx.Unlock()
+	if last := conR.lastNRS; last != nil &&
+		last.Height == nrsMsg.Height && last.Round == nrsMsg.Round && last.Step == nrsMsg.Step &&
+		last.LastCommitRound == nrsMsg.LastCommitRound &&
+		time.Since(conR.nrsTime) < newRoundStepResendInterval {
+		return false
+	}
+	conR.lastNRS = nrsMsg
+	conR.nrsTime = time.Now()
+	return true
 }
 
 // Broadcasts HasVoteMessage to peers that care.
@@ -423,27 +1013,139 @@ func (conR *ConsensusManager) broadcastHasVoteMessage(vote *types.Vote) {
 		Index:  vote.ValidatorIndex,
 	}
 	conR.Logger.Trace("broadcastHasVoteMessage", "msg", msg)
-	conR.Switch.Broadcast(StateChannel, MustEncode(msg))
+	conR.broadcast(StateChannel, msg)
 }
 
 func (conR *ConsensusManager) broadcastNewValidBlockMessage(rs *cstypes.RoundState) {
-	msg := &NewValidBlockMessage{
-		Height:           rs.Height,
-		Round:            rs.Round,
-		BlockPartsHeader: rs.ProposalBlockParts.Header(),
-		BlockParts:       rs.ProposalBlockParts.BitArray(),
-		IsCommit:         rs.Step == cstypes.RoundStepCommit,
+	msg := makeNewValidBlockMessage(rs)
+	if msg == nil {
+		conR.Logger.Error("Not broadcasting valid block without proposal block parts",
+			"height", rs.Height, "round", rs.Round, "step", rs.Step)
+		return
 	}
-	conR.Switch.Broadcast(StateChannel, MustEncode(msg))
+	conR.broadcast(StateChannel, msg)
+}
+
+// broadcast sends msg to all peers on chID without waiting for the sends to
+// complete. Once they have, the number of peers that failed to take the
+// message is logged if non-zero and delivered on the returned channel.
+func (conR *ConsensusManager) broadcast(chID byte, msg Message) <-chan int {
+	successChan := conR.Switch.Broadcast(chID, MustEncode(msg))
+	failedChan := make(chan int, 1)
+	go func() {
+		failed, total := 0, 0
+		for success := range successChan {
+			total++
+			if !success {
+				failed++
+			}
+		}
+		if failed > 0 {
+			conR.Logger.Warn(fmt.Sprintf("Broadcast failed for %d/%d peers", failed, total),
+				"chId", chID, "type", reflect.TypeOf(msg))
+		}
+		failedChan <- failed
+	}()
+	return failedChan
 }
 
 // ------------ Send message helpers -----------
 
 func (conR *ConsensusManager) sendNewRoundStepMessage(peer p2p.Peer) {
 	conR.Logger.Debug("manager - sendNewRoundStepMessages")
-	rs := conR.conS.GetRoundState()
+	rs := conR.service.GetRoundState()
 	nrsMsg := makeRoundStepMessage(rs)
-	peer.Send(StateChannel, MustEncode(nrsMsg))
+	if ps, ok := peer.Get(types.PeerStateKey).(*PeerState); ok && ps.wantsCommitHeaders() {
+		nrsMsg.CommitHeader = conR.commitHeader(rs)
+	}
+	conR.sendMsg(peer, StateChannel, nrsMsg)
+}
+
+// sendMsg sends the message to the peer, counting it if it was queued.
+func (conR *ConsensusManager) sendMsg(peer p2p.Peer, chID byte, msg Message) bool {
+	if !peer.Send(chID, MustEncode(msg)) {
+		return false
+	}
+	conR.countSent(peer, messageType(msg))
+	return true
+}
+
+// pickSendVote sends the peer a vote it lacks from votes, counting it if sent.
+func (conR *ConsensusManager) pickSendVote(ps *PeerState, votes types.VoteSetReader) bool {
+	if !ps.PickSendVote(votes) {
+		return false
+	}
+	conR.countSent(ps.peer, messageType(&VoteMessage{}))
+	return true
+}
+
+// countSent counts a message of the given type sent to the peer.
+func (conR *ConsensusManager) countSent(peer p2p.Peer, msgType string) {
+	conR.metrics.PeerSendMessages.With("peer_id", string(peer.ID()), "message_type", msgType).Add(1)
+}
+
+// messageType returns the name of the message type, used to label metrics.
+func messageType(msg Message) string {
+	return reflect.TypeOf(msg).Elem().Name()
+}
+
+// msgChannel returns the channel msg is sent on, and false for messages of
+// unknown types.
+func msgChannel(msg Message) (byte, bool) {
+	switch msg.(type) {
+	case *NewRoundStepMessage, *NewRoundStepRequestMessage, *NewValidBlockMessage,
+		*HasVoteMessage, *VoteSetMaj23Message:
+		return StateChannel, true
+	case *ProposalMessage, *ProposalPOLMessage, *BlockPartMessage:
+		return DataChannel, true
+	case *VoteMessage:
+		return VoteChannel, true
+	case *VoteSetBitsMessage:
+		return VoteSetBitsChannel, true
+	default:
+		return 0, false
+	}
+}
+
+// RequestNewRoundStep asks the peer for its current round step, returning
+// whether the request was queued.
+func (conR *ConsensusManager) RequestNewRoundStep(peer p2p.Peer) bool {
+	return conR.sendMsg(peer, StateChannel, &NewRoundStepRequestMessage{})
+}
+
+// nudgeStalledPeer re-sends our round step to a peer which is behind us and has
+// not advanced its height/round/step within the peer stall timeout, in case it
+// missed one of our NewRoundStepMessages. Returns true if a message was sent.
+func (conR *ConsensusManager) nudgeStalledPeer(rs *cstypes.RoundState, prs *cstypes.PeerRoundState, ps *PeerState) bool {
+	timeout := conR.service.Config().PeerStallTimeout
+	if timeout <= 0 {
+		return false
+	}
+	if prs.Height > rs.Height || (prs.Height == rs.Height && prs.Round >= rs.Round) {
+		return false
+	}
+	if !ps.markStalled(timeout) {
+		return false
+	}
+	conR.Logger.Debug("Peer stalled, re-sending round step", "peer", ps.peer,
+		"peerHeight", prs.Height, "peerRound", prs.Round, "height", rs.Height, "round", rs.Round)
+	return conR.sendMsg(ps.peer, StateChannel, makeRoundStepMessage(rs))
+}
+
+// evictLaggingPeer stops a peer which has been more than PeerLagHeights behind
+// us for longer than the peer lag timeout, so that it no longer holds gossip
+// routines. Returns true if the peer was stopped.
+func (conR *ConsensusManager) evictLaggingPeer(rs *cstypes.RoundState, prs *cstypes.PeerRoundState, ps *PeerState) bool {
+	timeout := conR.service.Config().PeerLagTimeout
+	if timeout <= 0 {
+		return false
+	}
+	if ps.lagDuration(rs.Height, conR.service.Config().PeerLagHeights) <= timeout {
+		return false
+	}
+	conR.Switch.StopPeerForError(ps.peer, fmt.Errorf("%w: height %d, ours %d, for more than %v",
+		ErrPeerLagging, prs.Height, rs.Height, timeout))
+	return true
 }
 
 // ------------ Helpers to create messages -----
@@ -454,12 +1156,29 @@ func makeRoundStepMessage(rs *cstypes.RoundState) (nrsMsg *NewRoundStepMessage)
 		Step:                  rs.Step,
 		SecondsSinceStartTime: uint64(time.Since(rs.StartTime).Seconds()),
 		LastCommitRound:       rs.LastCommit.GetRound(),
+		StartTime:             rs.StartTime,
 	}
 	return
 }
 
+// makeNewValidBlockMessage returns nil if rs has no proposal block parts, as
+// peers could not use a message without them.
+func makeNewValidBlockMessage(rs *cstypes.RoundState) *NewValidBlockMessage {
+	if rs.ProposalBlockParts == nil {
+		return nil
+	}
+	return &NewValidBlockMessage{
+		Height:           rs.Height,
+		Round:            rs.Round,
+		BlockPartsHeader: rs.ProposalBlockParts.Header(),
+		BlockParts:       rs.ProposalBlockParts.BitArray(),
+		IsCommit:         rs.Step == cstypes.RoundStepCommit,
+	}
+}
+
 // ----------- Gossip routines ---------------
 func (conR *ConsensusManager) gossipDataRoutine(peer p2p.Peer, ps *PeerState) {
+	defer conR.recoverPeer(peer, "gossipDataRoutine")
 	logger := conR.Logger.New("peer", peer)
 	logger.Trace("Start gossipDataRoutine for peer")
 
@@ -470,9 +1189,18 @@ OuterLoop:
 			logger.Info("Stopping gossipDataRoutine for peer")
 			return
 		}
-		rs := conR.conS.GetRoundState()
+		rs := conR.service.GetRoundState()
+		if rs == nil {
+			// Consensus is shutting down or not set up yet.
+			logger.Info("Stopping gossipDataRoutine for peer, no round state")
+			return
+		}
 		prs := ps.GetRoundState()
 
+		if conR.evictLaggingPeer(rs, prs, ps) {
+			return
+		}
+
 		// Send proposal Block parts?
 		if rs.ProposalBlockParts.HasHeader(prs.ProposalBlockPartsHeader) {
 			if index, ok := rs.ProposalBlockParts.BitArray().Sub(prs.ProposalBlockParts.Copy()).PickRandom(); ok {
@@ -483,7 +1211,7 @@ OuterLoop:
 					Part:   part,
 				}
 				logger.Debug("Sending block part", "height", prs.Height, "round", prs.Round)
-				if peer.Send(DataChannel, MustEncode(msg)) {
+				if conR.sendMsg(peer, DataChannel, msg) {
 					ps.SetHasProposalBlockPart(prs.Height, prs.Round, index)
 				}
 				continue OuterLoop
@@ -491,14 +1219,14 @@ OuterLoop:
 		}
 
 		// If the peer is on a previous height, help catch up.
-		if prs.Height > 0 && prs.Height < rs.Height && (prs.Height >= conR.conS.blockOperations.Base()) {
+		if prs.Height > 0 && prs.Height < rs.Height && (prs.Height >= conR.service.BlockOperations().Base()) {
 			// if we never received the commit message from the peer, the block parts wont be initialized
 			if prs.ProposalBlockParts == nil {
-				blockMeta := conR.conS.blockOperations.LoadBlockMeta(prs.Height)
+				blockMeta := conR.service.BlockOperations().LoadBlockMeta(prs.Height)
 				if blockMeta == nil {
 					logger.Error("Failed to load block meta",
-						"blockstoreBase", conR.conS.blockOperations.Base(), "blockstoreHeight", conR.conS.blockOperations.Height())
-					time.Sleep(conR.conS.config.PeerGossipSleepDuration)
+						"blockstoreBase", conR.service.BlockOperations().Base(), "blockstoreHeight", conR.service.BlockOperations().Height())
+					time.Sleep(conR.service.Config().PeerGossipSleepDuration)
 				} else {
 					ps.InitProposalBlockParts(blockMeta.BlockID.PartsHeader)
 				}
@@ -510,8 +1238,9 @@ OuterLoop:
 
 		// If height and round don't match, sleep.
 		if (rs.Height != prs.Height) || (rs.Round != prs.Round) {
+			conR.nudgeStalledPeer(rs, prs, ps)
 			logger.Trace("Peer Height|Round mismatch, sleeping", "peerHeight", prs.Height, "peerRound", prs.Round, "peer", peer)
-			time.Sleep(conR.conS.config.PeerGossipSleep())
+			time.Sleep(conR.service.Config().PeerGossipSleep())
 			continue OuterLoop
 		}
 
@@ -526,7 +1255,7 @@ OuterLoop:
 			{
 				msg := &ProposalMessage{Proposal: rs.Proposal}
 				logger.Debug("Sending proposal", "height", prs.Height, "round", prs.Round)
-				if peer.Send(DataChannel, MustEncode(msg)) {
+				if conR.sendMsg(peer, DataChannel, msg) {
 					// NOTE[ZM]: A peer might have received different proposal msg so this Proposal msg will be rejected!
 					ps.SetHasProposal(rs.Proposal)
 				}
@@ -535,20 +1264,20 @@ OuterLoop:
 			// Peer must receive ProposalMessage first.
 			// rs.Proposal was validated, so rs.Proposal.POLRound <= rs.Round,
 			// so we definitely have rs.Votes.Prevotes(rs.Proposal.POLRound).
-			if rs.Proposal.POLRound > 0 {
+			if rs.Proposal.HasPOLRound() {
 				msg := &ProposalPOLMessage{
 					Height:           rs.Height,
 					ProposalPOLRound: rs.Proposal.POLRound,
 					ProposalPOL:      rs.Votes.Prevotes(rs.Proposal.POLRound).BitArray(),
 				}
 				logger.Debug("Sending POL", "height", prs.Height, "round", prs.Round)
-				peer.Send(DataChannel, MustEncode(msg))
+				conR.sendMsg(peer, DataChannel, msg)
 			}
 			continue OuterLoop
 		}
 
 		// Nothing to do. Sleep.
-		time.Sleep(conR.conS.config.PeerGossipSleep())
+		time.Sleep(conR.service.Config().PeerGossipSleep())
 		continue OuterLoop
 	}
 }
@@ -558,25 +1287,25 @@ func (conR *ConsensusManager) gossipDataForCatchup(rs *cstypes.RoundState,
 
 	if index, ok := prs.ProposalBlockParts.Not().PickRandom(); ok {
 		// Ensure that the peer's PartSetHeader is correct
-		blockMeta := conR.conS.blockOperations.LoadBlockMeta(prs.Height)
+		blockMeta := conR.service.BlockOperations().LoadBlockMeta(prs.Height)
 		if blockMeta == nil {
 			conR.Logger.Error("Failed to load block meta",
-				"ourHeight", rs.Height, "blockstoreHeight", conR.conS.blockOperations.Height())
-			time.Sleep(conR.conS.config.PeerGossipSleep())
+				"ourHeight", rs.Height, "blockstoreHeight", conR.service.BlockOperations().Height())
+			time.Sleep(conR.service.Config().PeerGossipSleep())
 			return
 		}
 		if !blockMeta.BlockID.PartsHeader.Equals(prs.ProposalBlockPartsHeader) {
 			conR.Logger.Info("Peer ProposalBlockPartsHeader mismatch, sleeping",
 				"blockPartsHeader", blockMeta.BlockID.PartsHeader, "peerBlockPartsHeader", prs.ProposalBlockPartsHeader)
-			time.Sleep(conR.conS.config.PeerGossipSleep())
+			time.Sleep(conR.service.Config().PeerGossipSleep())
 			return
 		}
 		// Load the part
-		part := conR.conS.blockOperations.LoadBlockPart(prs.Height, index)
+		part := conR.service.BlockOperations().LoadBlockPart(prs.Height, index)
 		if part == nil {
 			conR.Logger.Error("Could not load part", "index", index,
 				"blockPartsHeader", blockMeta.BlockID.PartsHeader, "peerBlockPartsHeader", prs.ProposalBlockPartsHeader)
-			time.Sleep(conR.conS.config.PeerGossipSleep())
+			time.Sleep(conR.service.Config().PeerGossipSleep())
 			return
 		}
 
@@ -587,7 +1316,7 @@ func (conR *ConsensusManager) gossipDataForCatchup(rs *cstypes.RoundState,
 			Part:   part,
 		}
 		conR.Logger.Debug("Sending block part for catchup", "round", prs.Round, "index", index)
-		if peer.Send(DataChannel, MustEncode(msg)) {
+		if conR.sendMsg(peer, DataChannel, msg) {
 			ps.SetHasProposalBlockPart(prs.Height, prs.Round, index)
 		} else {
 			conR.Logger.Debug("Sending block part for catchup failed")
@@ -595,10 +1324,11 @@ func (conR *ConsensusManager) gossipDataForCatchup(rs *cstypes.RoundState,
 		return
 	}
 	//logger.Info("No parts to send in catch-up, sleeping")
-	time.Sleep(conR.conS.config.PeerGossipSleep())
+	time.Sleep(conR.service.Config().PeerGossipSleep())
 }
 
 func (conR *ConsensusManager) gossipVotesRoutine(peer p2p.Peer, ps *PeerState) {
+	defer conR.recoverPeer(peer, "gossipVotesRoutine")
 	logger := conR.Logger.New("peer", peer)
 	logger.Trace("Start gossipVotesRoutine for peer")
 
@@ -612,7 +1342,12 @@ OUTER_LOOP:
 			logger.Info("Stopping gossipVotesRoutine for peer")
 			return
 		}
-		rs := conR.conS.GetRoundState()
+		rs := conR.service.GetRoundState()
+		if rs == nil {
+			// Consensus is shutting down or not set up yet.
+			logger.Info("Stopping gossipVotesRoutine for peer, no round state")
+			return
+		}
 		prs := ps.GetRoundState()
 
 		switch sleeping {
@@ -636,7 +1371,7 @@ OUTER_LOOP:
 		// Special catchup logic.
 		// If peer is lagging by height 1, send LastCommit.
 		if (prs.Height != 0) && (rs.Height == prs.Height+1) {
-			if ps.PickSendVote(rs.LastCommit) {
+			if conR.pickSendVote(ps, rs.LastCommit) {
 				logger.Debug("Picked rs.LastCommit to send", "height", prs.Height)
 				continue OUTER_LOOP
 			}
@@ -644,12 +1379,8 @@ OUTER_LOOP:
 
 		// Catchup logic
 		// If peer is lagging by more than 1, send Commit.
-		if (prs.Height != 0) && (rs.Height >= prs.Height+2) {
-			// Load the block commit for prs.Height,
-			// which contains precommit signatures for prs.Height.
-			commit := conR.conS.blockOperations.LoadBlockCommit(prs.Height)
-			if ps.PickSendVote(commit) {
-				logger.Debug("Picked Catchup commit to send", "height", prs.Height)
+		if (prs.Height != 0) && (rs.Height >= prs.Height+2) && (prs.Height >= conR.service.BlockOperations().Base()) {
+			if conR.gossipCommitForCatchup(logger, prs, ps) {
 				continue OUTER_LOOP
 			}
 		}
@@ -665,67 +1396,94 @@ OUTER_LOOP:
 			sleeping = 1
 		}
 
-		time.Sleep(conR.conS.config.PeerGossipSleep())
+		time.Sleep(conR.service.Config().PeerGossipSleep())
 		continue OUTER_LOOP
 	}
 }
 
+// gossipCommitForCatchup sends the peer a precommit from the stored commit of
+// its height. Together with the block parts sent by gossipDataForCatchup this
+// lets a peer lagging by more than one height adopt the committed block.
+// Returns true if a vote was sent.
+func (conR *ConsensusManager) gossipCommitForCatchup(logger log.Logger, prs *cstypes.PeerRoundState, ps *PeerState) bool {
+	// Load the block commit for prs.Height,
+	// which contains precommit signatures for prs.Height.
+	commit := conR.service.BlockOperations().LoadBlockCommit(prs.Height)
+	if commit == nil {
+		logger.Error("Failed to load block commit", "height", prs.Height,
+			"blockstoreBase", conR.service.BlockOperations().Base(), "blockstoreHeight", conR.service.BlockOperations().Height())
+		return false
+	}
+	if conR.pickSendVote(ps, commit) {
+		logger.Debug("Picked Catchup commit to send", "height", prs.Height)
+		return true
+	}
+	return false
+}
+
 func (conR *ConsensusManager) gossipVotesForHeight(logger log.Logger, rs *cstypes.RoundState, prs *cstypes.PeerRoundState, ps *PeerState) bool {
 	//logger.Trace("Start gossipVotesForHeight for peer")
 
-	// If there are lastCommits to send...
-	if prs.Step == cstypes.RoundStepNewHeight {
-		if ps.PickSendVote(rs.LastCommit) {
-			logger.Debug("Picked rs.LastCommit to send")
+	for _, vs := range voteSetsToGossip(rs, prs) {
+		if conR.pickSendVote(ps, vs.votes) {
+			logger.Debug("Picked "+vs.name+" to send", "round", vs.votes.GetRound())
 			return true
 		}
 	}
-	// If there are POL prevotes to send...
-	if (prs.Step <= cstypes.RoundStepPropose) && (prs.Round != 0) && (prs.Round <= rs.Round) && (prs.ProposalPOLRound != 0) {
+	return false
+}
+
+// gossipVoteSet is a vote set to pick a vote from for a peer.
+type gossipVoteSet struct {
+	name  string
+	votes types.VoteSetReader
+}
+
+// voteSetsToGossip returns the vote sets to pick a vote from for a peer at
+// our height, by order of priority. Missing precommits for the peer's round
+// come before its prevotes, as they are what lets the peer commit.
+func voteSetsToGossip(rs *cstypes.RoundState, prs *cstypes.PeerRoundState) []gossipVoteSet {
+	var sets []gossipVoteSet
+	polPrevotes := func() {
 		if polPrevotes := rs.Votes.Prevotes(prs.ProposalPOLRound); polPrevotes != nil {
-			if ps.PickSendVote(polPrevotes) {
-				logger.Debug("Picked rs.Prevotes(prs.ProposalPOLRound) to send",
-					"round", prs.ProposalPOLRound)
-				return true
-			}
+			sets = append(sets, gossipVoteSet{"rs.Prevotes(prs.ProposalPOLRound)", polPrevotes})
 		}
 	}
-	// If there are prevotes to send...
-	if (prs.Step <= cstypes.RoundStepPrevoteWait) && (prs.Round <= rs.Round) {
-		if ps.PickSendVote(rs.Votes.Prevotes(prs.Round)) {
-			logger.Debug("Picked rs.Prevotes(prs.Round) to send", "round", prs.Round)
-			return true
-		}
+
+	// If there are lastCommits to send...
+	if prs.Step == cstypes.RoundStepNewHeight {
+		sets = append(sets, gossipVoteSet{"rs.LastCommit", rs.LastCommit})
+	}
+	// If there are POL prevotes to send...
+	if (prs.Step <= cstypes.RoundStepPropose) && (prs.Round != 0) && (prs.Round <= rs.Round) && (prs.ProposalPOLRound != types.NoPOLRound) {
+		polPrevotes()
 	}
 	// If there are precommits to send...
 	if (prs.Step <= cstypes.RoundStepPrecommitWait) && (prs.Round != 0) && (prs.Round <= rs.Round) {
-		if ps.PickSendVote(rs.Votes.Precommits(prs.Round)) {
-			logger.Debug("Picked rs.Precommits(prs.Round) to send", "round", prs.Round)
-			return true
-		}
+		sets = append(sets, gossipVoteSet{"rs.Precommits(prs.Round)", rs.Votes.Precommits(prs.Round)})
+	}
+	// If there are prevotes to send...
+	if (prs.Step <= cstypes.RoundStepPrevoteWait) && (prs.Round <= rs.Round) {
+		sets = append(sets, gossipVoteSet{"rs.Prevotes(prs.Round)", rs.Votes.Prevotes(prs.Round)})
 	}
 	// If there are prevotes to send...Needed because of validBlock mechanism
 	if (prs.Round != 0) && (prs.Round <= rs.Round) {
-		if ps.PickSendVote(rs.Votes.Prevotes(prs.Round)) {
-			logger.Debug("Picked rs.Prevotes(prs.Round) to send", "round", prs.Round)
-			return true
-		}
+		sets = append(sets, gossipVoteSet{"rs.Prevotes(prs.Round)", rs.Votes.Prevotes(prs.Round)})
 	}
 	// If there are POLPrevotes to send...
-	if prs.ProposalPOLRound != 0 {
-		if polPrevotes := rs.Votes.Prevotes(prs.ProposalPOLRound); polPrevotes != nil {
-			if ps.PickSendVote(polPrevotes) {
-				logger.Debug("Picked rs.Prevotes(prs.ProposalPOLRound) to send",
-					"round", prs.ProposalPOLRound)
-				return true
-			}
-		}
+	if prs.ProposalPOLRound != types.NoPOLRound {
+		polPrevotes()
 	}
-
-	return false
+	// If the peer moved past NewHeight without all of our LastCommit precommits,
+	// keep sending them so it can still complete its commit of the previous height.
+	if prs.Step != cstypes.RoundStepNewHeight && prs.LastCommit != nil && !prs.LastCommit.IsFull() {
+		sets = append(sets, gossipVoteSet{"missing rs.LastCommit", rs.LastCommit})
+	}
+	return sets
 }
 
 func (conR *ConsensusManager) queryMaj23Routine(peer p2p.Peer, ps *PeerState) {
+	defer conR.recoverPeer(peer, "queryMaj23Routine")
 	logger := conR.Logger.New("peer", peer)
 
 OUTER_LOOP:
@@ -738,7 +1496,7 @@ OUTER_LOOP:
 
 		// Send Height/Round/Prevotes
 		{
-			rs := conR.conS.GetRoundState()
+			rs := conR.service.GetRoundState()
 			prs := ps.GetRoundState()
 			if rs.Height == prs.Height {
 				if maj23, ok := rs.Votes.Prevotes(prs.Round).TwoThirdsMajority(); ok {
@@ -748,14 +1506,14 @@ OUTER_LOOP:
 						Type:    kproto.PrevoteType,
 						BlockID: maj23,
 					}))
-					time.Sleep(conR.conS.config.PeerQueryMaj23Sleep())
+					time.Sleep(conR.service.Config().PeerQueryMaj23Sleep())
 				}
 			}
 		}
 
 		// Send Height/Round/Precommits
 		{
-			rs := conR.conS.GetRoundState()
+			rs := conR.service.GetRoundState()
 			prs := ps.GetRoundState()
 			if rs.Height == prs.Height {
 				if maj23, ok := rs.Votes.Precommits(prs.Round).TwoThirdsMajority(); ok {
@@ -765,14 +1523,14 @@ OUTER_LOOP:
 						Type:    kproto.PrecommitType,
 						BlockID: maj23,
 					}))
-					time.Sleep(conR.conS.config.PeerQueryMaj23Sleep())
+					time.Sleep(conR.service.Config().PeerQueryMaj23Sleep())
 				}
 			}
 		}
 
 		// Send Height/Round/ProposalPOL
 		{
-			rs := conR.conS.GetRoundState()
+			rs := conR.service.GetRoundState()
 			prs := ps.GetRoundState()
 			if rs.Height == prs.Height {
 				if maj23, ok := rs.Votes.Prevotes(prs.ProposalPOLRound).TwoThirdsMajority(); ok {
@@ -782,7 +1540,7 @@ OUTER_LOOP:
 						Type:    kproto.PrevoteType,
 						BlockID: maj23,
 					}))
-					time.Sleep(conR.conS.config.PeerQueryMaj23Sleep())
+					time.Sleep(conR.service.Config().PeerQueryMaj23Sleep())
 				}
 			}
 		}
@@ -790,8 +1548,8 @@ OUTER_LOOP:
 		// Send Height/CatchupCommitRound/CatchupCommit.
 		{
 			prs := ps.GetRoundState()
-			if (prs.CatchupCommitRound != 0) && (prs.Height > 0) && (prs.Height <= conR.conS.blockOperations.Height()) {
-				commit := conR.conS.LoadCommit(prs.Height)
+			if (prs.CatchupCommitRound != 0) && (prs.Height > 0) && (prs.Height <= conR.service.BlockOperations().Height()) {
+				commit := conR.service.LoadCommit(prs.Height)
 				if commit != nil {
 					peer.TrySend(StateChannel, MustEncode(&VoteSetMaj23Message{
 						Height:  prs.Height,
@@ -799,13 +1557,13 @@ OUTER_LOOP:
 						Type:    kproto.PrecommitType,
 						BlockID: commit.BlockID,
 					}))
-					time.Sleep(conR.conS.config.PeerQueryMaj23Sleep())
+					time.Sleep(conR.service.Config().PeerQueryMaj23Sleep())
 				}
 
 			}
 		}
 
-		time.Sleep(conR.conS.config.PeerQueryMaj23Sleep())
+		time.Sleep(conR.service.Config().PeerQueryMaj23Sleep())
 
 		continue OUTER_LOOP
 	}
@@ -819,6 +1577,33 @@ type Message interface {
 	ValidateBasic() error
 }
 
+// msgLogContext returns the key/value pairs identifying msg in log records,
+// so that every message is logged with the same height, round and type keys.
+func msgLogContext(msg Message) []interface{} {
+	ctx := []interface{}{"type", messageType(msg)}
+	switch msg := msg.(type) {
+	case *NewRoundStepMessage:
+		ctx = append(ctx, "height", msg.Height, "round", msg.Round, "step", msg.Step)
+	case *NewValidBlockMessage:
+		ctx = append(ctx, "height", msg.Height, "round", msg.Round)
+	case *ProposalMessage:
+		ctx = append(ctx, "height", msg.Proposal.Height, "round", msg.Proposal.Round)
+	case *ProposalPOLMessage:
+		ctx = append(ctx, "height", msg.Height, "polRound", msg.ProposalPOLRound)
+	case *BlockPartMessage:
+		ctx = append(ctx, "height", msg.Height, "round", msg.Round)
+	case *VoteMessage:
+		ctx = append(ctx, "height", msg.Vote.Height, "round", msg.Vote.Round, "voteType", msg.Vote.Type)
+	case *HasVoteMessage:
+		ctx = append(ctx, "height", msg.Height, "round", msg.Round, "voteType", msg.Type)
+	case *VoteSetMaj23Message:
+		ctx = append(ctx, "height", msg.Height, "round", msg.Round, "voteType", msg.Type)
+	case *VoteSetBitsMessage:
+		ctx = append(ctx, "height", msg.Height, "round", msg.Round, "voteType", msg.Type)
+	}
+	return ctx
+}
+
 // ----------- Consensus Messages ------------
 
 // ConsensusMessage is a message that can be sent and received on the ConsensusManager
@@ -872,6 +1657,12 @@ type NewRoundStepMessage struct {
 	Step                  cstypes.RoundStepType `json:"step" gencodoc:"required"`
 	SecondsSinceStartTime uint64                `json:"elapsed" gencodoc:"required"`
 	LastCommitRound       uint32                `json:"lastCommitRound" gencodoc:"required"`
+	// StartTime is the absolute start of round 0, preferred over
+	// SecondsSinceStartTime when set. Older peers leave it zero.
+	StartTime time.Time `json:"startTime"`
+	// CommitHeader is the header of the block being committed at Height. It is
+	// only sent in the commit step, to peers that asked for it.
+	CommitHeader *types.Header `json:"commitHeader"`
 }
 
 // ValidateBasic performs basic validation.
@@ -879,6 +1670,15 @@ func (m *NewRoundStepMessage) ValidateBasic() error {
 	if !m.Step.IsValid() {
 		return ErrInvalidStep
 	}
+	if m.CommitHeader != nil {
+		if m.Step != cstypes.RoundStepCommit {
+			return fmt.Errorf("commit header sent in step %v", m.Step)
+		}
+		if m.CommitHeader.Height != m.Height {
+			return fmt.Errorf("commit header height %v does not match height %v",
+				m.CommitHeader.Height, m.Height)
+		}
+	}
 
 	// NOTE: SecondsSinceStartTime may be negative
 
@@ -907,6 +1707,24 @@ func (m *NewRoundStepMessage) ValidateHeight(initialHeight uint64) error {
 	return nil
 }
 
+// NewRoundStepRequestMessage asks a peer to send its current round step, so a
+// peer which missed a NewRoundStepMessage need not wait for the next one.
+type NewRoundStepRequestMessage struct {
+	// CommitHeaders asks the peer to attach commit headers to the round step
+	// messages it sends from now on.
+	CommitHeaders bool `json:"commitHeaders"`
+}
+
+// ValidateBasic performs basic validation.
+func (m *NewRoundStepRequestMessage) ValidateBasic() error {
+	return nil
+}
+
+// String returns a string representation.
+func (m *NewRoundStepRequestMessage) String() string {
+	return fmt.Sprintf("[NewRoundStepRequest CH:%v]", m.CommitHeaders)
+}
+
 // HasVoteMessage is sent to indicate that a particular vote has been received.
 type HasVoteMessage struct {
 	Height uint64
@@ -920,6 +1738,11 @@ func (m *HasVoteMessage) ValidateBasic() error {
 	if !types.IsVoteTypeValid(m.Type) {
 		return ErrInvalidMsgType
 	}
+	// Height, Round and Index are unsigned on the wire, so only the upper
+	// bound of Index needs checking.
+	if m.Index >= types.MaxVotesCount {
+		return fmt.Errorf("%w: %d, max: %d", types.ErrVoteInvalidValidatorIndex, m.Index, types.MaxVotesCount)
+	}
 	return nil
 }
 
@@ -992,8 +1815,33 @@ type PeerState struct {
 
 	mtx sync.Mutex             // NOTE: Modify below using setters, never directly.
 	PRS cstypes.PeerRoundState `json:"round_state"` // Exposed.
+
+	lastProgress  time.Time // when PRS height/round/step last changed, or the peer was last nudged
+	laggingSince  time.Time // when the peer was first seen too far behind us, zero if it is not
+	commitHeaders bool      // whether the peer wants commit headers in round step messages
+
+	seenSteps *lru.Cache[roundStep, struct{}] // round steps recently applied from the peer
+
+	recvProposal receivedProposal // last proposal received from the peer
+}
+
+// receivedProposal is a proposal received from a peer, in its encoded form.
+type receivedProposal struct {
+	height uint64
+	round  uint32
+	bz     []byte
 }
 
+// roundStep is the height/round/step announced by a NewRoundStepMessage.
+type roundStep struct {
+	height uint64
+	round  uint32
+	step   cstypes.RoundStepType
+}
+
+// seenRoundStepsSize is the number of recent round steps remembered per peer.
+const seenRoundStepsSize = 8
+
 // NewPeerState returns a new PeerState for the given Peer
 func NewPeerState(peer p2p.Peer) *PeerState {
 	return &PeerState{
@@ -1004,8 +1852,9 @@ func NewPeerState(peer p2p.Peer) *PeerState {
 			ProposalPOLRound:   0,
 			LastCommitRound:    0,
 			CatchupCommitRound: 0,
-			StartTime:          0,
 		},
+		lastProgress: time.Now(),
+		seenSteps:    lru.NewCache[roundStep, struct{}](seenRoundStepsSize),
 	}
 }
 
@@ -1016,14 +1865,89 @@ func (ps *PeerState) SetLogger(logger log.Logger) *PeerState {
 	return ps
 }
 
-// GetRoundState returns an shallow copy of the PeerRoundState.
+// GetRoundState returns a deep copy of the PeerRoundState.
 // There's no point in mutating it since it won't change PeerState.
 func (ps *PeerState) GetRoundState() *cstypes.PeerRoundState {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
 
-	prs := ps.PRS // copy
-	return &prs
+	return ps.PRS.DeepCopy()
+}
+
+// GetHeight returns an atomic snapshot of the PeerRoundState's height.
+func (ps *PeerState) GetHeight() uint64 {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	return ps.PRS.Height
+}
+
+// SetCommitHeaders sets whether the peer, typically a light follower, wants
+// the header of the block being committed in our round step messages. It is
+// set by the peer's NewRoundStepRequestMessage.
+func (ps *PeerState) SetCommitHeaders(enabled bool) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	ps.commitHeaders = enabled
+}
+
+// wantsCommitHeaders reports whether the peer wants commit headers.
+func (ps *PeerState) wantsCommitHeaders() bool {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	return ps.commitHeaders
+}
+
+// markStalled reports whether the peer's height/round/step has not changed for
+// longer than timeout. The stall timer restarts whenever it returns true, so a
+// stalled peer is reported at most once per timeout.
+func (ps *PeerState) markStalled(timeout time.Duration) bool {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if time.Since(ps.lastProgress) <= timeout {
+		return false
+	}
+	ps.lastProgress = time.Now()
+	return true
+}
+
+// lagDuration returns for how long the peer has been more than maxLag heights
+// behind height, counting from the first call which found it so. It returns
+// zero if the peer is not behind, or did not report its height yet.
+func (ps *PeerState) lagDuration(height, maxLag uint64) time.Duration {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if ps.PRS.Height == 0 || ps.PRS.Height+maxLag >= height {
+		ps.laggingSince = time.Time{}
+		return 0
+	}
+	if ps.laggingSince.IsZero() {
+		ps.laggingSince = time.Now()
+		return 0
+	}
+	return time.Since(ps.laggingSince)
+}
+
+// recordReceivedProposal remembers a proposal received from the peer. It
+// returns false if the peer already sent this proposal, and
+// ErrConflictingProposal if it sent a different one for the same height and
+// round.
+func (ps *PeerState) recordReceivedProposal(proposal *types.Proposal) (bool, error) {
+	bz, err := proposal.ToProto().Marshal()
+	if err != nil {
+		return false, err
+	}
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+
+	last := ps.recvProposal
+	if last.bz != nil && last.height == proposal.Height && last.round == proposal.Round {
+		if !bytes.Equal(last.bz, bz) {
+			return false, fmt.Errorf("%w: height %d, round %d", ErrConflictingProposal, proposal.Height, proposal.Round)
+		}
+		return false, nil
+	}
+	ps.recvProposal = receivedProposal{height: proposal.Height, round: proposal.Round, bz: bz}
+	return true, nil
 }
 
 // SetHasProposal sets the given proposal as known for the peer.
@@ -1213,23 +2137,28 @@ func (ps *PeerState) EnsureVoteBitArrays(height uint64, numValidators int) {
 
 func (ps *PeerState) ensureVoteBitArrays(height uint64, numValidators int) {
 	if ps.PRS.Height == height {
-		if ps.PRS.Prevotes == nil {
-			ps.PRS.Prevotes = cmn.NewBitArray(numValidators)
-		}
-		if ps.PRS.Precommits == nil {
-			ps.PRS.Precommits = cmn.NewBitArray(numValidators)
-		}
-		if ps.PRS.CatchupCommit == nil {
-			ps.PRS.CatchupCommit = cmn.NewBitArray(numValidators)
-		}
-		if ps.PRS.ProposalPOL == nil {
-			ps.PRS.ProposalPOL = cmn.NewBitArray(numValidators)
+		aliased := ps.PRS.CatchupCommit != nil && ps.PRS.CatchupCommit == ps.PRS.Precommits
+		ps.PRS.Prevotes = sizedBitArray(ps.PRS.Prevotes, numValidators)
+		ps.PRS.Precommits = sizedBitArray(ps.PRS.Precommits, numValidators)
+		if aliased {
+			ps.PRS.CatchupCommit = ps.PRS.Precommits
+		} else {
+			ps.PRS.CatchupCommit = sizedBitArray(ps.PRS.CatchupCommit, numValidators)
 		}
+		ps.PRS.ProposalPOL = sizedBitArray(ps.PRS.ProposalPOL, numValidators)
 	} else if ps.PRS.Height == height+1 {
-		if ps.PRS.LastCommit == nil {
-			ps.PRS.LastCommit = cmn.NewBitArray(numValidators)
-		}
+		ps.PRS.LastCommit = sizedBitArray(ps.PRS.LastCommit, numValidators)
+	}
+}
+
+// sizedBitArray returns bA if it has one bit per validator, or a new array
+// otherwise, e.g. if bA was sized for the validator set of another height.
+// A non-positive numValidators means the size is unknown and keeps bA.
+func sizedBitArray(bA *cmn.BitArray, numValidators int) *cmn.BitArray {
+	if numValidators <= 0 || bA.Size() == numValidators {
+		return bA
 	}
+	return cmn.NewBitArray(numValidators)
 }
 
 // SetHasVote sets the given vote as known by the peer
@@ -1245,19 +2174,51 @@ func (ps *PeerState) setHasVote(height uint64, round uint32, signedMsgType kprot
 	ps.logger.Debug("setHasVote", "H/R", cmn.Fmt("%v/%v", height, round), "type", types.GetReadableVoteTypeString(signedMsgType), "index", index)
 
 	psVotes := ps.getVoteBitArray(height, round, signedMsgType)
-	if psVotes != nil {
-		psVotes.SetIndex(int(index), true)
+	if psVotes == nil {
+		return
+	}
+	if int(index) >= psVotes.Size() {
+		ps.logger.Debug("Ignoring vote with out of range index", "index", index, "size", psVotes.Size())
+		return
 	}
+	psVotes.SetIndex(int(index), true)
+}
+
+// seenRoundStep reports whether the height/round/step of msg was recently
+// applied, without taking the peer state mutex.
+func (ps *PeerState) seenRoundStep(msg *NewRoundStepMessage) bool {
+	return ps.seenSteps.Contains(roundStep{msg.Height, msg.Round, msg.Step})
 }
 
-// ApplyNewRoundStepMessage updates the peer state for the new round.
-func (ps *PeerState) ApplyNewRoundStepMessage(msg *NewRoundStepMessage) {
+// rememberRoundStep records the height/round/step of an applied msg.
+func (ps *PeerState) rememberRoundStep(msg *NewRoundStepMessage) {
+	ps.seenSteps.Add(roundStep{msg.Height, msg.Round, msg.Step}, struct{}{})
+}
+
+// ApplyNewRoundStepMessage updates the peer state for the new round. A peer
+// reporting a lower height than before is started over from a blank state.
+// It returns an error, leaving the peer state untouched, if the peer changes
+// its last commit round without moving to another height.
+func (ps *PeerState) ApplyNewRoundStepMessage(msg *NewRoundStepMessage) error {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
 
+	// A peer going back more than peerResetHeights has been restarted or
+	// rolled back, and what we know about its votes and progress is stale.
+	if msg.Height+peerResetHeights < ps.PRS.Height {
+		ps.PRS = cstypes.PeerRoundState{}
+		ps.seenSteps.Purge()
+		ps.lastProgress = time.Now()
+		ps.laggingSince = time.Time{}
+	}
 	// Ignore duplicates or decreases
 	if CompareHRS(msg.Height, msg.Round, msg.Step, ps.PRS.Height, ps.PRS.Round, ps.PRS.Step) <= 0 {
-		return
+		return nil
+	}
+	// The commit of the previous height is fixed once the peer is at msg.Height.
+	if ps.PRS.Height != 0 && ps.PRS.Height == msg.Height && ps.PRS.LastCommitRound != msg.LastCommitRound {
+		return fmt.Errorf("%w: %d, peer committed height %d in round %d",
+			ErrWrongLastCommitRound, msg.LastCommitRound, msg.Height-1, ps.PRS.LastCommitRound)
 	}
 
 	// Just remember these values.
@@ -1267,18 +2228,22 @@ func (ps *PeerState) ApplyNewRoundStepMessage(msg *NewRoundStepMessage) {
 	psCatchupCommitRound := ps.PRS.CatchupCommitRound
 	psCatchupCommit := ps.PRS.CatchupCommit
 
-	startTime := time.Now().Unix() - int64(msg.SecondsSinceStartTime)
+	startTime := msg.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now().Add(-time.Duration(msg.SecondsSinceStartTime) * time.Second)
+	}
+	ps.lastProgress = time.Now()
 	ps.PRS.Height = msg.Height
 	ps.PRS.Round = msg.Round
 	ps.PRS.Step = msg.Step
-	ps.PRS.StartTime = uint64(startTime)
+	ps.PRS.StartTime = startTime
 	if (psHeight != msg.Height) || (psRound != msg.Round) {
 		ps.PRS.Proposal = false
 		ps.PRS.ProposalBlockPartsHeader = types.PartSetHeader{}
 		ps.PRS.ProposalBlockParts = nil
-		ps.PRS.ProposalPOLRound = 0
+		ps.PRS.ProposalPOLRound = types.NoPOLRound
 		ps.PRS.ProposalPOL = nil
-		// We'll update the BitArray capacity later.
+		// Sized by the manager with EnsureVoteBitArrays.
 		ps.PRS.Prevotes = nil
 		ps.PRS.Precommits = nil
 	}
@@ -1297,18 +2262,25 @@ func (ps *PeerState) ApplyNewRoundStepMessage(msg *NewRoundStepMessage) {
 		ps.PRS.CatchupCommitRound = 0
 		ps.PRS.CatchupCommit = nil
 	}
+	return nil
 }
 
 // ApplyHasVoteMessage updates the peer state for the new vote.
-func (ps *PeerState) ApplyHasVoteMessage(msg *HasVoteMessage) {
+// It returns an error if the vote index is out of range for the validator set.
+func (ps *PeerState) ApplyHasVoteMessage(msg *HasVoteMessage) error {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
 
 	if ps.PRS.Height != msg.Height {
-		return
+		return nil
 	}
 
+	psVotes := ps.getVoteBitArray(msg.Height, msg.Round, msg.Type)
+	if psVotes != nil && int(msg.Index) >= psVotes.Size() {
+		return fmt.Errorf("%w: %d, size %d", types.ErrVoteInvalidValidatorIndex, msg.Index, psVotes.Size())
+	}
 	ps.setHasVote(msg.Height, msg.Round, msg.Type, msg.Index)
+	return nil
 }
 
 // ApplyVoteSetBitsMessage updates the peer state for the bit-array of votes
@@ -1316,12 +2288,13 @@ func (ps *PeerState) ApplyHasVoteMessage(msg *HasVoteMessage) {
 // `ourVotes` is a BitArray of votes we have for msg.BlockID
 // NOTE: if ourVotes is nil (e.g. msg.Height < rs.Height),
 // we conservatively overwrite ps's votes w/ msg.Votes.
+// Votes sized for another validator set than ps's are ignored.
 func (ps *PeerState) ApplyVoteSetBitsMessage(msg *VoteSetBitsMessage, ourVotes *cmn.BitArray) {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
 
 	votes := ps.getVoteBitArray(msg.Height, msg.Round, msg.Type)
-	if votes != nil {
+	if votes != nil && votes.Size() == msg.Votes.Size() {
 		if ourVotes == nil {
 			votes.Update(msg.Votes)
 		} else {
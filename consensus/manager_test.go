@@ -0,0 +1,1935 @@
+/*
+ *  Copyright 2018 KardiaChain
+ *  This file is part of the go-kardia library.
+ *
+ *  The go-kardia library is free software: you can redistribute it and/or modify
+ *  it under the terms of the GNU Lesser General Public License as published by
+ *  the Free Software Foundation, either version 3 of the License, or
+ *  (at your option) any later version.
+ *
+ *  The go-kardia library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ *  GNU Lesser General Public License for more details.
+ *
+ *  You should have received a copy of the GNU Lesser General Public License
+ *  along with the go-kardia library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kardiachain/go-kardia/configs"
+	cstypes "github.com/kardiachain/go-kardia/consensus/types"
+	"github.com/kardiachain/go-kardia/kai/state/cstate"
+	"github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/crypto"
+	kevents "github.com/kardiachain/go-kardia/lib/events"
+	"github.com/kardiachain/go-kardia/lib/log"
+	"github.com/kardiachain/go-kardia/lib/p2p"
+	"github.com/kardiachain/go-kardia/lib/p2p/conn"
+	"github.com/kardiachain/go-kardia/lib/p2p/mock"
+	"github.com/kardiachain/go-kardia/lib/rand"
+	"github.com/kardiachain/go-kardia/lib/service"
+	kproto "github.com/kardiachain/go-kardia/proto/kardiachain/types"
+	"github.com/kardiachain/go-kardia/trie"
+	"github.com/kardiachain/go-kardia/types"
+)
+
+const testChainID = "kai"
+
+// testPeer is a mock peer capturing every message sent to it.
+type testPeer struct {
+	*mock.Peer
+
+	mtx  sync.Mutex
+	sent []testPeerMsg
+}
+
+type testPeerMsg struct {
+	chID byte
+	msg  Message
+}
+
+func newTestPeer() *testPeer {
+	return &testPeer{Peer: mock.NewPeer(nil)}
+}
+
+func (tp *testPeer) Send(chID byte, msgBytes []byte) bool {
+	msg, err := decodeMsg(msgBytes)
+	if err != nil {
+		panic(err)
+	}
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	tp.sent = append(tp.sent, testPeerMsg{chID: chID, msg: msg})
+	return true
+}
+
+func (tp *testPeer) TrySend(chID byte, msgBytes []byte) bool {
+	return tp.Send(chID, msgBytes)
+}
+
+// Sent returns a copy of the messages sent to the peer so far.
+func (tp *testPeer) Sent() []testPeerMsg {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	return append([]testPeerMsg(nil), tp.sent...)
+}
+
+// failingTestPeer is a mock peer whose sends always fail.
+type failingTestPeer struct {
+	*mock.Peer
+}
+
+func (fp failingTestPeer) Send(chID byte, msgBytes []byte) bool    { return false }
+func (fp failingTestPeer) TrySend(chID byte, msgBytes []byte) bool { return false }
+
+// newTestManager returns a running ConsensusManager in wait-sync mode, backed
+// by a bare ConsensusState and a switch without any network transport. The
+// returned private validators are ordered by their validator set index.
+func newTestManager(t *testing.T) (*ConsensusManager, []types.PrivValidator) {
+	logger := log.TestingLogger()
+
+	cs := &ConsensusState{
+		config:           configs.TestConsensusConfig(),
+		peerMsgQueue:     make(chan msgInfo, msgQueueSize),
+		internalMsgQueue: make(chan msgInfo, msgQueueSize),
+		evsw:             kevents.NewEventSwitch(),
+		done:             make(chan struct{}),
+	}
+	cs.BaseService = *service.NewBaseService(logger, "State", cs)
+	valSet, privVals := types.RandValidatorSet(4, 10)
+	sortedPrivVals := make([]types.PrivValidator, len(privVals))
+	for _, pv := range privVals {
+		idx, _ := valSet.GetByAddress(pv.GetAddress())
+		sortedPrivVals[idx] = pv
+	}
+	cs.state.ChainID = testChainID
+	cs.Height = 1
+	cs.Round = 1
+	cs.Step = cstypes.RoundStepNewHeight
+	cs.StartTime = time.Now()
+	cs.Validators = valSet
+	cs.Votes = cstypes.NewHeightVoteSet(logger, testChainID, 1, valSet)
+
+	conR := NewConsensusManager(cs, &configs.FastSyncConfig{Enable: true})
+	conR.SetLogger(logger)
+
+	priv, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	transport := p2p.NewMultiplexTransport(p2p.DefaultNodeInfo{}, p2p.NodeKey{PrivKey: priv}, conn.DefaulKAIConnConfig())
+	sw := p2p.NewSwitch(configs.DefaultP2PConfig(), transport)
+	sw.SetLogger(logger)
+	conR.SetSwitch(sw)
+
+	require.NoError(t, conR.Start())
+	t.Cleanup(func() {
+		_ = conR.Stop()
+	})
+	return conR, sortedPrivVals
+}
+
+// consensusState returns the consensus state behind a manager made by
+// newTestManager.
+func consensusState(conR *ConsensusManager) *ConsensusState {
+	return conR.service.(*ConsensusState)
+}
+
+// addTestPeer registers a new capturing peer with the manager's switch.
+func addTestPeer(conR *ConsensusManager) *testPeer {
+	peer := newTestPeer()
+	conR.InitPeer(peer)
+	p2p.AddPeerToSwitchPeerSet(conR.Switch, peer)
+	return peer
+}
+
+// addRunningTestPeer registers a new capturing peer and starts the gossip
+// routines for it, as the switch does once a peer is up.
+func addRunningTestPeer(conR *ConsensusManager, height uint64, round uint32, step cstypes.RoundStepType) *testPeer {
+	peer := addTestPeer(conR)
+	peer.Get(types.PeerStateKey).(*PeerState).ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: height,
+		Round:  round,
+		Step:   step,
+	})
+	conR.AddPeer(peer)
+	return peer
+}
+
+// waitForSent waits until the peer has received at least n messages.
+func waitForSent(t *testing.T, peer *testPeer, n int) []testPeerMsg {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if sent := peer.Sent(); len(sent) >= n {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d messages, got %d", n, len(peer.Sent()))
+	return nil
+}
+
+// mockConsensusService is a ConsensusService serving a fixed round state.
+type mockConsensusService struct {
+	rs     cstypes.RoundState
+	config *configs.ConsensusConfig
+	evsw   kevents.EventSwitch
+	queue  chan msgInfo
+}
+
+func (s *mockConsensusService) GetRoundState() *cstypes.RoundState {
+	rs := s.rs
+	return &rs
+}
+func (s *mockConsensusService) Config() *configs.ConsensusConfig { return s.config }
+func (s *mockConsensusService) EventSwitch() kevents.EventSwitch { return s.evsw }
+func (s *mockConsensusService) PeerMsgQueue() chan msgInfo       { return s.queue }
+func (s *mockConsensusService) GetState() cstate.LatestBlockState {
+	return cstate.LatestBlockState{InitialHeight: 1}
+}
+func (s *mockConsensusService) GetProposalSlot() ProposalSlot {
+	return ProposalSlot{Height: s.rs.Height, Round: s.rs.Round, ProposeDeadline: s.rs.ProposeDeadline}
+}
+func (s *mockConsensusService) PrivValidator() types.PrivValidator         { return nil }
+func (s *mockConsensusService) SetPrivValidator(types.PrivValidator)       {}
+func (s *mockConsensusService) SetEventBus(*types.EventBus)                {}
+func (s *mockConsensusService) BlockOperations() BaseBlockOperations       { return nil }
+func (s *mockConsensusService) LoadCommit(uint64) *types.Commit            { return nil }
+func (s *mockConsensusService) ResetToState(cstate.LatestBlockState, bool) {}
+func (s *mockConsensusService) Start() error                               { return nil }
+func (s *mockConsensusService) Stop() error                                { return nil }
+func (s *mockConsensusService) Wait()                                      {}
+
+func TestBroadcastNewRoundStepWithMockService(t *testing.T) {
+	logger := log.TestingLogger()
+	svc := &mockConsensusService{
+		rs:     cstypes.RoundState{Height: 7, Round: 2, Step: cstypes.RoundStepPrevote, StartTime: time.Now()},
+		config: configs.TestConsensusConfig(),
+		evsw:   kevents.NewEventSwitch(),
+		queue:  make(chan msgInfo, 1),
+	}
+	conR := &ConsensusManager{service: svc, metrics: NopMetrics()}
+	conR.BaseReactor = *p2p.NewBaseReactor("Consensus", conR)
+	conR.SetLogger(logger)
+	priv, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	transport := p2p.NewMultiplexTransport(p2p.DefaultNodeInfo{}, p2p.NodeKey{PrivKey: priv}, conn.DefaulKAIConnConfig())
+	sw := p2p.NewSwitch(configs.DefaultP2PConfig(), transport)
+	sw.SetLogger(logger)
+	conR.SetSwitch(sw)
+	peer := addTestPeer(conR)
+
+	conR.subscribeToBroadcastEvents()
+	t.Cleanup(conR.unsubscribeFromBroadcastEvents)
+	svc.evsw.FireEvent(types.EventNewRoundStep, svc.GetRoundState())
+	sent := waitForSent(t, peer, 1)
+	require.Len(t, sent, 1)
+	assert.Equal(t, StateChannel, sent[0].chID)
+	msg := sent[0].msg.(*NewRoundStepMessage)
+	assert.EqualValues(t, 7, msg.Height)
+	assert.EqualValues(t, 2, msg.Round)
+	assert.Equal(t, cstypes.RoundStepPrevote, msg.Step)
+
+	// Our round step is sent to a single peer from the same service.
+	other := addTestPeer(conR)
+	conR.sendNewRoundStepMessage(other)
+	require.Len(t, other.Sent(), 1)
+	assert.EqualValues(t, 7, other.Sent()[0].msg.(*NewRoundStepMessage).Height)
+}
+
+func TestSubscribeToBroadcastEventsIdempotent(t *testing.T) {
+	conR, _ := newTestManager(t)
+	peer := addTestPeer(conR)
+
+	// OnStart already subscribed once.
+	conR.subscribeToBroadcastEvents()
+	conR.subscribeToBroadcastEvents()
+
+	consensusState(conR).evsw.FireEvent(types.EventVote, &types.Vote{
+		Height:         1,
+		Round:          1,
+		Type:           kproto.PrevoteType,
+		ValidatorIndex: 0,
+	})
+
+	waitForSent(t, peer, 1)
+	// Give any duplicated listener a chance to broadcast as well.
+	time.Sleep(50 * time.Millisecond)
+	sent := peer.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, StateChannel, sent[0].chID)
+	assert.IsType(t, &HasVoteMessage{}, sent[0].msg)
+}
+
+// receiveMsg delivers msg from peer to the manager on the given channel.
+func receiveMsg(conR *ConsensusManager, chID byte, peer p2p.Peer, msg Message) {
+	conR.Receive(chID, peer, MustEncode(msg))
+}
+
+func randBlockID() types.BlockID {
+	return types.BlockID{
+		Hash: common.BytesToHash(rand.Bytes(32)),
+		PartsHeader: types.PartSetHeader{
+			Total: 1,
+			Hash:  common.BytesToHash(rand.Bytes(32)),
+		},
+	}
+}
+
+// signTestProposal signs the proposal with the current proposer's key.
+func signTestProposal(t *testing.T, conR *ConsensusManager, privVals []types.PrivValidator, proposal *types.Proposal) *types.Proposal {
+	idx, _ := consensusState(conR).Validators.GetByAddress(consensusState(conR).Validators.GetProposer().Address)
+	pb := proposal.ToProto()
+	require.NoError(t, privVals[idx].SignProposal(conR.ChainID(), pb))
+	proposal.Signature = pb.Signature
+	return proposal
+}
+
+// signTestVote signs the vote for chainID with the given key.
+func signTestVote(t *testing.T, privVal types.PrivValidator, chainID string, vote *types.Vote) *types.Vote {
+	pb := vote.ToProto()
+	require.NoError(t, privVal.SignVote(chainID, pb))
+	vote.Signature = pb.Signature
+	return vote
+}
+
+func TestReceiveDispatchesByChannel(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+
+	// StateChannel: round step updates the peer state.
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height:          1,
+		Round:           1,
+		Step:            cstypes.RoundStepPropose,
+		LastCommitRound: 1,
+	})
+	prs := ps.GetRoundState()
+	assert.EqualValues(t, 1, prs.Height)
+	assert.Equal(t, cstypes.RoundStepPropose, prs.Step)
+
+	// DataChannel: proposals are queued for the consensus state.
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: proposal})
+	mi := <-consensusState(conR).peerMsgQueue
+	assert.IsType(t, &ProposalMessage{}, mi.Msg)
+	assert.Equal(t, peer.ID(), mi.PeerID)
+	assert.True(t, ps.GetRoundState().Proposal)
+
+	// VoteChannel: votes are queued for the consensus state.
+	vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+		Type:             kproto.PrevoteType,
+		Height:           1,
+		Round:            1,
+		Timestamp:        time.Now(),
+		ValidatorAddress: privVals[0].GetAddress(),
+	})
+	receiveMsg(conR, VoteChannel, peer, &VoteMessage{Vote: vote})
+	mi = <-consensusState(conR).peerMsgQueue
+	assert.IsType(t, &VoteMessage{}, mi.Msg)
+	assert.True(t, ps.GetRoundState().Prevotes.GetIndex(0))
+
+	// VoteSetBitsChannel: the peer's vote bit array is updated.
+	votes := common.NewBitArray(4)
+	votes.SetIndex(3, true)
+	receiveMsg(conR, VoteSetBitsChannel, peer, &VoteSetBitsMessage{
+		Height:  1,
+		Round:   1,
+		Type:    kproto.PrevoteType,
+		BlockID: randBlockID(),
+		Votes:   votes,
+	})
+	assert.True(t, ps.GetRoundState().Prevotes.GetIndex(3))
+	assert.True(t, peer.IsRunning())
+}
+
+func TestReceiveRejectsWrongChannel(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+
+	vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+		Type:             kproto.PrevoteType,
+		Height:           1,
+		Round:            1,
+		Timestamp:        time.Now(),
+		ValidatorAddress: privVals[0].GetAddress(),
+	})
+	receiveMsg(conR, StateChannel, peer, &VoteMessage{Vote: vote})
+	assert.False(t, peer.IsRunning())
+	assert.False(t, ps.GetRoundState().Prevotes.GetIndex(0))
+	assert.Empty(t, consensusState(conR).peerMsgQueue)
+
+	// Round steps are only taken from the state channel.
+	peer = addTestPeer(conR)
+	ps = peer.Get(types.PeerStateKey).(*PeerState)
+	receiveMsg(conR, DataChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+	assert.False(t, peer.IsRunning())
+	assert.Zero(t, ps.GetRoundState().Height)
+}
+
+func TestReceiveDropsVotesOutOfHeightWindow(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+
+	for _, height := range []uint64{100, 2} {
+		vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+			Type:             kproto.PrevoteType,
+			Height:           height,
+			Round:            1,
+			Timestamp:        time.Now(),
+			ValidatorAddress: privVals[0].GetAddress(),
+		})
+		receiveMsg(conR, VoteChannel, peer, &VoteMessage{Vote: vote})
+		assert.Len(t, consensusState(conR).peerMsgQueue, 0, height)
+	}
+	assert.True(t, peer.IsRunning())
+
+	// Votes for our height are still queued.
+	vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+		Type:             kproto.PrevoteType,
+		Height:           1,
+		Round:            1,
+		Timestamp:        time.Now(),
+		ValidatorAddress: privVals[0].GetAddress(),
+	})
+	receiveMsg(conR, VoteChannel, peer, &VoteMessage{Vote: vote})
+	assert.Len(t, consensusState(conR).peerMsgQueue, 1)
+}
+
+func TestReceiveRejectsForeignChainSignatures(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+
+	proposal := types.NewProposal(1, 1, 0, randBlockID())
+	conR.SetChainID("other-chain")
+	signTestProposal(t, conR, privVals, proposal)
+	conR.SetChainID(testChainID)
+
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: proposal})
+	assert.False(t, peer.IsRunning())
+	assert.Len(t, consensusState(conR).peerMsgQueue, 0)
+
+	peer = addTestPeer(conR)
+	vote := signTestVote(t, privVals[1], "other-chain", &types.Vote{
+		Type:             kproto.PrecommitType,
+		Height:           1,
+		Round:            1,
+		Timestamp:        time.Now(),
+		ValidatorAddress: privVals[1].GetAddress(),
+		ValidatorIndex:   1,
+	})
+	receiveMsg(conR, VoteChannel, peer, &VoteMessage{Vote: vote})
+	assert.False(t, peer.IsRunning())
+	assert.Len(t, consensusState(conR).peerMsgQueue, 0)
+}
+
+func TestReceiveRecoversFromPanic(t *testing.T) {
+	conR, _ := newTestManager(t)
+
+	// A peer without a state makes the handler panic.
+	peer := newTestPeer()
+	p2p.AddPeerToSwitchPeerSet(conR.Switch, peer)
+	assert.NotPanics(t, func() {
+		receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPropose})
+	})
+	assert.False(t, peer.IsRunning())
+
+	// Other peers are still served.
+	other := addTestPeer(conR)
+	receiveMsg(conR, StateChannel, other, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 1})
+	assert.True(t, other.IsRunning())
+	assert.Equal(t, cstypes.RoundStepPropose, other.Get(types.PeerStateKey).(*PeerState).GetRoundState().Step)
+}
+
+func TestGossipRoutinesRecoverFromPanic(t *testing.T) {
+	conR, _ := newTestManager(t)
+	routines := map[string]func(p2p.Peer, *PeerState){
+		"gossipData":  conR.gossipDataRoutine,
+		"gossipVotes": conR.gossipVotesRoutine,
+		"queryMaj23":  conR.queryMaj23Routine,
+	}
+	for name, routine := range routines {
+		// A nil peer state makes the routine panic on first use.
+		peer := addTestPeer(conR)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			routine(peer, nil)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s routine did not return", name)
+		}
+		assert.False(t, peer.IsRunning(), name)
+	}
+}
+
+// nilRoundStateService is a ConsensusService without a round state, as during
+// shutdown.
+type nilRoundStateService struct {
+	ConsensusService
+}
+
+func (nilRoundStateService) GetRoundState() *cstypes.RoundState { return nil }
+
+func TestGossipRoutinesStopWithoutRoundState(t *testing.T) {
+	conR, _ := newTestManager(t)
+	conR.service = nilRoundStateService{conR.service}
+	routines := map[string]func(p2p.Peer, *PeerState){
+		"gossipData":  conR.gossipDataRoutine,
+		"gossipVotes": conR.gossipVotesRoutine,
+	}
+	for name, routine := range routines {
+		peer := addTestPeer(conR)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			routine(peer, peer.Get(types.PeerStateKey).(*PeerState))
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s routine did not return", name)
+		}
+		// The routine returned on its own rather than recovering from a panic,
+		// which would have stopped the peer.
+		assert.True(t, peer.IsRunning(), name)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestDisconnectReasonOf(t *testing.T) {
+	testCases := []struct {
+		reason   interface{}
+		expected DisconnectReason
+	}{
+		{nil, DisconnectShutdown},
+		{conn.ErrPongTimeout, DisconnectTimeout},
+		{fmt.Errorf("read: %w", timeoutError{}), DisconnectTimeout},
+		{io.EOF, DisconnectConnectionClosed},
+		{fmt.Errorf("read: %w", net.ErrClosed), DisconnectConnectionClosed},
+		{ErrInvalidProposalSignature, DisconnectProtocolError},
+		{errors.New("unknown channel 42"), DisconnectProtocolError},
+		{"some reason", DisconnectUnknown},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, disconnectReasonOf(tc.reason), "reason: %v", tc.reason)
+	}
+
+	conR, _ := newTestManager(t)
+	peer := addTestPeer(conR)
+	conR.RemovePeer(peer, io.EOF)
+	assert.IsType(t, struct{}{}, peer.Get(types.PeerStateKey))
+}
+
+func TestReceiveRejectsOversizedBlocks(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	// Allow blocks of at most 3 parts.
+	consensusState(conR).state.ConsensusParams.Block.MaxBytes = 2 * types.BlockPartSizeBytes
+	require.EqualValues(t, 3, conR.maxBlockPartsCount())
+
+	blockID := randBlockID()
+	blockID.PartsHeader.Total = 4
+	peer := addTestPeer(conR)
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, blockID))
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: proposal})
+	assert.False(t, peer.IsRunning())
+	assert.False(t, peer.Get(types.PeerStateKey).(*PeerState).GetRoundState().Proposal)
+
+	peer = addTestPeer(conR)
+	receiveMsg(conR, StateChannel, peer, &NewValidBlockMessage{
+		Height:           1,
+		Round:            1,
+		BlockPartsHeader: blockID.PartsHeader,
+		BlockParts:       common.NewBitArray(int(blockID.PartsHeader.Total)),
+	})
+	assert.False(t, peer.IsRunning())
+
+	peer = addTestPeer(conR)
+	receiveMsg(conR, DataChannel, peer, &BlockPartMessage{
+		Height: 1,
+		Round:  1,
+		Part:   &types.Part{Index: 3, Bytes: []byte{0x01}},
+	})
+	assert.False(t, peer.IsRunning())
+	assert.Len(t, consensusState(conR).peerMsgQueue, 0)
+
+	// A block within bounds is still accepted.
+	blockID.PartsHeader.Total = 3
+	peer = addTestPeer(conR)
+	proposal = signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, blockID))
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: proposal})
+	assert.True(t, peer.IsRunning())
+	assert.Len(t, consensusState(conR).peerMsgQueue, 1)
+}
+
+func TestGossipVotesSendsMissingLastCommit(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	valSet := consensusState(conR).Validators
+
+	// Our LastCommit holds every precommit for height 1.
+	blockID := randBlockID()
+	lastCommit := types.NewVoteSet(testChainID, 1, 1, kproto.PrecommitType, valSet)
+	for i, pv := range privVals {
+		vote := signTestVote(t, pv, testChainID, &types.Vote{
+			Type:             kproto.PrecommitType,
+			Height:           1,
+			Round:            1,
+			BlockID:          blockID,
+			Timestamp:        time.Now(),
+			ValidatorAddress: pv.GetAddress(),
+			ValidatorIndex:   uint32(i),
+		})
+		added, err := lastCommit.AddVote(vote)
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+	rs := &cstypes.RoundState{
+		Height:     2,
+		Round:      1,
+		Step:       cstypes.RoundStepPropose,
+		Validators: valSet,
+		Votes:      cstypes.NewHeightVoteSet(log.TestingLogger(), testChainID, 2, valSet),
+		LastCommit: lastCommit,
+	}
+
+	// The peer already moved on to height 2 missing the precommit of validator 2.
+	peerLastCommit := common.NewBitArray(valSet.Size())
+	for i := 0; i < valSet.Size(); i++ {
+		peerLastCommit.SetIndex(i, i != 2)
+	}
+	ps.mtx.Lock()
+	ps.PRS.Height = 2
+	ps.PRS.Round = 1
+	ps.PRS.Step = cstypes.RoundStepPropose
+	ps.PRS.LastCommitRound = 1
+	ps.PRS.LastCommit = peerLastCommit
+	ps.mtx.Unlock()
+
+	prs := ps.GetRoundState()
+	require.True(t, conR.gossipVotesForHeight(conR.Logger, rs, prs, ps))
+	sent := peer.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, VoteChannel, sent[0].chID)
+	vote := sent[0].msg.(*VoteMessage).Vote
+	assert.EqualValues(t, 1, vote.Height)
+	assert.EqualValues(t, 2, vote.ValidatorIndex)
+	assert.True(t, ps.GetRoundState().LastCommit.IsFull())
+
+	// Nothing is left to send once the peer's LastCommit is complete.
+	assert.False(t, conR.gossipVotesForHeight(conR.Logger, rs, ps.GetRoundState(), ps))
+}
+
+func TestQueuePeerMsgDropPolicy(t *testing.T) {
+	newMsg := func(height uint64) msgInfo {
+		return msgInfo{Msg: &HasVoteMessage{Height: height}, PeerID: "peer"}
+	}
+	fill := func(conR *ConsensusManager) {
+		for i := 0; i < cap(consensusState(conR).peerMsgQueue); i++ {
+			consensusState(conR).peerMsgQueue <- newMsg(uint64(i + 1))
+		}
+	}
+	// queue runs queuePeerMsg, failing the test if it blocks.
+	queue := func(conR *ConsensusManager, mi msgInfo) bool {
+		done := make(chan bool, 1)
+		go func() { done <- conR.queuePeerMsg(mi) }()
+		select {
+		case ok := <-done:
+			return ok
+		case <-time.After(time.Second):
+			t.Fatal("queuePeerMsg blocked on a full queue")
+			return false
+		}
+	}
+
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.PeerMsgQueueDropPolicy = configs.PeerMsgQueueRejectNewest
+	fill(conR)
+	assert.False(t, queue(conR, newMsg(0)))
+	assert.Len(t, consensusState(conR).peerMsgQueue, cap(consensusState(conR).peerMsgQueue))
+	assert.EqualValues(t, 1, (<-consensusState(conR).peerMsgQueue).Msg.(*HasVoteMessage).Height)
+
+	conR, _ = newTestManager(t)
+	consensusState(conR).config.PeerMsgQueueDropPolicy = configs.PeerMsgQueueDropOldest
+	fill(conR)
+	assert.True(t, queue(conR, newMsg(0)))
+	assert.Len(t, consensusState(conR).peerMsgQueue, cap(consensusState(conR).peerMsgQueue))
+	// The oldest message is gone and the new one was queued last.
+	assert.EqualValues(t, 2, (<-consensusState(conR).peerMsgQueue).Msg.(*HasVoteMessage).Height)
+	var last msgInfo
+	for len(consensusState(conR).peerMsgQueue) > 0 {
+		last = <-consensusState(conR).peerMsgQueue
+	}
+	assert.EqualValues(t, 0, last.Msg.(*HasVoteMessage).Height)
+}
+
+func TestReceiveRejectsWrongSizedProposalPOL(t *testing.T) {
+	conR, _ := newTestManager(t)
+	valSize := consensusState(conR).Validators.Size()
+
+	newPOLMsg := func(size int) *ProposalPOLMessage {
+		pol := common.NewBitArray(size)
+		pol.SetIndex(size-1, true)
+		return &ProposalPOLMessage{Height: 1, ProposalPOLRound: 1, ProposalPOL: pol}
+	}
+	setPeerPOLRound := func(ps *PeerState) {
+		ps.mtx.Lock()
+		ps.PRS.Height = 1
+		ps.PRS.ProposalPOLRound = 1
+		ps.mtx.Unlock()
+	}
+
+	peer := addTestPeer(conR)
+	setPeerPOLRound(peer.Get(types.PeerStateKey).(*PeerState))
+	receiveMsg(conR, DataChannel, peer, newPOLMsg(valSize+5))
+	assert.False(t, peer.IsRunning())
+	assert.Nil(t, peer.Get(types.PeerStateKey).(*PeerState).GetRoundState().ProposalPOL)
+
+	peer = addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	setPeerPOLRound(ps)
+	receiveMsg(conR, DataChannel, peer, newPOLMsg(valSize))
+	assert.True(t, peer.IsRunning())
+	assert.Equal(t, valSize, ps.GetRoundState().ProposalPOL.Size())
+}
+
+func TestReceiveHasVoteOutOfRangeIndex(t *testing.T) {
+	conR, _ := newTestManager(t)
+	valSize := consensusState(conR).Validators.Size()
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	ps.mtx.Lock()
+	ps.PRS.Height = 1
+	ps.PRS.Round = 1
+	ps.mtx.Unlock()
+	ps.EnsureVoteBitArrays(1, valSize)
+
+	// setHasVote ignores indexes past the bit array.
+	assert.NotPanics(t, func() {
+		ps.mtx.Lock()
+		defer ps.mtx.Unlock()
+		ps.setHasVote(1, 1, kproto.PrevoteType, uint32(valSize+100))
+	})
+
+	assert.NotPanics(t, func() {
+		receiveMsg(conR, StateChannel, peer, &HasVoteMessage{
+			Height: 1,
+			Round:  1,
+			Type:   kproto.PrevoteType,
+			Index:  uint32(valSize),
+		})
+	})
+	assert.False(t, peer.IsRunning())
+	assert.True(t, ps.GetRoundState().Prevotes.IsEmpty())
+
+	peer = addTestPeer(conR)
+	ps = peer.Get(types.PeerStateKey).(*PeerState)
+	ps.mtx.Lock()
+	ps.PRS.Height = 1
+	ps.PRS.Round = 1
+	ps.mtx.Unlock()
+	ps.EnsureVoteBitArrays(1, valSize)
+	receiveMsg(conR, StateChannel, peer, &HasVoteMessage{
+		Height: 1,
+		Round:  1,
+		Type:   kproto.PrevoteType,
+		Index:  uint32(valSize - 1),
+	})
+	assert.True(t, peer.IsRunning())
+	assert.True(t, ps.GetRoundState().Prevotes.GetIndex(valSize-1))
+}
+
+func TestHealthy(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.StallTimeout = 50 * time.Millisecond
+
+	healthy, reason := conR.Healthy()
+	assert.False(t, healthy)
+	assert.Equal(t, "waiting for sync", reason)
+
+	// The consensus state is never started, so restore waitSync before the
+	// manager is stopped.
+	setWaitSync := func(waitSync bool) {
+		conR.mtx.Lock()
+		conR.waitSync = waitSync
+		conR.mtx.Unlock()
+	}
+	setWaitSync(false)
+	defer setWaitSync(true)
+
+	healthy, reason = conR.Healthy()
+	assert.False(t, healthy)
+	assert.Contains(t, reason, "no peers")
+
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	ps.mtx.Lock()
+	ps.PRS.Height = 1
+	ps.mtx.Unlock()
+	healthy, reason = conR.Healthy()
+	assert.True(t, healthy, reason)
+
+	// Hold the round static past the timeout.
+	time.Sleep(2 * consensusState(conR).config.StallTimeout)
+	healthy, reason = conR.Healthy()
+	assert.False(t, healthy)
+	assert.Contains(t, reason, "stalled at height 1 round 1")
+
+	// Advancing the round makes consensus healthy again.
+	consensusState(conR).mtx.Lock()
+	consensusState(conR).Round++
+	consensusState(conR).mtx.Unlock()
+	healthy, reason = conR.Healthy()
+	assert.True(t, healthy, reason)
+}
+
+func TestHealthyStallClockStartsOnStart(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.StallTimeout = 50 * time.Millisecond
+	conR.mtx.Lock()
+	conR.waitSync = false
+	conR.mtx.Unlock()
+	defer func() {
+		conR.mtx.Lock()
+		conR.waitSync = true
+		conR.mtx.Unlock()
+	}()
+
+	// No probe has run yet, but the round has been static since OnStart.
+	time.Sleep(2 * consensusState(conR).config.StallTimeout)
+	healthy, reason := conR.Healthy()
+	assert.False(t, healthy)
+	assert.Contains(t, reason, "stalled at height 1 round 1")
+}
+
+// catchupBlockOperations serves a single committed block from memory.
+type catchupBlockOperations struct {
+	BaseBlockOperations
+
+	block  *types.Block
+	parts  *types.PartSet
+	commit *types.Commit
+}
+
+func (bo *catchupBlockOperations) Base() uint64   { return 1 }
+func (bo *catchupBlockOperations) Height() uint64 { return bo.block.Height() }
+
+func (bo *catchupBlockOperations) LoadBlockMeta(height uint64) *types.BlockMeta {
+	if height != bo.block.Height() {
+		return nil
+	}
+	return types.NewBlockMeta(bo.block, bo.parts)
+}
+
+func (bo *catchupBlockOperations) LoadBlockPart(height uint64, index int) *types.Part {
+	if height != bo.block.Height() {
+		return nil
+	}
+	return bo.parts.GetPart(index)
+}
+
+func (bo *catchupBlockOperations) LoadBlockCommit(height uint64) *types.Commit {
+	if height != bo.block.Height() {
+		return nil
+	}
+	return bo.commit
+}
+
+func TestGossipCatchupBehindPeer(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	valSet := consensusState(conR).Validators
+
+	// We have committed height 1 and are at height 3.
+	block := types.NewBlock(&types.Header{Height: 1, Time: time.Now()}, nil, &types.Commit{}, nil, trie.NewStackTrie(nil))
+	parts := block.MakePartSet(types.BlockPartSizeBytes)
+	blockID := types.BlockID{Hash: block.Hash(), PartsHeader: parts.Header()}
+	precommits := types.NewVoteSet(testChainID, 1, 1, kproto.PrecommitType, valSet)
+	for i, pv := range privVals {
+		vote := signTestVote(t, pv, testChainID, &types.Vote{
+			Type:             kproto.PrecommitType,
+			Height:           1,
+			Round:            1,
+			BlockID:          blockID,
+			Timestamp:        time.Now(),
+			ValidatorAddress: pv.GetAddress(),
+			ValidatorIndex:   uint32(i),
+		})
+		_, err := precommits.AddVote(vote)
+		require.NoError(t, err)
+	}
+	consensusState(conR).blockOperations = &catchupBlockOperations{block: block, parts: parts, commit: precommits.MakeCommit()}
+	rs := &cstypes.RoundState{Height: 3, Round: 1}
+
+	// The peer is still at height 1.
+	ps.mtx.Lock()
+	ps.PRS.Height = 1
+	ps.PRS.Round = 1
+	ps.mtx.Unlock()
+
+	require.True(t, conR.gossipCommitForCatchup(conR.Logger, ps.GetRoundState(), ps))
+	ps.InitProposalBlockParts(blockID.PartsHeader)
+	conR.gossipDataForCatchup(rs, ps.GetRoundState(), ps, peer)
+
+	sent := peer.Sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, VoteChannel, sent[0].chID)
+	vote := sent[0].msg.(*VoteMessage).Vote
+	assert.EqualValues(t, 1, vote.Height)
+	assert.Equal(t, blockID, vote.BlockID)
+	assert.Equal(t, DataChannel, sent[1].chID)
+	part := sent[1].msg.(*BlockPartMessage)
+	assert.EqualValues(t, 1, part.Height)
+	assert.Equal(t, parts.GetPart(0).Bytes, part.Part.Bytes)
+	assert.True(t, ps.GetRoundState().ProposalBlockParts.IsFull())
+
+	// Nothing is sent for a height we do not have a commit for.
+	ps.mtx.Lock()
+	ps.PRS.Height = 2
+	ps.mtx.Unlock()
+	assert.False(t, conR.gossipCommitForCatchup(conR.Logger, ps.GetRoundState(), ps))
+}
+
+func TestGetPeerStatesSorted(t *testing.T) {
+	conR, _ := newTestManager(t)
+	assert.Empty(t, conR.GetPeerStates())
+
+	// Peer IDs are random, so insertion order is arbitrary.
+	peers := make(map[p2p.ID]*testPeer)
+	for height := uint64(1); height <= 8; height++ {
+		peer := addTestPeer(conR)
+		ps := peer.Get(types.PeerStateKey).(*PeerState)
+		ps.mtx.Lock()
+		ps.PRS.Height = height
+		ps.mtx.Unlock()
+		peers[peer.ID()] = peer
+	}
+
+	states := conR.GetPeerStates()
+	require.Len(t, states, len(peers))
+	assert.True(t, sort.SliceIsSorted(states, func(i, j int) bool {
+		return states[i].NodeID < states[j].NodeID
+	}))
+	for _, state := range states {
+		ps := peers[state.NodeID].Get(types.PeerStateKey).(*PeerState)
+		assert.Equal(t, ps.GetHeight(), state.RoundState.Height)
+	}
+}
+
+func TestGossipVotesPrefersPrecommits(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	blockID := randBlockID()
+	for _, voteType := range []kproto.SignedMsgType{kproto.PrevoteType, kproto.PrecommitType} {
+		vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+			Type:             voteType,
+			Height:           1,
+			Round:            1,
+			BlockID:          blockID,
+			Timestamp:        time.Now(),
+			ValidatorAddress: privVals[0].GetAddress(),
+			ValidatorIndex:   0,
+		})
+		added, err := consensusState(conR).Votes.AddVote(vote, "")
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+
+	// The peer is prevoting in our round and misses both votes.
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+	rs := consensusState(conR).GetRoundState()
+
+	var names []string
+	for _, vs := range voteSetsToGossip(rs, ps.GetRoundState()) {
+		names = append(names, vs.name)
+	}
+	assert.Equal(t, []string{"rs.Precommits(prs.Round)", "rs.Prevotes(prs.Round)", "rs.Prevotes(prs.Round)"}, names)
+
+	for _, voteType := range []kproto.SignedMsgType{kproto.PrecommitType, kproto.PrevoteType} {
+		require.True(t, conR.gossipVotesForHeight(conR.Logger, rs, ps.GetRoundState(), ps))
+		sent := peer.Sent()
+		assert.Equal(t, voteType, sent[len(sent)-1].msg.(*VoteMessage).Vote.Type)
+	}
+	assert.False(t, conR.gossipVotesForHeight(conR.Logger, rs, ps.GetRoundState(), ps))
+}
+
+func TestReceiveVoteSetBitsAvoidsResend(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	blockID := randBlockID()
+	vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+		Type:             kproto.PrevoteType,
+		Height:           1,
+		Round:            1,
+		BlockID:          blockID,
+		Timestamp:        time.Now(),
+		ValidatorAddress: privVals[0].GetAddress(),
+		ValidatorIndex:   0,
+	})
+	added, err := consensusState(conR).Votes.AddVote(vote, "")
+	require.NoError(t, err)
+	require.True(t, added)
+
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+	receiveMsg(conR, StateChannel, peer, &HasVoteMessage{Height: 1, Round: 1, Type: kproto.PrevoteType, Index: 2})
+
+	// The peer reports our vote for the block, keeping the one we lack.
+	votes := common.NewBitArray(4)
+	votes.SetIndex(0, true)
+	receiveMsg(conR, VoteSetBitsChannel, peer, &VoteSetBitsMessage{
+		Height:  1,
+		Round:   1,
+		Type:    kproto.PrevoteType,
+		BlockID: blockID,
+		Votes:   votes,
+	})
+	prevotes := ps.GetRoundState().Prevotes
+	assert.True(t, prevotes.GetIndex(0))
+	assert.True(t, prevotes.GetIndex(2))
+	assert.False(t, prevotes.GetIndex(1))
+
+	rs := consensusState(conR).GetRoundState()
+	assert.False(t, conR.gossipVotesForHeight(conR.Logger, rs, ps.GetRoundState(), ps))
+	assert.Empty(t, peer.Sent())
+	assert.True(t, peer.IsRunning())
+}
+
+func TestChannelRecvMessageCapacity(t *testing.T) {
+	conR, _ := newTestManager(t)
+	cfg := *consensusState(conR).config
+	cfg.DataRecvMessageCapacity = 4 << 20
+	cfg.VoteRecvMessageCapacity = 64 << 10
+	consensusState(conR).config = &cfg
+
+	capacities := make(map[byte]int)
+	for _, ch := range conR.GetChannels() {
+		capacities[ch.ID] = ch.RecvMessageCapacity
+	}
+	assert.Equal(t, map[byte]int{
+		StateChannel:       maxMsgSize,
+		DataChannel:        4 << 20,
+		VoteChannel:        64 << 10,
+		VoteSetBitsChannel: maxMsgSize,
+	}, capacities)
+}
+
+func TestGoroutineCount(t *testing.T) {
+	conR, _ := newTestManager(t)
+	peer1 := addRunningTestPeer(conR, 1, 1, cstypes.RoundStepPropose)
+	peer2 := addRunningTestPeer(conR, 1, 1, cstypes.RoundStepPropose)
+	assert.Equal(t, 6, conR.GoroutineCount())
+
+	waitForCount := func(n int) {
+		deadline := time.Now().Add(2 * time.Second)
+		for conR.GoroutineCount() != n {
+			require.True(t, time.Now().Before(deadline), "%d routines running, want %d", conR.GoroutineCount(), n)
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	// The switch stops a peer before removing it.
+	require.NoError(t, peer1.Stop())
+	conR.RemovePeer(peer1, nil)
+	waitForCount(3)
+	require.NoError(t, peer2.Stop())
+	conR.RemovePeer(peer2, nil)
+	waitForCount(0)
+}
+
+func TestPeersAtHeight(t *testing.T) {
+	conR, _ := newTestManager(t)
+	for _, height := range []uint64{3, 4, 4, 5} {
+		peer := addTestPeer(conR)
+		peer.Get(types.PeerStateKey).(*PeerState).ApplyNewRoundStepMessage(&NewRoundStepMessage{
+			Height: height,
+			Step:   cstypes.RoundStepNewHeight,
+		})
+	}
+	// A peer without a round state yet is at height 0.
+	addTestPeer(conR)
+
+	assert.Equal(t, 2, conR.PeersAtHeight(4))
+	assert.Equal(t, 1, conR.PeersAtHeight(5))
+	assert.Equal(t, 1, conR.PeersAtHeight(0))
+	assert.Equal(t, 0, conR.PeersAtHeight(6))
+}
+
+func TestVoteSummary(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	prevotes, precommits, round := conR.VoteSummary()
+	assert.Equal(t, 0, prevotes)
+	assert.Equal(t, 0, precommits)
+	assert.EqualValues(t, 1, round)
+
+	blockID := randBlockID()
+	addVotes := func(voteType kproto.SignedMsgType, round uint32, n int) {
+		for i, pv := range privVals[:n] {
+			vote := signTestVote(t, pv, testChainID, &types.Vote{
+				Type:             voteType,
+				Height:           1,
+				Round:            round,
+				BlockID:          blockID,
+				Timestamp:        time.Now(),
+				ValidatorAddress: pv.GetAddress(),
+				ValidatorIndex:   uint32(i),
+			})
+			added, err := consensusState(conR).Votes.AddVote(vote, "")
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+	}
+	addVotes(kproto.PrevoteType, 1, 3)
+	addVotes(kproto.PrecommitType, 1, 2)
+	// Votes from other rounds are not counted.
+	addVotes(kproto.PrecommitType, 2, 4)
+
+	prevotes, precommits, round = conR.VoteSummary()
+	assert.Equal(t, 3, prevotes)
+	assert.Equal(t, 2, precommits)
+	assert.EqualValues(t, 1, round)
+}
+
+func TestNudgeStalledPeer(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.PeerStallTimeout = 20 * time.Millisecond
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	rs := consensusState(conR).GetRoundState()
+
+	// The peer is stuck at an old round of our height.
+	ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{Height: 1, Round: 0, Step: cstypes.RoundStepPrecommit})
+	assert.False(t, conR.nudgeStalledPeer(rs, ps.GetRoundState(), ps))
+
+	time.Sleep(2 * consensusState(conR).config.PeerStallTimeout)
+	require.True(t, conR.nudgeStalledPeer(rs, ps.GetRoundState(), ps))
+	sent := peer.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, StateChannel, sent[0].chID)
+	msg := sent[0].msg.(*NewRoundStepMessage)
+	assert.EqualValues(t, 1, msg.Height)
+	assert.EqualValues(t, 1, msg.Round)
+
+	// The nudge restarts the stall timer.
+	assert.False(t, conR.nudgeStalledPeer(rs, ps.GetRoundState(), ps))
+
+	// A peer at our round is never nudged.
+	ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPropose})
+	time.Sleep(2 * consensusState(conR).config.PeerStallTimeout)
+	assert.False(t, conR.nudgeStalledPeer(rs, ps.GetRoundState(), ps))
+	assert.Len(t, peer.Sent(), 1)
+}
+
+func TestEvictLaggingPeer(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.PeerLagHeights = 2
+	consensusState(conR).config.PeerLagTimeout = 20 * time.Millisecond
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	rs := &cstypes.RoundState{Height: 10}
+
+	// A peer within PeerLagHeights of us is never evicted.
+	ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{Height: 8, Round: 0, Step: cstypes.RoundStepPropose})
+	assert.False(t, conR.evictLaggingPeer(rs, ps.GetRoundState(), ps))
+	time.Sleep(2 * consensusState(conR).config.PeerLagTimeout)
+	assert.False(t, conR.evictLaggingPeer(rs, ps.GetRoundState(), ps))
+
+	// One further behind is, once it has lagged for the timeout.
+	rs.Height = 11
+	assert.False(t, conR.evictLaggingPeer(rs, ps.GetRoundState(), ps))
+	assert.True(t, peer.IsRunning())
+	time.Sleep(2 * consensusState(conR).config.PeerLagTimeout)
+	require.True(t, conR.evictLaggingPeer(rs, ps.GetRoundState(), ps))
+	assert.False(t, peer.IsRunning())
+}
+
+func TestPeerStateLagDuration(t *testing.T) {
+	ps := NewPeerState(newTestPeer()).SetLogger(log.TestingLogger())
+	assert.Zero(t, ps.lagDuration(10, 2)) // height not reported yet
+
+	ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{Height: 5, Round: 0, Step: cstypes.RoundStepPropose})
+	assert.Zero(t, ps.lagDuration(10, 2))
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, ps.lagDuration(10, 2) >= 10*time.Millisecond)
+
+	// Catching up to within maxLag restarts the timer.
+	ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{Height: 8, Round: 0, Step: cstypes.RoundStepPropose})
+	assert.Zero(t, ps.lagDuration(10, 2))
+	assert.Zero(t, ps.lagDuration(11, 2))
+}
+
+func TestApplyNewRoundStepHeightRegression(t *testing.T) {
+	ps := NewPeerState(newTestPeer()).SetLogger(log.TestingLogger())
+	require.NoError(t, ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 5, Round: 2, Step: cstypes.RoundStepPrecommit, LastCommitRound: 1,
+	}))
+	ps.EnsureVoteBitArrays(5, 4)
+	ps.EnsureVoteBitArrays(4, 4)
+	ps.SetHasVote(&types.Vote{Height: 5, Round: 2, Type: kproto.PrecommitType, ValidatorIndex: 1})
+	ps.SetHasVote(&types.Vote{Height: 4, Round: 1, Type: kproto.PrecommitType, ValidatorIndex: 2})
+	prs := ps.GetRoundState()
+	require.True(t, prs.Precommits.GetIndex(1))
+	require.True(t, prs.LastCommit.GetIndex(2))
+
+	// Going back in round is ignored...
+	require.NoError(t, ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 5, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 1,
+	}))
+	assert.EqualValues(t, 2, ps.GetRoundState().Round)
+
+	// ...as is going back by up to peerResetHeights...
+	require.NoError(t, ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 5 - peerResetHeights, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 1,
+	}))
+	assert.EqualValues(t, 5, ps.GetRoundState().Height)
+	assert.True(t, ps.GetRoundState().Precommits.GetIndex(1))
+
+	// ...but going back further starts the peer over.
+	ps.lagDuration(10, 2)
+	require.False(t, ps.laggingSince.IsZero())
+	require.NoError(t, ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 2, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 1,
+	}))
+	prs = ps.GetRoundState()
+	assert.EqualValues(t, 2, prs.Height)
+	assert.EqualValues(t, 1, prs.Round)
+	assert.EqualValues(t, 1, prs.LastCommitRound)
+	assert.Nil(t, prs.Precommits)
+	assert.Nil(t, prs.Prevotes)
+	assert.Nil(t, prs.LastCommit)
+	assert.True(t, ps.laggingSince.IsZero())
+}
+
+func TestGossipRelaysReceivedProposal(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	src := addTestPeer(conR)
+	dst := addRunningTestPeer(conR, 1, 1, cstypes.RoundStepPropose)
+
+	// A proposal received from src is queued for the consensus state...
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+	receiveMsg(conR, DataChannel, src, &ProposalMessage{Proposal: proposal})
+	mi := <-consensusState(conR).peerMsgQueue
+	require.Equal(t, src.ID(), mi.PeerID)
+
+	// ...and once accepted, the gossip routine relays it to dst.
+	consensusState(conR).mtx.Lock()
+	consensusState(conR).Proposal = mi.Msg.(*ProposalMessage).Proposal
+	consensusState(conR).mtx.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for !dst.Get(types.PeerStateKey).(*PeerState).GetRoundState().Proposal {
+		require.True(t, time.Now().Before(deadline), "proposal was not gossiped")
+		time.Sleep(5 * time.Millisecond)
+	}
+	var relayed *ProposalMessage
+	for _, sent := range dst.Sent() {
+		if msg, ok := sent.msg.(*ProposalMessage); ok {
+			assert.Equal(t, DataChannel, sent.chID)
+			relayed = msg
+		}
+	}
+	require.NotNil(t, relayed)
+	assert.Equal(t, proposal.POLBlockID, relayed.Proposal.POLBlockID)
+	assert.Equal(t, proposal.Signature, relayed.Proposal.Signature)
+	assert.Empty(t, src.Sent())
+}
+
+func TestReceiveDuplicateProposal(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+
+	// The same proposal is queued once.
+	for i := 0; i < 3; i++ {
+		receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: proposal})
+	}
+	require.Len(t, consensusState(conR).peerMsgQueue, 1)
+	<-consensusState(conR).peerMsgQueue
+	assert.True(t, peer.IsRunning())
+
+	// Other peers relaying it are not punished.
+	other := addTestPeer(conR)
+	receiveMsg(conR, DataChannel, other, &ProposalMessage{Proposal: proposal})
+	assert.Empty(t, consensusState(conR).peerMsgQueue)
+	assert.True(t, other.IsRunning())
+
+	// A different proposal for the same height and round is dropped, the peer
+	// only relayed what the proposer signed.
+	conflicting := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: conflicting})
+	assert.Empty(t, consensusState(conR).peerMsgQueue)
+	assert.True(t, peer.IsRunning())
+
+	// Unverified proposals are not recorded, so a forged one does not keep
+	// the real proposal out.
+	conR2, privVals2 := newTestManager(t)
+	src := addTestPeer(conR2)
+	forged := signTestProposal(t, conR2, privVals2, types.NewProposal(1, 1, 0, randBlockID()))
+	forged.Signature = conflicting.Signature
+	receiveMsg(conR2, DataChannel, src, &ProposalMessage{Proposal: forged})
+	assert.Empty(t, consensusState(conR2).peerMsgQueue)
+	assert.Nil(t, src.Get(types.PeerStateKey).(*PeerState).recvProposal.bz)
+}
+
+// advancingService is a ConsensusService whose round moves on right after each
+// read of the round state or proposal slot.
+type advancingService struct {
+	ConsensusService
+	cs *ConsensusState
+}
+
+func (s advancingService) advance() {
+	s.cs.mtx.Lock()
+	s.cs.Round++
+	s.cs.mtx.Unlock()
+}
+
+func (s advancingService) GetRoundState() *cstypes.RoundState {
+	defer s.advance()
+	return s.ConsensusService.GetRoundState()
+}
+
+func (s advancingService) GetProposalSlot() ProposalSlot {
+	defer s.advance()
+	return s.ConsensusService.GetProposalSlot()
+}
+
+func TestReceiveProposalChecksOneSlot(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	cs := consensusState(conR)
+	src := addTestPeer(conR)
+	forged := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+	forged.Signature[0] ^= 0xff
+	conR.service = advancingService{conR.service, cs}
+	defer func() { conR.service = cs }()
+
+	// The round moving on while the proposal is checked does not let a forged
+	// one through unverified.
+	receiveMsg(conR, DataChannel, src, &ProposalMessage{Proposal: forged})
+	assert.Empty(t, cs.peerMsgQueue)
+	assert.False(t, src.IsRunning())
+}
+
+func TestPeerStateValidatorSetChange(t *testing.T) {
+	ps := NewPeerState(newTestPeer()).SetLogger(log.TestingLogger())
+	require.NoError(t, ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 5, Round: 0, Step: cstypes.RoundStepPrecommit, LastCommitRound: 0,
+	}))
+	ps.EnsureVoteBitArrays(5, 4)
+
+	// Height 6 has 7 validators, its last commit still has 4.
+	require.NoError(t, ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 6, Round: 0, Step: cstypes.RoundStepPropose, LastCommitRound: 0,
+	}))
+	ps.EnsureVoteBitArrays(6, 7)
+	ps.EnsureVoteBitArrays(5, 4)
+	ps.SetHasVote(&types.Vote{Height: 5, Round: 0, Type: kproto.PrecommitType, ValidatorIndex: 3})
+	prs := ps.GetRoundState()
+	assert.Equal(t, 7, prs.Prevotes.Size())
+	assert.Equal(t, 7, prs.Precommits.Size())
+	assert.Equal(t, 4, prs.LastCommit.Size())
+	assert.True(t, prs.LastCommit.GetIndex(3))
+
+	require.NoError(t, ps.ApplyHasVoteMessage(&HasVoteMessage{Height: 6, Round: 0, Type: kproto.PrevoteType, Index: 6}))
+	assert.True(t, ps.GetRoundState().Prevotes.GetIndex(6))
+	ps.SetHasVote(&types.Vote{Height: 5, Round: 0, Type: kproto.PrecommitType, ValidatorIndex: 6})
+	assert.Equal(t, 4, ps.GetRoundState().LastCommit.Size())
+
+	// Arrays sized for another validator set are replaced.
+	ps.mtx.Lock()
+	ps.PRS.Precommits = common.NewBitArray(4)
+	ps.PRS.CatchupCommitRound, ps.PRS.CatchupCommit = 0, ps.PRS.Precommits
+	ps.mtx.Unlock()
+	ps.EnsureVoteBitArrays(6, 7)
+	prs = ps.GetRoundState()
+	assert.Equal(t, 7, prs.Precommits.Size())
+	assert.Equal(t, 7, prs.CatchupCommit.Size())
+	assert.True(t, prs.LastCommit.GetIndex(3))
+
+	// So are vote set bits of the wrong width.
+	blockID := randBlockID()
+	wrong := common.NewBitArray(4)
+	wrong.SetIndex(0, true)
+	ps.ApplyVoteSetBitsMessage(&VoteSetBitsMessage{Height: 6, Round: 0, Type: kproto.PrecommitType, BlockID: blockID, Votes: wrong}, nil)
+	assert.False(t, ps.GetRoundState().Precommits.GetIndex(0))
+	right := common.NewBitArray(7)
+	right.SetIndex(5, true)
+	ps.ApplyVoteSetBitsMessage(&VoteSetBitsMessage{Height: 6, Round: 0, Type: kproto.PrecommitType, BlockID: blockID, Votes: right}, nil)
+	assert.True(t, ps.GetRoundState().Precommits.GetIndex(5))
+}
+
+func TestReceiveLateProposal(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	cfg := *consensusState(conR).config
+	cfg.LateProposalTimeout = time.Second
+	consensusState(conR).config = &cfg
+	peer := addTestPeer(conR)
+
+	// The propose step of the round timed out long ago.
+	consensusState(conR).ProposeDeadline = time.Now().Add(-time.Minute)
+	late := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: late})
+	assert.Empty(t, consensusState(conR).peerMsgQueue)
+	assert.True(t, peer.IsRunning())
+
+	// Within the timeout it is accepted.
+	consensusState(conR).ProposeDeadline = time.Now()
+	other := addTestPeer(conR)
+	receiveMsg(conR, DataChannel, other, &ProposalMessage{Proposal: late})
+	require.Len(t, consensusState(conR).peerMsgQueue, 1)
+	<-consensusState(conR).peerMsgQueue
+}
+
+func TestReceiveReplayedProposal(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer := addTestPeer(conR)
+
+	// A proposal from round 0 is dropped at round 1, without stopping the
+	// peer which may simply be behind.
+	old := signTestProposal(t, conR, privVals, types.NewProposal(1, 0, types.NoPOLRound, randBlockID()))
+	receiveMsg(conR, DataChannel, peer, &ProposalMessage{Proposal: old})
+	assert.Empty(t, consensusState(conR).peerMsgQueue)
+	assert.True(t, peer.IsRunning())
+
+	// Replayed as a round 1 proposal, its signature no longer verifies.
+	replayed := *old
+	replayed.Round = 1
+	other := addTestPeer(conR)
+	receiveMsg(conR, DataChannel, other, &ProposalMessage{Proposal: &replayed})
+	assert.Empty(t, consensusState(conR).peerMsgQueue)
+	assert.False(t, other.IsRunning())
+}
+
+func TestReceiveProposalFromTwoPeers(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	peer1, peer2 := addTestPeer(conR), addTestPeer(conR)
+	for _, peer := range []*testPeer{peer1, peer2} {
+		peer.Get(types.PeerStateKey).(*PeerState).ApplyNewRoundStepMessage(&NewRoundStepMessage{
+			Height: 1, Round: 1, Step: cstypes.RoundStepPropose,
+		})
+	}
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+
+	receiveMsg(conR, DataChannel, peer1, &ProposalMessage{Proposal: proposal})
+	receiveMsg(conR, DataChannel, peer2, &ProposalMessage{Proposal: proposal})
+	require.Len(t, consensusState(conR).peerMsgQueue, 1)
+	mi := <-consensusState(conR).peerMsgQueue
+	assert.Equal(t, peer1.ID(), mi.PeerID)
+
+	// Both peers are known to have it, so it is not relayed back to them.
+	for _, peer := range []*testPeer{peer1, peer2} {
+		assert.True(t, peer.Get(types.PeerStateKey).(*PeerState).GetRoundState().Proposal)
+		assert.True(t, peer.IsRunning())
+	}
+
+	// A proposal for the next round is queued once we are in it.
+	consensusState(conR).Round = 2
+	next := signTestProposal(t, conR, privVals, types.NewProposal(1, 2, 0, randBlockID()))
+	receiveMsg(conR, DataChannel, peer2, &ProposalMessage{Proposal: next})
+	require.Len(t, consensusState(conR).peerMsgQueue, 1)
+}
+
+func TestReceiveProposalRejectedByFullQueue(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	cs := consensusState(conR)
+	cs.config.PeerMsgQueueDropPolicy = configs.PeerMsgQueueRejectNewest
+	peer1, peer2 := addTestPeer(conR), addTestPeer(conR)
+	for _, peer := range []*testPeer{peer1, peer2} {
+		peer.Get(types.PeerStateKey).(*PeerState).ApplyNewRoundStepMessage(&NewRoundStepMessage{
+			Height: 1, Round: 1, Step: cstypes.RoundStepPropose,
+		})
+	}
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+
+	for i := 0; i < cap(cs.peerMsgQueue); i++ {
+		cs.peerMsgQueue <- msgInfo{Msg: &HasVoteMessage{Height: 1}, PeerID: "peer"}
+	}
+	receiveMsg(conR, DataChannel, peer1, &ProposalMessage{Proposal: proposal})
+	require.Len(t, cs.peerMsgQueue, cap(cs.peerMsgQueue))
+	for len(cs.peerMsgQueue) > 0 {
+		<-cs.peerMsgQueue
+	}
+
+	// The rejected proposal is not taken for a duplicate once there is room.
+	receiveMsg(conR, DataChannel, peer2, &ProposalMessage{Proposal: proposal})
+	require.Len(t, cs.peerMsgQueue, 1)
+	mi := <-cs.peerMsgQueue
+	assert.Equal(t, peer2.ID(), mi.PeerID)
+	assert.Equal(t, proposal.Signature, mi.Msg.(*ProposalMessage).Proposal.Signature)
+}
+
+func TestBroadcastNewRoundStepSkipsUnchanged(t *testing.T) {
+	conR, _ := newTestManager(t)
+	peer := addTestPeer(conR)
+
+	rs := &cstypes.RoundState{Height: 1, Round: 1, Step: cstypes.RoundStepPropose, StartTime: time.Now()}
+	consensusState(conR).evsw.FireEvent(types.EventNewRoundStep, rs)
+	waitForSent(t, peer, 1)
+
+	// Only the elapsed time changed: nothing is sent.
+	consensusState(conR).evsw.FireEvent(types.EventNewRoundStep, rs)
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, peer.Sent(), 1)
+
+	next := *rs
+	next.Step = cstypes.RoundStepPrevote
+	consensusState(conR).evsw.FireEvent(types.EventNewRoundStep, &next)
+	sent := waitForSent(t, peer, 2)
+	require.Len(t, sent, 2)
+	assert.Equal(t, cstypes.RoundStepPrevote, sent[1].msg.(*NewRoundStepMessage).Step)
+}
+
+func TestBroadcastNewRoundStepCommitHeader(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.IsSendCommitHeaders = true
+	light, plain := addTestPeer(conR), addTestPeer(conR)
+	receiveMsg(conR, StateChannel, light, &NewRoundStepRequestMessage{CommitHeaders: true})
+	require.True(t, light.Get(types.PeerStateKey).(*PeerState).wantsCommitHeaders())
+
+	block := types.NewBlock(&types.Header{Height: 1, Time: time.Now()}, nil, &types.Commit{}, nil, trie.NewStackTrie(nil))
+	rs := &cstypes.RoundState{Height: 1, Round: 1, Step: cstypes.RoundStepCommit, StartTime: time.Now(), ProposalBlock: block}
+	consensusState(conR).evsw.FireEvent(types.EventNewRoundStep, rs)
+
+	msg := waitForSent(t, light, 1)[0].msg.(*NewRoundStepMessage)
+	require.NotNil(t, msg.CommitHeader)
+	assert.Equal(t, block.Hash(), msg.CommitHeader.Hash())
+	msg = waitForSent(t, plain, 1)[0].msg.(*NewRoundStepMessage)
+	assert.Nil(t, msg.CommitHeader)
+
+	// Outside the commit step there is no header to send.
+	next := *rs
+	next.Height, next.Step = 2, cstypes.RoundStepPropose
+	consensusState(conR).evsw.FireEvent(types.EventNewRoundStep, &next)
+	msg = waitForSent(t, light, 2)[1].msg.(*NewRoundStepMessage)
+	assert.Nil(t, msg.CommitHeader)
+}
+
+func TestRequestCommitHeaders(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).config.IsRequestCommitHeaders = true
+
+	// The request goes out with the handshake, whatever else the gossip
+	// routines send.
+	peer := addTestPeer(conR)
+	conR.AddPeer(peer)
+	require.Eventually(t, func() bool {
+		for _, sent := range peer.Sent() {
+			if msg, ok := sent.msg.(*NewRoundStepRequestMessage); ok {
+				return sent.chID == StateChannel && msg.CommitHeaders
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNewRoundStepCommitHeaderValidateBasic(t *testing.T) {
+	msg := &NewRoundStepMessage{Height: 2, Round: 0, Step: cstypes.RoundStepCommit, CommitHeader: &types.Header{Height: 2}}
+	require.NoError(t, msg.ValidateBasic())
+	msg.CommitHeader.Height = 1
+	require.Error(t, msg.ValidateBasic())
+	msg.CommitHeader.Height, msg.Step = 2, cstypes.RoundStepPrevote
+	require.Error(t, msg.ValidateBasic())
+}
+
+func TestBroadcastReportsFailedPeers(t *testing.T) {
+	conR, _ := newTestManager(t)
+	ok1, ok2 := addTestPeer(conR), addTestPeer(conR)
+	bad := failingTestPeer{mock.NewPeer(nil)}
+	conR.InitPeer(bad)
+	p2p.AddPeerToSwitchPeerSet(conR.Switch, bad)
+
+	msg := &HasVoteMessage{Height: 1, Round: 1, Type: kproto.PrevoteType, Index: 0}
+	select {
+	case failed := <-conR.broadcast(StateChannel, msg):
+		assert.Equal(t, 1, failed)
+	case <-time.After(time.Second):
+		t.Fatal("broadcast did not complete")
+	}
+	assert.Len(t, ok1.Sent(), 1)
+	assert.Len(t, ok2.Sent(), 1)
+}
+
+func TestGossipProposalPOL(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	consensusState(conR).mtx.Lock()
+	consensusState(conR).Round = 2
+	consensusState(conR).Votes.SetRound(2)
+	consensusState(conR).mtx.Unlock()
+
+	// sentPOL waits for the proposal to reach a fresh peer and reports
+	// whether a ProposalPOLMessage was sent along with it.
+	sentPOL := func(proposal *types.Proposal) bool {
+		consensusState(conR).mtx.Lock()
+		consensusState(conR).Proposal = proposal
+		consensusState(conR).mtx.Unlock()
+
+		peer := addRunningTestPeer(conR, 1, 2, cstypes.RoundStepPropose)
+		deadline := time.Now().Add(time.Second)
+		for !peer.Get(types.PeerStateKey).(*PeerState).GetRoundState().Proposal {
+			require.True(t, time.Now().Before(deadline), "proposal was not gossiped")
+			time.Sleep(5 * time.Millisecond)
+		}
+		for _, sent := range peer.Sent() {
+			if msg, ok := sent.msg.(*ProposalPOLMessage); ok {
+				assert.Equal(t, proposal.POLRound, msg.ProposalPOLRound)
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.False(t, sentPOL(signTestProposal(t, conR, privVals, types.NewProposal(1, 2, types.NoPOLRound, randBlockID()))))
+	assert.True(t, sentPOL(signTestProposal(t, conR, privVals, types.NewProposal(1, 2, 1, randBlockID()))))
+}
+
+func TestReceiveNewRoundStepSizesVoteBitArrays(t *testing.T) {
+	conR, _ := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	valSize := consensusState(conR).Validators.Size()
+
+	for round := uint32(1); round <= 2; round++ {
+		receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+			Height:          1,
+			Round:           round,
+			Step:            cstypes.RoundStepPropose,
+			LastCommitRound: 1,
+		})
+		prs := ps.GetRoundState()
+		require.Equal(t, round, prs.Round)
+		require.NotNil(t, prs.Prevotes)
+		require.NotNil(t, prs.Precommits)
+		assert.Equal(t, valSize, prs.Prevotes.Size())
+		assert.Equal(t, valSize, prs.Precommits.Size())
+
+		// HasVote announcements for the new round are tracked.
+		receiveMsg(conR, StateChannel, peer, &HasVoteMessage{Height: 1, Round: round, Type: kproto.PrecommitType, Index: 2})
+		assert.True(t, ps.GetRoundState().Precommits.GetIndex(2))
+	}
+}
+
+func TestReceiveNewRoundStepSkipsRepeats(t *testing.T) {
+	conR, _ := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	msg := &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 1}
+
+	receiveMsg(conR, StateChannel, peer, msg)
+	require.NotNil(t, ps.GetRoundState().Prevotes)
+
+	// A full pass would size the vote bit arrays again, a skipped one leaves
+	// them alone.
+	for i := 0; i < 3; i++ {
+		ps.mtx.Lock()
+		ps.PRS.Prevotes = nil
+		ps.mtx.Unlock()
+		msg.SecondsSinceStartTime++
+		receiveMsg(conR, StateChannel, peer, msg)
+		assert.Nil(t, ps.GetRoundState().Prevotes)
+	}
+
+	// A new step goes through.
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+	assert.Equal(t, cstypes.RoundStepPrevote, ps.GetRoundState().Step)
+	assert.NotNil(t, ps.GetRoundState().Prevotes)
+}
+
+func TestReceiveNewRoundStepRequest(t *testing.T) {
+	conR, _ := newTestManager(t)
+	requester := addTestPeer(conR)
+	other := addTestPeer(conR)
+
+	// Nothing is sent while fast syncing.
+	receiveMsg(conR, StateChannel, requester, &NewRoundStepRequestMessage{})
+	assert.Empty(t, requester.Sent())
+
+	// The consensus state is never started, so restore waitSync before the
+	// manager is stopped.
+	setWaitSync := func(waitSync bool) {
+		conR.mtx.Lock()
+		conR.waitSync = waitSync
+		conR.mtx.Unlock()
+	}
+	setWaitSync(false)
+	defer setWaitSync(true)
+
+	receiveMsg(conR, StateChannel, requester, &NewRoundStepRequestMessage{})
+	sent := requester.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, StateChannel, sent[0].chID)
+	msg, ok := sent[0].msg.(*NewRoundStepMessage)
+	require.True(t, ok, "unexpected message %T", sent[0].msg)
+	rs := consensusState(conR).GetRoundState()
+	assert.Equal(t, rs.Height, msg.Height)
+	assert.Equal(t, rs.Round, msg.Round)
+	assert.Equal(t, rs.Step, msg.Step)
+	assert.Empty(t, other.Sent())
+	assert.True(t, requester.IsRunning())
+
+	// The request itself goes out on the state channel.
+	require.True(t, conR.RequestNewRoundStep(other))
+	sent = other.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, StateChannel, sent[0].chID)
+	assert.IsType(t, &NewRoundStepRequestMessage{}, sent[0].msg)
+}
+
+func TestReceiveNewRoundStepStartTime(t *testing.T) {
+	conR, _ := newTestManager(t)
+
+	// The absolute start time survives the wire to the millisecond.
+	peer := addTestPeer(conR)
+	startTime := time.Now().Add(-1500 * time.Millisecond)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height:                1,
+		Round:                 1,
+		Step:                  cstypes.RoundStepPropose,
+		SecondsSinceStartTime: 1,
+		LastCommitRound:       1,
+		StartTime:             startTime,
+	})
+	prs := peer.Get(types.PeerStateKey).(*PeerState).GetRoundState()
+	assert.WithinDuration(t, startTime, prs.StartTime, time.Millisecond)
+
+	// Older peers only send the elapsed seconds.
+	peer = addTestPeer(conR)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height:                1,
+		Round:                 1,
+		Step:                  cstypes.RoundStepPropose,
+		SecondsSinceStartTime: 2,
+		LastCommitRound:       1,
+	})
+	prs = peer.Get(types.PeerStateKey).(*PeerState).GetRoundState()
+	assert.WithinDuration(t, time.Now().Add(-2*time.Second), prs.StartTime, time.Second)
+}
+
+func TestReceiveNewRoundStepLastCommitRound(t *testing.T) {
+	conR, _ := newTestManager(t)
+	consensusState(conR).state.InitialHeight = 1
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height: 2, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 2,
+	})
+	require.EqualValues(t, 2, ps.GetRoundState().LastCommitRound)
+
+	// Above the initial height there is always a last commit round.
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height: 3, Round: 1, Step: cstypes.RoundStepPropose, LastCommitRound: 0,
+	})
+	prs := ps.GetRoundState()
+	assert.EqualValues(t, 2, prs.Height)
+	assert.EqualValues(t, 2, prs.LastCommitRound)
+
+	// The last commit round can't change within a height.
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height: 2, Round: 2, Step: cstypes.RoundStepPropose, LastCommitRound: 5,
+	})
+	prs = ps.GetRoundState()
+	assert.EqualValues(t, 1, prs.Round)
+	assert.EqualValues(t, 2, prs.LastCommitRound)
+	err := ps.ApplyNewRoundStepMessage(&NewRoundStepMessage{
+		Height: 2, Round: 2, Step: cstypes.RoundStepPropose, LastCommitRound: 5,
+	})
+	assert.True(t, errors.Is(err, ErrWrongLastCommitRound), "unexpected error: %v", err)
+
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{
+		Height: 2, Round: 2, Step: cstypes.RoundStepPropose, LastCommitRound: 2,
+	})
+	assert.EqualValues(t, 2, ps.GetRoundState().Round)
+}
+
+func TestReceiveLogContext(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	src := addTestPeer(conR)
+
+	var (
+		mtx     sync.Mutex
+		records []*log.Record
+	)
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		records = append(records, r)
+		return nil
+	}))
+	conR.Logger = logger
+
+	proposal := signTestProposal(t, conR, privVals, types.NewProposal(1, 1, 0, randBlockID()))
+	receiveMsg(conR, DataChannel, src, &ProposalMessage{Proposal: proposal})
+	<-consensusState(conR).peerMsgQueue
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	var ctx map[interface{}]interface{}
+	for _, r := range records {
+		if r.Msg == "Receive" {
+			ctx = make(map[interface{}]interface{})
+			for i := 0; i+1 < len(r.Ctx); i += 2 {
+				ctx[r.Ctx[i]] = r.Ctx[i+1]
+			}
+		}
+	}
+	require.NotNil(t, ctx, "no receive record was logged")
+	assert.Equal(t, src.ID(), ctx["peer"])
+	assert.Equal(t, "ProposalMessage", ctx["type"])
+	assert.Equal(t, uint64(1), ctx["height"])
+	assert.Equal(t, uint32(1), ctx["round"])
+}
+
+func TestMakeNewValidBlockMessage(t *testing.T) {
+	rs := &cstypes.RoundState{Height: 1, Round: 1, Step: cstypes.RoundStepCommit}
+	assert.Nil(t, makeNewValidBlockMessage(rs))
+
+	block := types.NewBlock(&types.Header{Height: 1, Time: time.Now()}, nil, &types.Commit{}, nil, trie.NewStackTrie(nil))
+	rs.ProposalBlockParts = block.MakePartSet(types.BlockPartSizeBytes)
+	msg := makeNewValidBlockMessage(rs)
+	require.NotNil(t, msg)
+	require.NoError(t, msg.ValidateBasic())
+	assert.True(t, msg.IsCommit)
+	assert.Equal(t, rs.ProposalBlockParts.Header(), msg.BlockPartsHeader)
+}
+
+func TestReceiveInvalidHasVote(t *testing.T) {
+	conR, _ := newTestManager(t)
+
+	for _, msg := range []*HasVoteMessage{
+		{Height: 1, Round: 1, Type: kproto.SignedMsgType(0x7f), Index: 0},
+		{Height: 1, Round: 1, Type: kproto.PrevoteType, Index: types.MaxVotesCount},
+		{Height: 1, Round: 1, Type: kproto.PrevoteType, Index: ^uint32(0)},
+	} {
+		peer := addTestPeer(conR)
+		ps := peer.Get(types.PeerStateKey).(*PeerState)
+		assert.Error(t, msg.ValidateBasic())
+		receiveMsg(conR, StateChannel, peer, msg)
+		assert.False(t, peer.IsRunning(), "peer not stopped for %v", msg)
+		assert.Nil(t, ps.GetRoundState().Prevotes)
+	}
+}
+
+// testCounter is a metrics.Counter recording totals by label values.
+type testCounter struct {
+	mtx    *sync.Mutex
+	counts map[string]float64
+	lvs    []string
+}
+
+func newTestCounter() *testCounter {
+	return &testCounter{mtx: &sync.Mutex{}, counts: make(map[string]float64)}
+}
+
+func (c *testCounter) With(labelValues ...string) metrics.Counter {
+	return &testCounter{mtx: c.mtx, counts: c.counts, lvs: append(append([]string{}, c.lvs...), labelValues...)}
+}
+
+func (c *testCounter) Add(delta float64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.counts[strings.Join(c.lvs, ",")] += delta
+}
+
+func (c *testCounter) value(peer p2p.Peer, msgType string) float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.counts[strings.Join([]string{"peer_id", string(peer.ID()), "message_type", msgType}, ",")]
+}
+
+func TestManagerMetrics(t *testing.T) {
+	conR, privVals := newTestManager(t)
+	received, sent := newTestCounter(), newTestCounter()
+	conR.metrics = &Metrics{PeerReceiveMessages: received, PeerSendMessages: sent}
+
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+	receiveMsg(conR, StateChannel, peer, &HasVoteMessage{Height: 1, Round: 1, Type: kproto.PrevoteType, Index: 1})
+	receiveMsg(conR, StateChannel, peer, &HasVoteMessage{Height: 1, Round: 1, Type: kproto.PrevoteType, Index: 2})
+	assert.Equal(t, float64(1), received.value(peer, "NewRoundStepMessage"))
+	assert.Equal(t, float64(2), received.value(peer, "HasVoteMessage"))
+
+	vote := signTestVote(t, privVals[0], testChainID, &types.Vote{
+		Type:             kproto.PrevoteType,
+		Height:           1,
+		Round:            1,
+		BlockID:          randBlockID(),
+		Timestamp:        time.Now(),
+		ValidatorAddress: privVals[0].GetAddress(),
+		ValidatorIndex:   0,
+	})
+	added, err := consensusState(conR).Votes.AddVote(vote, "")
+	require.NoError(t, err)
+	require.True(t, added)
+
+	rs := consensusState(conR).GetRoundState()
+	require.True(t, conR.gossipVotesForHeight(conR.Logger, rs, ps.GetRoundState(), ps))
+	conR.sendNewRoundStepMessage(peer)
+	require.Len(t, peer.Sent(), 2)
+	assert.Equal(t, float64(1), sent.value(peer, "VoteMessage"))
+	assert.Equal(t, float64(1), sent.value(peer, "NewRoundStepMessage"))
+
+	// Nothing is counted for messages the peer refuses.
+	failing := failingTestPeer{mock.NewPeer(nil)}
+	assert.False(t, conR.sendMsg(failing, StateChannel, &NewRoundStepRequestMessage{}))
+	assert.Zero(t, sent.value(failing, "NewRoundStepRequestMessage"))
+}
+
+func TestPeerStateGetRoundStateDeepCopy(t *testing.T) {
+	conR, _ := newTestManager(t)
+	peer := addTestPeer(conR)
+	ps := peer.Get(types.PeerStateKey).(*PeerState)
+	receiveMsg(conR, StateChannel, peer, &NewRoundStepMessage{Height: 1, Round: 1, Step: cstypes.RoundStepPrevote, LastCommitRound: 1})
+
+	prs := ps.GetRoundState()
+	require.NotNil(t, prs.Prevotes)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			ps.setHasVote(1, 1, kproto.PrevoteType, uint32(i%4))
+			ps.setHasVote(1, 1, kproto.PrecommitType, uint32(i%4))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		snapshot := ps.GetRoundState()
+		_ = snapshot.Prevotes.String()
+		_ = snapshot.Precommits.String()
+	}
+	<-done
+
+	// Votes recorded after the snapshot was taken do not leak into it.
+	assert.True(t, prs.Prevotes.IsEmpty())
+	assert.True(t, prs.Precommits.IsEmpty())
+	assert.True(t, ps.GetRoundState().Prevotes.IsFull())
+	assert.NotSame(t, prs.Prevotes, ps.GetRoundState().Prevotes)
+}
@@ -3,6 +3,7 @@ package consensus
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	cstypes "github.com/kardiachain/go-kardia/consensus/types"
@@ -32,9 +33,19 @@ func MsgToProto(msg Message) (*kcons.Message, error) {
 					Step:                  uint32(msg.Step),
 					SecondsSinceStartTime: msg.SecondsSinceStartTime,
 					LastCommitRound:       msg.LastCommitRound,
+					StartTime:             unixMillis(msg.StartTime),
 				},
 			},
 		}
+		if msg.CommitHeader != nil {
+			pb.GetNewRoundStep().CommitHeader = msg.CommitHeader.ToProto()
+		}
+	case *NewRoundStepRequestMessage:
+		pb = kcons.Message{
+			Sum: &kcons.Message_NewRoundStepRequest{
+				NewRoundStepRequest: &kcons.NewRoundStepRequest{CommitHeaders: msg.CommitHeaders},
+			},
+		}
 	case *NewValidBlockMessage:
 		pbPartSetHeader := msg.BlockPartsHeader.ToProto()
 		pbBits := msg.BlockParts.ToProto()
@@ -153,13 +164,24 @@ func MsgFromProto(msg *kcons.Message) (Message, error) {
 	switch msg := msg.Sum.(type) {
 	case *kcons.Message_NewRoundStep:
 		rs := msg.NewRoundStep.Step
-		pb = &NewRoundStepMessage{
+		nrsMsg := &NewRoundStepMessage{
 			Height:                msg.NewRoundStep.Height,
 			Round:                 msg.NewRoundStep.Round,
 			Step:                  cstypes.RoundStepType(rs),
 			SecondsSinceStartTime: msg.NewRoundStep.SecondsSinceStartTime,
 			LastCommitRound:       msg.NewRoundStep.LastCommitRound,
-		}
+			StartTime:             fromUnixMillis(msg.NewRoundStep.StartTime),
+		}
+		if msg.NewRoundStep.CommitHeader != nil {
+			header, err := types.HeaderFromProto(msg.NewRoundStep.CommitHeader)
+			if err != nil {
+				return nil, fmt.Errorf("commit header from proto error: %w", err)
+			}
+			nrsMsg.CommitHeader = &header
+		}
+		pb = nrsMsg
+	case *kcons.Message_NewRoundStepRequest:
+		pb = &NewRoundStepRequestMessage{CommitHeaders: msg.NewRoundStepRequest.CommitHeaders}
 	case *kcons.Message_NewValidBlock:
 		pbPartSetHeader, err := types.PartSetHeaderFromProto(&msg.NewValidBlock.BlockPartSetHeader)
 		if err != nil {
@@ -368,3 +390,19 @@ func WALFromProto(msg *kcons.WALMessage) (WALMessage, error) {
 	}
 	return pb, nil
 }
+
+// unixMillis returns t in milliseconds since the Unix epoch, 0 for the zero time.
+func unixMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// fromUnixMillis is the inverse of unixMillis.
+func fromUnixMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
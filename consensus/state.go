@@ -495,14 +495,17 @@ func (cs *ConsensusState) setProposal(proposal *types.Proposal) error {
 		return nil
 	}
 
-	// Verify POLRound, which must be -1 or between 0 and proposal.Round exclusive.
-	if (proposal.POLRound < 1) && ((proposal.POLRound > 0) || (proposal.POLRound > proposal.Round)) {
+	// Verify POLRound, which must be NoPOLRound or before proposal.Round.
+	if proposal.HasPOLRound() && proposal.POLRound >= proposal.Round {
 		cs.Logger.Trace("Invalid proposal POLRound", "proposal.POLRound", proposal.POLRound, "proposal.Round", proposal.Round)
 		return ErrInvalidProposalPOLRound
 	}
 
 	proposalAddress := cs.Validators.GetProposer().Address
-	signBytes := types.ProposalSignBytes(cs.state.ChainID, proposal.ToProto())
+	signBytes, err := types.ProposalSignBytes(cs.state.ChainID, proposal.ToProto())
+	if err != nil {
+		return err
+	}
 	if !types.VerifySignature(proposalAddress, crypto.Keccak256(signBytes), proposal.Signature) {
 		return ErrInvalidProposalPOLRound
 	}
@@ -698,7 +701,7 @@ func (cs *ConsensusState) addVote(vote *types.Vote, peerID p2p.ID) (bool, error)
 
 			// Update Valid* if we can.
 			// NOTE: our proposal block may be nil or not what received a polka..
-			if !blockID.Hash.IsZero() && (cs.ValidRound < vote.Round) && (vote.Round == cs.Round) {
+			if !blockID.IsZero() && (cs.ValidRound < vote.Round) && (vote.Round == cs.Round) {
 				if cs.ProposalBlock.HashesTo(blockID.Hash) {
 					cs.Logger.Info("Updating ValidBlock because of POL.", "validRound", cs.ValidRound, "POLRound", vote.Round)
 					cs.ValidRound = vote.Round
@@ -730,12 +733,12 @@ func (cs *ConsensusState) addVote(vote *types.Vote, peerID p2p.ID) (bool, error)
 			cs.enterNewRound(height, vote.Round)
 		case (cs.Round == vote.Round) && (cstypes.RoundStepPrevote <= cs.Step):
 			blockID, ok := prevotes.TwoThirdsMajority()
-			if ok && (cs.isProposalComplete() || blockID.Hash.IsZero()) {
+			if ok && (cs.isProposalComplete() || blockID.IsZero()) {
 				cs.enterPrecommit(height, vote.Round)
 			} else if prevotes.HasTwoThirdsAny() {
 				cs.enterPrevoteWait(height, vote.Round)
 			}
-		case cs.Proposal != nil && (1 <= cs.Proposal.POLRound) && (cs.Proposal.POLRound == vote.Round):
+		case cs.Proposal != nil && cs.Proposal.HasPOLRound() && (cs.Proposal.POLRound == vote.Round):
 			// If the proposal is now complete, enter prevote of cs.Round.
 			if cs.isProposalComplete() {
 				cs.enterPrevote(height, cs.Round)
@@ -751,7 +754,7 @@ func (cs *ConsensusState) addVote(vote *types.Vote, peerID p2p.ID) (bool, error)
 			// Executed as TwoThirdsMajority could be from a higher round
 			cs.enterNewRound(height, vote.Round)
 			cs.enterPrecommit(height, vote.Round)
-			if !blockID.Hash.IsZero() {
+			if !blockID.IsZero() {
 				cs.enterCommit(height, vote.Round)
 				if cs.config.IsSkipTimeoutCommit && precommits.HasAll() {
 					cs.enterNewRound(cs.Height, 1)
@@ -858,7 +861,8 @@ func (cs *ConsensusState) newStep() {
 
 // SetProposal inputs a proposal.
 func (cs *ConsensusState) SetProposal(proposal *types.Proposal, peerID p2p.ID) error {
-
+	// The receive routine keeps the proposal, so don't share it with the caller.
+	proposal = proposal.Copy()
 	if peerID == "" {
 		cs.internalMsgQueue <- msgInfo{&ProposalMessage{proposal}, ""}
 	} else {
@@ -995,6 +999,21 @@ func (cs *ConsensusState) GetRoundState() *cstypes.RoundState {
 	return &rs
 }
 
+// Config returns the consensus configuration.
+func (cs *ConsensusState) Config() *cfg.ConsensusConfig {
+	return cs.config
+}
+
+// EventSwitch returns the switch firing the events broadcast by the manager.
+func (cs *ConsensusState) EventSwitch() kevents.EventSwitch {
+	return cs.evsw
+}
+
+// PeerMsgQueue returns the queue of messages received from peers.
+func (cs *ConsensusState) PeerMsgQueue() chan msgInfo {
+	return cs.peerMsgQueue
+}
+
 // LoadCommit loads the commit for a given height.
 func (cs *ConsensusState) LoadCommit(height uint64) *types.Commit {
 	cs.mtx.RLock()
@@ -1005,6 +1024,54 @@ func (cs *ConsensusState) LoadCommit(height uint64) *types.Commit {
 	return cs.blockOperations.LoadBlockCommit(height)
 }
 
+// GetState returns a shallow copy of the latest block state.
+func (cs *ConsensusState) GetState() cstate.LatestBlockState {
+	cs.mtx.RLock()
+	defer cs.mtx.RUnlock()
+	return cs.state
+}
+
+// GetProposalSlot returns the height and round being decided, along with their
+// proposer and propose deadline, all read at once.
+func (cs *ConsensusState) GetProposalSlot() ProposalSlot {
+	// GetProposer caches the proposer in the validator set, so take the write lock.
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+	slot := ProposalSlot{Height: cs.Height, Round: cs.Round, ProposeDeadline: cs.ProposeDeadline}
+	if cs.Validators != nil {
+		slot.Proposer = cs.Validators.GetProposer()
+	}
+	return slot
+}
+
+// PrivValidator returns the private validator account signing votes.
+func (cs *ConsensusState) PrivValidator() types.PrivValidator {
+	cs.mtx.RLock()
+	defer cs.mtx.RUnlock()
+	return cs.privValidator
+}
+
+// BlockOperations returns the store the committed blocks are read from.
+func (cs *ConsensusState) BlockOperations() BaseBlockOperations {
+	return cs.blockOperations
+}
+
+// ResetToState moves the consensus state to state once fast sync is done,
+// reconstructing its LastCommit from the seen commit. If skipWAL is set, the
+// WAL is not replayed on start.
+func (cs *ConsensusState) ResetToState(state cstate.LatestBlockState, skipWAL bool) {
+	// We have no votes, so reconstruct LastCommit from SeenCommit.
+	if state.LastBlockHeight > 0 {
+		cs.reconstructLastCommit(state)
+	}
+	// NOTE: The line below causes broadcastNewRoundStepRoutine() to broadcast a
+	// NewRoundStepMessage.
+	cs.updateToState(state)
+	if skipWAL {
+		cs.doWALCatchup = false
+	}
+}
+
 // Enter: `timeoutNewHeight` by startTime (commitTime+timeoutCommit),
 // 	or, if SkipTimeout==true, after receiving all precommits from (height,round-1)
 // Enter: `timeoutPrecommits` after any +2/3 precommits from (height,round-1)
@@ -1050,6 +1117,7 @@ func (cs *ConsensusState) enterNewRound(height uint64, round uint32) {
 	}
 	cs.Votes.SetRound(round + 1) // also track next round (round+1) to allow round-skipping
 	cs.TriggeredTimeoutPrecommit = false
+	cs.ProposeDeadline = time.Time{}
 	if err := cs.eventBus.PublishEventNewRound(cs.NewRoundEvent()); err != nil {
 		cs.Logger.Error("Error publishing new round", "err", err)
 	}
@@ -1094,6 +1162,7 @@ func (cs *ConsensusState) enterPropose(height uint64, round uint32) {
 	}()
 
 	// If we don't get the proposal quick enough, enterPrevote
+	cs.ProposeDeadline = time.Now().Add(cs.config.Propose(round))
 	cs.scheduleTimeout(cs.config.Propose(round), height, round, cstypes.RoundStepPropose)
 
 	// TODO(namdoh): For now this any node is a validator. Remove it once we
@@ -1246,7 +1315,7 @@ func (cs *ConsensusState) enterPrecommit(height uint64, round uint32) {
 	// the latest POLRound should be this round.
 	polRound, _ := cs.Votes.POLInfo()
 	if polRound < round {
-		cmn.PanicSanity(cmn.Fmt("This POLRound should be %v but got %", round, polRound))
+		cmn.PanicSanity(cmn.Fmt("This POLRound should be %v but got %v", round, polRound))
 	}
 
 	// +2/3 prevoted nil. Unlock and precommit nil.
@@ -1551,7 +1620,7 @@ func (cs *ConsensusState) isProposalComplete() bool {
 	}
 	// we have the proposal. if there's a POLRound,
 	// make sure we have the prevotes from it too
-	if cs.Proposal.POLRound < 1 {
+	if !cs.Proposal.HasPOLRound() {
 		return true
 	}
 	// if this is false the proposer is lying or we haven't received the POL yet
@@ -20,6 +20,7 @@ package types
 
 import (
 	"fmt"
+	"time"
 
 	cmn "github.com/kardiachain/go-kardia/lib/common"
 	"github.com/kardiachain/go-kardia/types"
@@ -31,11 +32,11 @@ type PeerRoundState struct {
 	Height                   uint64              `json:"height"`                      // Height peer is at
 	Round                    uint32              `json:"round"`                       // Round peer is at, -1 if unknown.
 	Step                     RoundStepType       `json:"step"`                        // Step peer is at
-	StartTime                uint64              `json:"start_time"`                  // Estimated start of round 0 at this height
+	StartTime                time.Time           `json:"start_time"`                  // Estimated start of round 0 at this height
 	Proposal                 bool                `json:"proposal"`                    // True if peer has proposal for this round
 	ProposalBlockPartsHeader types.PartSetHeader `json:"proposal_block_parts_header"` //
 	ProposalBlockParts       *cmn.BitArray       `json:"proposal_block_parts"`        //
-	ProposalPOLRound         uint32              `json:"proposal_pol_round"`          // Proposal's POL round. -1 if none.
+	ProposalPOLRound         uint32              `json:"proposal_pol_round"`          // Proposal's POL round. types.NoPOLRound if none.
 	ProposalPOL              *cmn.BitArray       `json:"proposal_pol"`                // nil until ProposalPOLMessage received.
 	Prevotes                 *cmn.BitArray       `json:"prevotes"`                    // All votes peer has for this round
 	Precommits               *cmn.BitArray       `json:"precommits"`                  // All precommits peer has for this round
@@ -45,6 +46,22 @@ type PeerRoundState struct {
 	CatchupCommit            *cmn.BitArray       `json:"catchup_commit"`              // All commit precommits peer has for this height & CatchupCommitRound
 }
 
+// DeepCopy returns a copy of the PeerRoundState that shares no bit arrays
+// with the original, so it can be read without holding the peer state lock.
+func (prs *PeerRoundState) DeepCopy() *PeerRoundState {
+	if prs == nil {
+		return nil
+	}
+	cp := *prs
+	cp.ProposalBlockParts = prs.ProposalBlockParts.Copy()
+	cp.ProposalPOL = prs.ProposalPOL.Copy()
+	cp.Prevotes = prs.Prevotes.Copy()
+	cp.Precommits = prs.Precommits.Copy()
+	cp.LastCommit = prs.LastCommit.Copy()
+	cp.CatchupCommit = prs.CatchupCommit.Copy()
+	return &cp
+}
+
 // StringLong returns a string representation of the PeerRoundState
 // func (prs PeerRoundState) StringLong() string {
 // 	return fmt.Sprintf("PeerRoundState{%v/%v/%v @%v  Proposal:%v  POL:%v (round %v)  Prevotes:%v  Precommits:%v  LastCommit:%v (round %v)  Catchup:%v (round %v)}",
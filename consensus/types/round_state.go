@@ -84,6 +84,8 @@ type RoundState struct {
 	Step      RoundStepType `json:"step"`
 	StartTime time.Time     `json:"start_time"`
 
+	ProposeDeadline time.Time `json:"propose_deadline"` // When the propose step of Round times out, zero before it is entered
+
 	CommitTime                time.Time           `json:"commit_time"` // Subjective time when +2/3 precommits for Block at Round were found
 	Validators                *types.ValidatorSet `json:"validators"`  // TODO(huny@): Assume static validator set for now
 	Proposal                  *types.Proposal     `json:"proposal"`
@@ -19,6 +19,7 @@
 package rawdb
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -55,6 +56,33 @@ func ReadCanonicalHash(db kaidb.Reader, height uint64) common.Hash {
 	return common.BytesToHash(data)
 }
 
+// ReadCanonicalHashErr is like ReadCanonicalHash, but returns an error if the
+// database read fails. A missing hash is not an error.
+func ReadCanonicalHashErr(db kaidb.Reader, height uint64) (common.Hash, error) {
+	data, err := readKey(db, headerHashKey(height))
+	if err != nil || len(data) == 0 {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(data), nil
+}
+
+// readKey retrieves key from db. Unlike a plain Get, a missing key returns no
+// data and no error, so only failed reads are reported.
+func readKey(db kaidb.Reader, key []byte) ([]byte, error) {
+	data, err := db.Get(key)
+	if err == nil {
+		return data, nil
+	}
+	has, hasErr := db.Has(key)
+	if hasErr != nil {
+		return nil, hasErr
+	}
+	if has {
+		return nil, err
+	}
+	return nil, nil
+}
+
 // ReadChainConfig retrieves the consensus settings based on the given genesis hash.
 func ReadChainConfig(db kaidb.Reader, hash common.Hash) *configs.ChainConfig {
 	data, _ := db.Get(configKey(hash))
@@ -222,6 +250,17 @@ func ReadHeaderHeight(db kaidb.Reader, hash common.Hash) *uint64 {
 	return &height
 }
 
+// ReadHeaderHeightErr is like ReadHeaderHeight, but returns an error if the
+// database read fails. A missing height is not an error.
+func ReadHeaderHeightErr(db kaidb.Reader, hash common.Hash) (*uint64, error) {
+	data, err := readKey(db, headerHeightKey(hash))
+	if err != nil || len(data) != 8 {
+		return nil, err
+	}
+	height := binary.BigEndian.Uint64(data)
+	return &height, nil
+}
+
 // ReadBody retrieves the commit at a given height.
 func ReadCommit(db kaidb.Reader, height uint64) *types.Commit {
 	var pbc = new(kproto.Commit)
@@ -526,13 +565,17 @@ func WriteBloomBits(db kaidb.Writer, bit uint, section uint64, head common.Hash,
 // ReadBlockMeta returns the BlockMeta for the given height.
 // If no block is found for the given height, it returns nil.
 func ReadBlockMeta(db kaidb.Reader, height uint64) *types.BlockMeta {
-	var pbbm = new(kproto.BlockMeta)
 	metaBytes, _ := db.Get(blockMetaKey(height))
 
 	if len(metaBytes) == 0 {
 		return nil
 	}
+	return decodeBlockMeta(metaBytes)
+}
 
+// decodeBlockMeta decodes a protobuf encoded BlockMeta as stored in the database.
+func decodeBlockMeta(metaBytes []byte) *types.BlockMeta {
+	var pbbm = new(kproto.BlockMeta)
 	err := proto.Unmarshal(metaBytes, pbbm)
 	if err != nil {
 		panic(fmt.Errorf("unmarshal to kproto.BlockMeta: %w", err))
@@ -601,6 +644,66 @@ func ReadHeader(db kaidb.Reader, height uint64) *types.Header {
 	return nil
 }
 
+// ReadHeaderErr is like ReadHeader, but returns an error if the database read
+// fails. A missing header is not an error.
+func ReadHeaderErr(db kaidb.Reader, height uint64) (*types.Header, error) {
+	metaBytes, err := readKey(db, blockMetaKey(height))
+	if err != nil || len(metaBytes) == 0 {
+		return nil, err
+	}
+	return decodeBlockMeta(metaBytes).Header, nil
+}
+
+// ReadCanonicalHeaderRange retrieves the canonical headers from start up to end
+// (inclusive) by iterating over the canonical hash and block meta keyspaces
+// together, skipping the heights missing either of them. An error is returned
+// if either iteration fails.
+func ReadCanonicalHeaderRange(db kaidb.Iteratee, start, end uint64) ([]*types.Header, error) {
+	var headers []*types.Header
+	if start > end {
+		return headers, nil
+	}
+	hashIt := db.NewIterator(headerPrefix, encodeBlockHeight(start))
+	defer hashIt.Release()
+	metaIt := db.NewIterator(blockMetaPrefix, encodeBlockHeight(start))
+	defer metaIt.Release()
+
+	// nextMeta moves metaIt to the next block meta, returning its height.
+	nextMeta := func() (uint64, bool) {
+		for metaIt.Next() {
+			if key := metaIt.Key(); len(key) == len(blockMetaPrefix)+8 {
+				return binary.BigEndian.Uint64(key[len(blockMetaPrefix):]), true
+			}
+		}
+		return 0, false
+	}
+	metaHeight, metaOk := nextMeta()
+	for metaOk && hashIt.Next() {
+		// Skip everything but the height -> canonical hash mappings
+		key := hashIt.Key()
+		if len(key) != len(headerPrefix)+8+len(headerHashSuffix) || !bytes.HasSuffix(key, headerHashSuffix) {
+			continue
+		}
+		height := binary.BigEndian.Uint64(key[len(headerPrefix) : len(headerPrefix)+8])
+		if height > end {
+			break
+		}
+		for metaOk && metaHeight < height {
+			metaHeight, metaOk = nextMeta()
+		}
+		if metaOk && metaHeight == height {
+			headers = append(headers, decodeBlockMeta(metaIt.Value()).Header)
+		}
+	}
+	if err := hashIt.Error(); err != nil {
+		return nil, err
+	}
+	if err := metaIt.Error(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
 // ReadBlockPart returns the block part fo the given height and index
 func ReadBlockPart(db kaidb.Reader, height uint64, index int) *types.Part {
 	var pbpart = new(kproto.Part)
@@ -708,7 +811,12 @@ func DeleteBlockMeta(db kaidb.KeyValueWriter, height uint64) {
 
 func DeleteBlockPart(db kaidb.Database, height uint64) error {
 	blockMeta := ReadBlockMeta(db, height)
-	for i := 0; i < int(blockMeta.BlockID.PartsHeader.Total); i++ {
+	return DeleteBlockParts(db, height, blockMeta.BlockID.PartsHeader.Total)
+}
+
+// DeleteBlockParts removes the first total block parts stored at the given height.
+func DeleteBlockParts(db kaidb.KeyValueWriter, height uint64, total uint32) error {
+	for i := 0; i < int(total); i++ {
 		if err := db.Delete(blockPartKey(height, i)); err != nil {
 			return err
 		}
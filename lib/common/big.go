@@ -392,3 +392,16 @@ func (x *BigInt) Copy() *BigInt {
 func (x *BigInt) String() string {
 	return fmt.Sprintf("%v", x.GetInt64())
 }
+
+// Big returns a copy of x as a big.Int.
+func (x *BigInt) Big() *big.Int {
+	if x.bigint == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(x.bigint)
+}
+
+// SetBig sets x to a copy of i.
+func (x *BigInt) SetBig(i *big.Int) {
+	x.bigint = new(big.Int).Set(i)
+}
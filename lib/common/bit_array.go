@@ -117,7 +117,8 @@ func (bA *BitArray) copyBits(bits int) *BitArray {
 	}
 }
 
-// Returns a BitArray of larger bits size.
+// Or returns the union of bA and o, with the size of the larger of the two.
+// Arrays of different sizes are aligned at index 0.
 func (bA *BitArray) Or(o *BitArray) *BitArray {
 	if bA == nil && o == nil {
 		return nil
@@ -128,11 +129,12 @@ func (bA *BitArray) Or(o *BitArray) *BitArray {
 	if o == nil {
 		return bA.Copy()
 	}
+	o = o.Copy()
 	bA.mtx.Lock()
 	defer bA.mtx.Unlock()
 	c := bA.copyBits(MaxInt(int(bA.Bits), int(o.Bits)))
-	for i := 0; i < len(c.Elems); i++ {
-		c.Elems[i] |= o.Elems[i]
+	for i := 0; i < len(o.Elems); i++ {
+		c.Elems[i] |= o.elem(i)
 	}
 	return c
 }
@@ -168,28 +170,30 @@ func (bA *BitArray) Not() *BitArray {
 	return c
 }
 
+// Sub returns the bits set in bA but not in o, with the size of bA. Bits of bA
+// past the size of o are kept.
 func (bA *BitArray) Sub(o *BitArray) *BitArray {
 	if bA == nil || o == nil {
 		// TODO: Decide if we should do 1's complement here?
 		return nil
 	}
+	o = o.Copy()
 	bA.mtx.Lock()
 	defer bA.mtx.Unlock()
-	if bA.Bits > o.Bits {
-		c := bA.copy()
-		for i := 0; i < len(o.Elems)-1; i++ {
-			c.Elems[i] &= ^c.Elems[i]
-		}
-		i := len(o.Elems) - 1
-		if i >= 0 {
-			for idx := i * 64; idx < int(o.Bits); idx++ {
-				// NOTE: each individual GetIndex() call to o is safe.
-				c.setIndex(idx, c.getIndex(idx) && !o.GetIndex(idx))
-			}
-		}
-		return c
+	c := bA.copy()
+	for i := 0; i < len(c.Elems) && i < len(o.Elems); i++ {
+		c.Elems[i] &^= o.elem(i)
+	}
+	return c
+}
+
+// elem returns the i'th element of bA with any bits past its size cleared,
+// such as those left set by Not.
+func (bA *BitArray) elem(i int) uint64 {
+	if rem := bA.Bits - uint(i)*64; rem < 64 {
+		return bA.Elems[i] & (uint64(1)<<rem - 1)
 	}
-	return bA.and(o.Not()) // Note degenerate case where o == nil
+	return bA.Elems[i]
 }
 
 func (bA *BitArray) IsEmpty() bool {
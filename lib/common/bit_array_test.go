@@ -0,0 +1,98 @@
+/*
+ *  Copyright 2018 KardiaChain
+ *  This file is part of the go-kardia library.
+ *
+ *  The go-kardia library is free software: you can redistribute it and/or modify
+ *  it under the terms of the GNU Lesser General Public License as published by
+ *  the Free Software Foundation, either version 3 of the License, or
+ *  (at your option) any later version.
+ *
+ *  The go-kardia library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ *  GNU Lesser General Public License for more details.
+ *
+ *  You should have received a copy of the GNU Lesser General Public License
+ *  along with the go-kardia library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+// bitArrayOf returns a BitArray of the given size with the given indices set.
+func bitArrayOf(bits int, indices ...int) *BitArray {
+	bA := NewBitArray(bits)
+	for _, i := range indices {
+		bA.SetIndex(i, true)
+	}
+	return bA
+}
+
+// setIndices returns the indices set in bA.
+func setIndices(bA *BitArray) []int {
+	indices := []int{}
+	for i := 0; i < bA.Size(); i++ {
+		if bA.GetIndex(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+func TestBitArrayOrSub(t *testing.T) {
+	for i, test := range []struct {
+		a, b    *BitArray
+		orSize  int
+		or      []int
+		subSize int
+		sub     []int
+	}{
+		// same size
+		{bitArrayOf(4, 0, 1), bitArrayOf(4, 1, 3), 4, []int{0, 1, 3}, 4, []int{0}},
+		{bitArrayOf(4), bitArrayOf(4, 2), 4, []int{2}, 4, []int{}},
+		// mismatched sizes within one element
+		{bitArrayOf(8, 0, 5, 7), bitArrayOf(6, 0, 4), 8, []int{0, 4, 5, 7}, 8, []int{5, 7}},
+		{bitArrayOf(6, 0, 4), bitArrayOf(8, 0, 5, 7), 8, []int{0, 4, 5, 7}, 6, []int{4}},
+		// mismatched sizes across elements
+		{bitArrayOf(130, 1, 64, 129), bitArrayOf(65, 1, 64), 130, []int{1, 64, 129}, 130, []int{129}},
+		{bitArrayOf(65, 0, 64), bitArrayOf(130, 63, 64, 100), 130, []int{0, 63, 64, 100}, 65, []int{0}},
+		// bits past the size of the other array are ignored
+		{bitArrayOf(70, 3, 66), bitArrayOf(2, 1).Not(), 70, []int{0, 3, 66}, 70, []int{3, 66}},
+	} {
+		or := test.a.Or(test.b)
+		if or.Size() != test.orSize || !reflect.DeepEqual(setIndices(or), test.or) {
+			t.Errorf("test %d: Or = %v (size %d), want %v (size %d)", i, setIndices(or), or.Size(), test.or, test.orSize)
+		}
+		sub := test.a.Sub(test.b)
+		if sub.Size() != test.subSize || !reflect.DeepEqual(setIndices(sub), test.sub) {
+			t.Errorf("test %d: Sub = %v (size %d), want %v (size %d)", i, setIndices(sub), sub.Size(), test.sub, test.subSize)
+		}
+	}
+}
+
+func TestBitArrayOrSubNil(t *testing.T) {
+	a := bitArrayOf(4, 1)
+	if (*BitArray)(nil).Or(nil) != nil {
+		t.Error("union of nil arrays is not nil")
+	}
+	if got := setIndices(a.Or(nil)); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("a.Or(nil) = %v", got)
+	}
+	if got := setIndices((*BitArray)(nil).Or(a)); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("nil.Or(a) = %v", got)
+	}
+	if a.Sub(nil) != nil || (*BitArray)(nil).Sub(a) != nil {
+		t.Error("difference with a nil array is not nil")
+	}
+	// Operating on an array with itself must not deadlock.
+	if !a.Sub(a).IsEmpty() {
+		t.Error("a.Sub(a) is not empty")
+	}
+	if got := setIndices(a.Or(a)); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("a.Or(a) = %v", got)
+	}
+}
@@ -46,6 +46,19 @@ func (c *Cache[K, V]) Contains(key K) bool {
 	return c.cache.Contains(key)
 }
 
+// ContainsOrAdd reports whether the given key exists in the cache, adding it
+// with value if it does not, in a single step.
+func (c *Cache[K, V]) ContainsOrAdd(key K, value V) (ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cache.Contains(key) {
+		return true
+	}
+	c.cache.Add(key, value)
+	return false
+}
+
 // Get retrieves a value from the cache. This marks the key as recently used.
 func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
 	c.mu.Lock()
@@ -47,6 +47,9 @@ const (
 	defaultPongTimeout         = 45 * time.Second
 )
 
+// ErrPongTimeout is reported when a peer does not answer a ping in time.
+var ErrPongTimeout = errors.New("pong timeout")
+
 type receiveCbFunc func(chID byte, msgBytes []byte)
 type errorCbFunc func(interface{})
 
@@ -456,7 +459,7 @@ FOR_LOOP:
 		case timeout := <-c.pongTimeoutCh:
 			if timeout {
 				c.Logger.Debug("Pong timeout")
-				err = errors.New("pong timeout")
+				err = ErrPongTimeout
 			} else {
 				c.stopPongTimer()
 			}
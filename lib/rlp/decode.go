@@ -25,11 +25,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/kardiachain/go-kardia/lib/common"
 	"github.com/kardiachain/go-kardia/lib/rlp/internal/rlpstruct"
 )
 
@@ -87,6 +90,22 @@ func Decode(r io.Reader, val interface{}) error {
 	return stream.Decode(val)
 }
 
+// DecodeStrict is like Decode, but the input must contain exactly one value:
+// it returns ErrMoreThanOneValue if anything follows the value in r.
+func DecodeStrict(r io.Reader, val interface{}) error {
+	stream := streamPool.Get().(*Stream)
+	defer streamPool.Put(stream)
+
+	stream.Reset(r, 0)
+	if err := stream.Decode(val); err != nil {
+		return err
+	}
+	if _, _, err := stream.Kind(); err != io.EOF {
+		return ErrMoreThanOneValue
+	}
+	return nil
+}
+
 // DecodeBytes parses RLP data from b into val. Please see package-level documentation for
 // the decoding rules. The input must contain exactly one value and no trailing data.
 func DecodeBytes(b []byte, val interface{}) error {
@@ -140,9 +159,34 @@ func wrapStreamError(err error, typ reflect.Type) error {
 	return err
 }
 
+// contextError adds the path of the value being decoded to an error which is
+// not a *decodeError, such as one returned by a custom Decoder. The original
+// error remains available through errors.Is/As.
+type contextError struct {
+	err error
+	ctx []string
+}
+
+func (err *contextError) Error() string {
+	ctx := ""
+	for i := len(err.ctx) - 1; i >= 0; i-- {
+		ctx += err.ctx[i]
+	}
+	return fmt.Sprintf("%v, decoding into %s", err.err, ctx)
+}
+
+func (err *contextError) Unwrap() error {
+	return err.err
+}
+
 func addErrorContext(err error, ctx string) error {
-	if decErr, ok := err.(*decodeError); ok {
-		decErr.ctx = append(decErr.ctx, ctx)
+	switch e := err.(type) {
+	case *decodeError:
+		e.ctx = append(e.ctx, ctx)
+	case *contextError:
+		e.ctx = append(e.ctx, ctx)
+	default:
+		return &contextError{err: err, ctx: []string{ctx}}
 	}
 	return err
 }
@@ -150,6 +194,8 @@ func addErrorContext(err error, ctx string) error {
 var (
 	decoderInterface = reflect.TypeOf(new(Decoder)).Elem()
 	bigInt           = reflect.TypeOf(big.Int{})
+	timeType         = reflect.TypeOf(time.Time{})
+	signedBigInt     = reflect.TypeOf(common.BigInt{})
 )
 
 func makeDecoder(typ reflect.Type, tags rlpstruct.Tags) (dec decoder, err error) {
@@ -161,6 +207,10 @@ func makeDecoder(typ reflect.Type, tags rlpstruct.Tags) (dec decoder, err error)
 		return decodeBigInt, nil
 	case typ.AssignableTo(bigInt):
 		return decodeBigIntNoPtr, nil
+	case typ == timeType:
+		return decodeTime, nil
+	case typ == signedBigInt:
+		return decodeSignedBigInt, nil
 	case kind == reflect.Ptr:
 		return makePtrDecoder(typ, tags)
 	case reflect.PtrTo(typ).Implements(decoderInterface):
@@ -210,6 +260,18 @@ func decodeBool(s *Stream, val reflect.Value) error {
 	return nil
 }
 
+func decodeTime(s *Stream, val reflect.Value) error {
+	t, err := s.Time()
+	if err == ErrTimeRange {
+		return &decodeError{msg: "time out of range", typ: val.Type()}
+	}
+	if err != nil {
+		return wrapStreamError(err, val.Type())
+	}
+	val.Set(reflect.ValueOf(t))
+	return nil
+}
+
 func decodeString(s *Stream, val reflect.Value) error {
 	b, err := s.Bytes()
 	if err != nil {
@@ -272,6 +334,36 @@ func decodeBigInt(s *Stream, val reflect.Value) error {
 	return nil
 }
 
+// decodeSignedBigInt decodes a common.BigInt written by writeSignedBigInt.
+func decodeSignedBigInt(s *Stream, val reflect.Value) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return wrapStreamError(err, val.Type())
+	}
+	var i *big.Int
+	if kind != List {
+		if err := decodeBigInt(s, reflect.ValueOf(&i).Elem()); err != nil {
+			return err
+		}
+	} else {
+		if _, err := s.List(); err != nil {
+			return wrapStreamError(err, val.Type())
+		}
+		if err := decodeBigInt(s, reflect.ValueOf(&i).Elem()); err != nil {
+			return err
+		}
+		if i.Sign() == 0 {
+			return &decodeError{msg: "negative zero", typ: val.Type()}
+		}
+		if err := s.ListEnd(); err != nil {
+			return wrapStreamError(err, val.Type())
+		}
+		i.Neg(i)
+	}
+	val.Addr().Interface().(*common.BigInt).SetBig(i)
+	return nil
+}
+
 func makeListDecoder(typ reflect.Type, tag rlpstruct.Tags) (decoder, error) {
 	etype := typ.Elem()
 	if etype.Kind() == reflect.Uint8 && !reflect.PtrTo(etype).Implements(decoderInterface) {
@@ -785,6 +877,23 @@ func (s *Stream) Bool() (bool, error) {
 	}
 }
 
+// Time reads an unsigned integer of nanoseconds since the Unix epoch and
+// returns it as a UTC time, or the zero time for zero. Values above the
+// maximum int64 return ErrTimeRange.
+func (s *Stream) Time() (time.Time, error) {
+	nanos, err := s.uint(64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if nanos > math.MaxInt64 {
+		return time.Time{}, ErrTimeRange
+	}
+	if nanos == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, int64(nanos)).UTC(), nil
+}
+
 // List starts decoding an RLP list. If the input does not contain a
 // list, the returned error will be ErrExpectedList. When the list's
 // end has been reached, any Stream operation will return EOL.
@@ -845,9 +954,14 @@ func (s *Stream) Decode(val interface{}) error {
 	}
 
 	err = decoder(s, rval.Elem())
-	if decErr, ok := err.(*decodeError); ok && len(decErr.ctx) > 0 {
-		// Add decode target type to error so context has more meaning.
-		decErr.ctx = append(decErr.ctx, fmt.Sprint("(", rtyp.Elem(), ")"))
+	// Add decode target type to error so context has more meaning.
+	switch e := err.(type) {
+	case *decodeError:
+		if len(e.ctx) > 0 {
+			e.ctx = append(e.ctx, fmt.Sprint("(", rtyp.Elem(), ")"))
+		}
+	case *contextError:
+		e.ctx = append(e.ctx, fmt.Sprint("(", rtyp.Elem(), ")"))
 	}
 	return err
 }
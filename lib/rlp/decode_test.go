@@ -26,8 +26,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
-
-	"github.com/kardiachain/go-kardia/lib/common"
+	"testing/iotest"
+	"time"
 )
 
 func TestStreamKind(t *testing.T) {
@@ -532,6 +532,14 @@ var decodeTests = []decodeTest{
 	{input: "B848FFFFFFFFFFFFFFFFF800000000000000001BFFFFFFFFFFFFFFFFC8000000000000000045FFFFFFFFFFFFFFFFC800000000000000001BFFFFFFFFFFFFFFFFF8000000000000000001", ptr: new(*big.Int), value: veryVeryBigInt},
 	{input: "10", ptr: new(big.Int), value: *big.NewInt(16)}, // non-pointer also works
 	{input: "C0", ptr: new(*big.Int), error: "rlp: expected input string or byte for *big.Int"},
+
+	// times
+	{input: "80", ptr: new(time.Time), value: time.Time{}},
+	{input: "8203E8", ptr: new(time.Time), value: time.Unix(0, 1000).UTC()},
+	{input: "8816345785D8A00000", ptr: new(time.Time), value: time.Unix(1600000000, 0).UTC()},
+	{input: "820010", ptr: new(time.Time), error: "rlp: non-canonical integer (leading zero bytes) for time.Time"},
+	{input: "888000000000000000", ptr: new(time.Time), error: "rlp: time out of range for time.Time"},
+	{input: "C0", ptr: new(time.Time), error: "rlp: expected input string or byte for time.Time"},
 	{input: "00", ptr: new(*big.Int), error: "rlp: non-canonical integer (leading zero bytes) for *big.Int"},
 	{input: "820001", ptr: new(*big.Int), error: "rlp: non-canonical integer (leading zero bytes) for *big.Int"},
 	{input: "8105", ptr: new(*big.Int), error: "rlp: non-canonical size information for *big.Int"},
@@ -810,14 +818,14 @@ var decodeTests = []decodeTest{
 	{
 		input: "C3010203",
 		ptr:   new([]io.Reader),
-		error: "rlp: type io.Reader is not RLP-serializable",
+		error: "rlp: type io.Reader is not RLP-serializable, decoding into ([]io.Reader)[0]",
 	},
 
 	// fuzzer crashes
 	{
 		input: "c330f9c030f93030ce3030303030303030bd303030303030",
 		ptr:   new(interface{}),
-		error: "rlp: element is larger than containing list",
+		error: "rlp: element is larger than containing list, decoding into (interface {})[1]",
 	},
 }
 
@@ -918,6 +926,30 @@ func (t *testDecoder) DecodeRLP(s *Stream) error {
 	return nil
 }
 
+func TestDecodeStrict(t *testing.T) {
+	var v []uint
+	input := unhex("C3010203")
+	if err := DecodeStrict(bytes.NewReader(input), &v); err != nil {
+		t.Fatalf("DecodeStrict error: %v", err)
+	}
+
+	// Trailing garbage is ignored by Decode but rejected by DecodeStrict, even if
+	// it is not a valid RLP value itself.
+	for _, trailing := range []string{"00", "C0", "B9"} {
+		input := unhex("C3010203" + trailing)
+		if err := Decode(bytes.NewReader(input), &v); err != nil {
+			t.Errorf("trailing %s: Decode error: %v", trailing, err)
+		}
+		if err := DecodeStrict(bytes.NewReader(input), &v); err != ErrMoreThanOneValue {
+			t.Errorf("trailing %s: DecodeStrict error mismatch: got %v, want %v", trailing, err, ErrMoreThanOneValue)
+		}
+		// Readers without ReadByte are buffered by the stream.
+		if err := DecodeStrict(iotest.OneByteReader(bytes.NewReader(input)), &v); err != ErrMoreThanOneValue {
+			t.Errorf("trailing %s: DecodeStrict (buffered) error mismatch: got %v, want %v", trailing, err, ErrMoreThanOneValue)
+		}
+	}
+}
+
 func TestDecodeDecoder(t *testing.T) {
 	var s struct {
 		T1 testDecoder
@@ -945,6 +977,42 @@ func TestDecodeDecoder(t *testing.T) {
 	}
 }
 
+var errTestDecoder = errors.New("invalid value")
+
+// failingDecoder rejects any value other than 1.
+type failingDecoder struct{}
+
+func (*failingDecoder) DecodeRLP(s *Stream) error {
+	v, err := s.Uint()
+	if err != nil {
+		return err
+	}
+	if v != 1 {
+		return errTestDecoder
+	}
+	return nil
+}
+
+func TestDecodeDecoderErrorContext(t *testing.T) {
+	type inner struct {
+		A failingDecoder
+		B []failingDecoder
+	}
+	type outer struct {
+		Height uint64
+		Inner  inner
+	}
+	var s outer
+	err := DecodeBytes(unhex("C605C401C20102"), &s)
+	if !errors.Is(err, errTestDecoder) {
+		t.Fatalf("got error %v, want %v", err, errTestDecoder)
+	}
+	want := "invalid value, decoding into (rlp.outer).Inner.B[1]"
+	if err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+}
+
 func TestDecodeDecoderNilPointer(t *testing.T) {
 	var s struct {
 		T1 *testDecoder `rlp:"nil"`
@@ -1172,7 +1240,7 @@ func BenchmarkDecodeByteArrayStruct(b *testing.B) {
 func BenchmarkDecodeBigInts(b *testing.B) {
 	ints := make([]*big.Int, 200)
 	for i := range ints {
-		ints[i] = common.BigPow(2, int64(i))
+		ints[i] = new(big.Int).Lsh(big.NewInt(1), uint(i))
 	}
 	enc, err := EncodeToBytes(ints)
 	if err != nil {
@@ -38,8 +38,8 @@ If the type implements the Encoder interface, Encode calls EncodeRLP. It does no
 call EncodeRLP on nil pointer values.
 
 To encode a pointer, the value being pointed to is encoded. A nil pointer to a struct
-type, slice or array always encodes as an empty RLP list unless the slice or array has
-elememt type byte. A nil pointer to any other value encodes as the empty string.
+type other than time.Time or common.BigInt, slice or array always encodes as an empty RLP list unless the
+slice or array has elememt type byte. A nil pointer to any other value encodes as the empty string.
 
 Struct values are encoded as an RLP list of all their encoded public fields. Recursive
 struct types are supported.
@@ -56,6 +56,13 @@ are not supported and will return an error when encoding.
 
 Boolean values are encoded as the unsigned integers zero (false) and one (true).
 
+time.Time values are encoded as the unsigned integer number of nanoseconds since the Unix
+epoch. The zero time encodes as zero. The epoch itself, which would encode the same way, and
+times before it or after the year 2262 return an error when encoding.
+
+common.BigInt values may be negative. Non-negative values encode like big.Int, negative
+values as an RLP list containing their absolute value.
+
 An interface value encodes as the value contained in the interface.
 
 Floating point numbers, maps, channels and functions are not supported.
@@ -93,6 +100,13 @@ than the bit size of the type, decoding will return an error. Decode also suppor
 To decode into a boolean, the input must contain an unsigned integer of value zero (false)
 or one (true).
 
+To decode into a time.Time, the input must contain an unsigned integer of at most the
+maximum int64. It is decoded as nanoseconds since the Unix epoch in UTC, except for zero,
+which decodes as the zero time.
+
+To decode into a common.BigInt, the input must contain an unsigned integer, or a list
+containing a single non-zero unsigned integer for its negation.
+
 To decode into an interface value, one of these types is stored in the value:
 
 	  []interface{}, for RLP lists
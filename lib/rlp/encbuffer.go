@@ -4,9 +4,11 @@ package rlp
 
 import (
 	"io"
+	"math"
 	"math/big"
 	"reflect"
 	"sync"
+	"time"
 )
 
 type encBuffer struct {
@@ -114,6 +116,21 @@ func (buf *encBuffer) writeUint64(i uint64) {
 	}
 }
 
+// writeTime writes t as the number of nanoseconds since the Unix epoch, or
+// zero for the zero time.
+func (buf *encBuffer) writeTime(t time.Time) error {
+	if t.IsZero() {
+		buf.writeUint64(0)
+		return nil
+	}
+	// The epoch itself would encode like the zero time.
+	if !t.After(time.Unix(0, 0)) || t.After(time.Unix(0, math.MaxInt64)) {
+		return ErrTimeRange
+	}
+	buf.writeUint64(uint64(t.UnixNano()))
+	return nil
+}
+
 func (buf *encBuffer) writeBytes(b []byte) {
 	if len(b) == 1 && b[0] <= 0x7F {
 		// fits single byte, no string header
@@ -362,6 +379,12 @@ func (w EncoderBuffer) WriteBigInt(i *big.Int) {
 	w.buf.writeBigInt(i)
 }
 
+// WriteTime encodes t as the number of nanoseconds since the Unix epoch.
+// It returns ErrTimeRange for times Encode rejects.
+func (w EncoderBuffer) WriteTime(t time.Time) error {
+	return w.buf.writeTime(t)
+}
+
 // WriteBytes encodes b as an RLP string.
 func (w EncoderBuffer) WriteBytes(b []byte) {
 	w.buf.writeBytes(b)
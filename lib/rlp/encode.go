@@ -24,7 +24,9 @@ import (
 	"io"
 	"math/big"
 	"reflect"
+	"time"
 
+	"github.com/kardiachain/go-kardia/lib/common"
 	"github.com/kardiachain/go-kardia/lib/rlp/internal/rlpstruct"
 )
 
@@ -37,6 +39,11 @@ var (
 
 var ErrNegativeBigInt = errors.New("rlp: cannot encode negative big.Int")
 
+// ErrTimeRange is returned for times which can't be represented as a uint64
+// of nanoseconds since the Unix epoch. The epoch itself is rejected, as it
+// would encode like the zero time.
+var ErrTimeRange = errors.New("rlp: time out of range")
+
 // Encoder is implemented by types that require custom
 // encoding rules or want to encode private fields.
 type Encoder interface {
@@ -143,6 +150,10 @@ func makeWriter(typ reflect.Type, ts rlpstruct.Tags) (writer, error) {
 		return writeBigIntPtr, nil
 	case typ.AssignableTo(bigInt):
 		return writeBigIntNoPtr, nil
+	case typ == timeType:
+		return writeTime, nil
+	case typ == signedBigInt:
+		return writeSignedBigInt, nil
 	case kind == reflect.Ptr:
 		return makePtrWriter(typ, ts)
 	case reflect.PtrTo(typ).Implements(encoderInterface):
@@ -157,6 +168,8 @@ func makeWriter(typ reflect.Type, ts rlpstruct.Tags) (writer, error) {
 		return writeBytes, nil
 	case kind == reflect.Array && isByte(typ.Elem()):
 		return makeByteArrayWriter(typ), nil
+	case kind == reflect.Slice && isPlainByteSlice(typ.Elem()) && !ts.Tail:
+		return writeByteSlices, nil
 	case kind == reflect.Slice || kind == reflect.Array:
 		return makeSliceWriter(typ, ts)
 	case kind == reflect.Struct:
@@ -209,11 +222,60 @@ func writeBigIntNoPtr(val reflect.Value, w *encBuffer) error {
 	return nil
 }
 
+// writeSignedBigInt writes a common.BigInt. Non-negative values are written
+// like a big.Int, negative ones as a list holding their absolute value.
+func writeSignedBigInt(val reflect.Value, w *encBuffer) error {
+	x := val.Interface().(common.BigInt)
+	i := x.Big()
+	if i.Sign() >= 0 {
+		w.writeBigInt(i)
+		return nil
+	}
+	index := w.list()
+	w.writeBigInt(i.Neg(i))
+	w.listEnd(index)
+	return nil
+}
+
+func writeTime(val reflect.Value, w *encBuffer) error {
+	return w.writeTime(val.Interface().(time.Time))
+}
+
 func writeBytes(val reflect.Value, w *encBuffer) error {
 	w.writeBytes(val.Bytes())
 	return nil
 }
 
+// isPlainByteSlice reports whether typ is a byte slice encoded as a plain
+// string, so that slices of it can be written by writeByteSlices.
+func isPlainByteSlice(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && isByte(typ.Elem()) && typ != rawValueType &&
+		!reflect.PtrTo(typ).Implements(encoderInterface)
+}
+
+// writeByteSlices is the fast path for slices of byte slices, such as block
+// parts and merkle proofs. It avoids going through the element writer for
+// every element.
+func writeByteSlices(val reflect.Value, w *encBuffer) error {
+	vlen := val.Len()
+	if vlen == 0 {
+		w.str = append(w.str, 0xC0)
+		return nil
+	}
+	listOffset := w.list()
+	if slices, ok := byteSlices(val); ok {
+		for _, b := range slices {
+			w.writeBytes(b)
+		}
+	} else {
+		for i := 0; i < vlen; i++ {
+			w.writeBytes(val.Index(i).Bytes())
+		}
+	}
+	w.listEnd(listOffset)
+	return nil
+}
+
 func makeByteArrayWriter(typ reflect.Type) writer {
 	switch typ.Len() {
 	case 0:
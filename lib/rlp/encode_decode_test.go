@@ -21,7 +21,11 @@ package rlp
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
+
+	"github.com/kardiachain/go-kardia/lib/common"
 )
 
 type Simple struct {
@@ -87,6 +91,88 @@ func TestBasic(t *testing.T) {
 	}
 }
 
+type BoolTime struct {
+	Flag    bool
+	Time    time.Time
+	TimePtr *time.Time
+}
+
+func TestBoolTime(t *testing.T) {
+	now := time.Now()
+	for _, x := range []BoolTime{
+		{},
+		{Flag: true, Time: now, TimePtr: &now},
+		{Flag: false, Time: time.Unix(0, 1), TimePtr: nil},
+	} {
+		b, err := EncodeToBytes(x)
+		if err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+		var y BoolTime
+		if err := DecodeBytes(b, &y); err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		// A nil pointer decodes as a pointer to the zero time.
+		want := time.Time{}
+		if x.TimePtr != nil {
+			want = *x.TimePtr
+		}
+		if x.Flag != y.Flag || !x.Time.Equal(y.Time) || y.TimePtr == nil || !want.Equal(*y.TimePtr) {
+			t.Errorf("decoded %+v, want %+v", y, x)
+		}
+	}
+}
+
+type SignedBigInts struct {
+	Height   *common.BigInt
+	POLRound *common.BigInt
+	Missing  *common.BigInt `rlp:"nil"`
+}
+
+func TestSignedBigInt(t *testing.T) {
+	for _, x := range []int64{0, 1, -1, 300, -300, 1 << 62, -1 << 62} {
+		b, err := EncodeToBytes(common.NewBigInt(x))
+		if err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+		y := new(common.BigInt)
+		if err := DecodeBytes(b, y); err != nil {
+			t.Fatalf("Decode %x error: %v", b, err)
+		}
+		if !y.EqualsInt(x) {
+			t.Errorf("decoded %x as %v, want %d", b, y, x)
+		}
+	}
+
+	// Non-negative values are compatible with big.Int.
+	b, err := EncodeToBytes(big.NewInt(300))
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	y := new(common.BigInt)
+	if err := DecodeBytes(b, y); err != nil || !y.EqualsInt(300) {
+		t.Errorf("decoded big.Int %x as %v, %v", b, y, err)
+	}
+
+	b, err = EncodeToBytes(&SignedBigInts{Height: common.NewBigInt(10), POLRound: common.NewBigInt(-1)})
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	var dec SignedBigInts
+	if err := DecodeBytes(b, &dec); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !dec.Height.EqualsInt(10) || !dec.POLRound.EqualsInt(-1) || dec.Missing != nil {
+		t.Errorf("decoded %v/%v/%v, want 10/-1/nil", dec.Height, dec.POLRound, dec.Missing)
+	}
+
+	for _, input := range []string{"C0", "C180", "C20101", "C10001", "00"} {
+		if err := DecodeBytes(unhex(input), new(common.BigInt)); err == nil {
+			t.Errorf("decoded invalid input %s", input)
+		}
+	}
+}
+
 /* Disable-the test for now.
 // This test is expected to fail.
 // Fix issues#73 to make this test passes.
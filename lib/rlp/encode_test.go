@@ -28,6 +28,7 @@ import (
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/kardiachain/go-kardia/lib/common"
 )
@@ -150,6 +151,25 @@ var encTests = []encTest{
 	{val: big.NewInt(-1), error: "rlp: cannot encode negative big.Int"},
 	{val: *big.NewInt(-1), error: "rlp: cannot encode negative big.Int"},
 
+	// times
+	{val: time.Time{}, output: "80"},
+	{val: time.Unix(0, 1000), output: "8203E8"},
+	{val: time.Unix(1600000000, 0), output: "8816345785D8A00000"},
+	{val: (*time.Time)(nil), output: "80"},
+	{val: time.Unix(-1, 0), error: "rlp: time out of range"},
+	{val: time.Unix(0, 0), error: "rlp: time out of range"},
+	{val: time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC), error: "rlp: time out of range"},
+
+	// signed big ints
+	{val: common.NewBigInt(0), output: "80"},
+	{val: common.NewBigInt(1), output: "01"},
+	{val: common.NewBigInt(300), output: "82012C"},
+	{val: common.NewBigInt(-1), output: "C101"},
+	{val: common.NewBigInt(-300), output: "C382012C"},
+	{val: *common.NewBigInt(5), output: "05"},
+	{val: common.BigInt{}, output: "80"},
+	{val: (*common.BigInt)(nil), output: "80"},
+
 	// byte arrays
 	{val: [0]byte{}, output: "80"},
 	{val: [1]byte{0}, output: "00"},
@@ -479,7 +499,7 @@ func BenchmarkPutint(b *testing.B) {
 func BenchmarkEncodeBigInts(b *testing.B) {
 	ints := make([]*big.Int, 200)
 	for i := range ints {
-		ints[i] = common.BigPow(2, int64(i))
+		ints[i] = new(big.Int).Lsh(big.NewInt(1), uint(i))
 	}
 	out := bytes.NewBuffer(make([]byte, 0, 4096))
 	b.ResetTimer()
@@ -571,3 +591,86 @@ func BenchmarkEncodeStructPtrSlice(b *testing.B) {
 		}
 	}
 }
+
+// blockPartsValue resembles a block split into parts with their merkle proofs.
+type blockPartsValue struct {
+	Height uint64
+	Parts  [][]byte
+	Proofs [][]byte
+}
+
+func makeBlockPartsValue(numParts, partSize int) *blockPartsValue {
+	value := &blockPartsValue{Height: 1000}
+	for i := 0; i < numParts; i++ {
+		value.Parts = append(value.Parts, bytes.Repeat([]byte{byte(i)}, partSize))
+		for j := 0; j < 8; j++ {
+			value.Proofs = append(value.Proofs, bytes.Repeat([]byte{byte(j)}, 32))
+		}
+	}
+	return value
+}
+
+func BenchmarkEncodeBlockParts(b *testing.B) {
+	for _, size := range []int{32, 1024, 65536} {
+		value := makeBlockPartsValue(64, size)
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			var out bytes.Buffer
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				out.Reset()
+				if err := Encode(&out, value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type namedByteSlice []byte
+
+func TestEncodeByteSlicesFastPath(t *testing.T) {
+	inputs := [][][]byte{
+		nil,
+		{},
+		{{}, {0x00}, {0x7f}, {0x80}},
+		{bytes.Repeat([]byte{1}, 55), bytes.Repeat([]byte{2}, 56), bytes.Repeat([]byte{3}, 1024)},
+	}
+	for i, input := range inputs {
+		// Slices of interfaces go through the generic slice writer.
+		generic := make([]interface{}, len(input))
+		named := make([]namedByteSlice, len(input))
+		for j, b := range input {
+			generic[j] = b
+			named[j] = b
+		}
+		want, err := EncodeToBytes(generic)
+		if err != nil {
+			t.Fatalf("test %d: generic encoding error: %v", i, err)
+		}
+
+		for _, val := range []interface{}{input, &input, named, &named, &struct{ V [][]byte }{input}} {
+			output, err := EncodeToBytes(val)
+			if err != nil {
+				t.Fatalf("test %d: %T encoding error: %v", i, val, err)
+			}
+			if _, ok := val.(*struct{ V [][]byte }); ok {
+				// Struct fields are addressable and take the unsafe path.
+				if output, _, err = SplitList(output); err != nil {
+					t.Fatalf("test %d: %T split error: %v", i, val, err)
+				}
+			}
+			if !bytes.Equal(output, want) {
+				t.Errorf("test %d: %T output mismatch:\ngot   %X\nwant  %X", i, val, output, want)
+			}
+		}
+	}
+
+	// RawValue elements are still written as they are.
+	output, err := EncodeToBytes([]RawValue{unhex("C0"), unhex("01")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex("C2C001"); !bytes.Equal(output, want) {
+		t.Errorf("RawValue output mismatch: got %X, want %X", output, want)
+	}
+}
@@ -34,7 +34,8 @@ type Type struct {
 // as an empty string or empty list.
 func (t Type) DefaultNilValue() NilKind {
 	k := t.Kind
-	if isUint(k) || k == reflect.String || k == reflect.Bool || isByteArray(t) {
+	if isUint(k) || k == reflect.String || k == reflect.Bool || isByteArray(t) ||
+		t.Name == "time.Time" || t.Name == "common.BigInt" {
 		return NilKindString
 	}
 	return NilKindList
@@ -285,3 +285,53 @@ func TestAppendUint64Random(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRawValueStructRoundTrip(t *testing.T) {
+	type envelope struct {
+		Kind    uint
+		Payload RawValue
+		Trailer []RawValue
+	}
+	payload := RawValue(unhex("C50183FFFFFF"))
+	in := envelope{
+		Kind:    7,
+		Payload: payload,
+		Trailer: []RawValue{unhex("80"), unhex("C0"), unhex("8203E8")},
+	}
+	enc, err := EncodeToBytes(in)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	// The payload must appear verbatim in the encoding.
+	if !bytes.Contains(enc, payload) {
+		t.Fatalf("encoding %x does not contain raw payload %x", enc, payload)
+	}
+
+	var out envelope
+	if err := DecodeBytes(enc, &out); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if out.Kind != in.Kind {
+		t.Errorf("kind mismatch: got %d, want %d", out.Kind, in.Kind)
+	}
+	if !bytes.Equal(out.Payload, payload) {
+		t.Errorf("payload mismatch: got %x, want %x", out.Payload, payload)
+	}
+	if len(out.Trailer) != len(in.Trailer) {
+		t.Fatalf("trailer length mismatch: got %d, want %d", len(out.Trailer), len(in.Trailer))
+	}
+	for i := range in.Trailer {
+		if !bytes.Equal(out.Trailer[i], in.Trailer[i]) {
+			t.Errorf("trailer %d mismatch: got %x, want %x", i, out.Trailer[i], in.Trailer[i])
+		}
+	}
+
+	// Re-encoding the decoded value must be byte-identical.
+	reenc, err := EncodeToBytes(out)
+	if err != nil {
+		t.Fatalf("re-encode error: %v", err)
+	}
+	if !bytes.Equal(reenc, enc) {
+		t.Errorf("re-encoding mismatch: got %x, want %x", reenc, enc)
+	}
+}
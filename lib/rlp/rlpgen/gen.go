@@ -316,6 +316,23 @@ func (op bigIntOp) genDecode(ctx *genContext) (string, string) {
 	return result, b.String()
 }
 
+// timeOp handles time.Time, which has its own encoding as nanoseconds since
+// the Unix epoch.
+type timeOp struct{}
+
+func (op timeOp) genWrite(ctx *genContext, v string) string {
+	return fmt.Sprintf("if err := w.WriteTime(%s); err != nil { return err }\n", v)
+}
+
+func (op timeOp) genDecode(ctx *genContext) (string, string) {
+	var resultV = ctx.temp()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s, err := dec.Time()\n", resultV)
+	fmt.Fprintf(&b, "if err != nil { return err }\n")
+	return resultV, b.String()
+}
+
 // encoderDecoderOp handles rlp.Encoder and rlp.Decoder.
 // In order to be used with this, the type must implement both interfaces.
 // This restriction may be lifted in the future by creating separate ops for
@@ -621,6 +638,9 @@ func (bctx *buildContext) makeOp(name *types.Named, typ types.Type, tags rlpstru
 		if isBigInt(typ) {
 			return bigIntOp{}, nil
 		}
+		if isTime(typ) {
+			return timeOp{}, nil
+		}
 		if typ == bctx.rawValueType {
 			return bctx.makeRawValueOp(), nil
 		}
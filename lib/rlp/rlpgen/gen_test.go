@@ -34,7 +34,7 @@ func init() {
 	}
 }
 
-var tests = []string{"uints", "nil", "rawvalue", "optional", "bigint"}
+var tests = []string{"uints", "nil", "rawvalue", "optional", "bigint", "time"}
 
 func TestOutput(t *testing.T) {
 	for _, test := range tests {
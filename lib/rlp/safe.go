@@ -27,3 +27,9 @@ import "reflect"
 func byteArrayBytes(v reflect.Value, length int) []byte {
 	return v.Slice(0, length).Bytes()
 }
+
+// byteSlices is not available without unsafe, elements of v are read one by
+// one instead.
+func byteSlices(v reflect.Value) ([][]byte, bool) {
+	return nil, false
+}
@@ -35,3 +35,12 @@ func byteArrayBytes(v reflect.Value, length int) []byte {
 	hdr.Len = length
 	return s
 }
+
+// byteSlices returns the elements of v, a slice of byte slices, without
+// going through reflection for each of them. It requires v to be addressable.
+func byteSlices(v reflect.Value) ([][]byte, bool) {
+	if !v.CanAddr() {
+		return nil, false
+	}
+	return *(*[][]byte)(unsafe.Pointer(v.UnsafeAddr())), true
+}
@@ -2,6 +2,7 @@ package kai
 
 import (
 	"fmt"
+	"time"
 
 	bcReactor "github.com/kardiachain/go-kardia/blockchain"
 	"github.com/kardiachain/go-kardia/internal/kaiapi"
@@ -166,7 +167,15 @@ func New(stack *node.Node, config *Config) (*Kardiachain, error) {
 
 	bOper := blockchain.NewBlockOperations(logger, kai.blockchain, kai.txPool, evPool, stakingUtil)
 
-	kai.evR = evidence.NewReactor(evPool)
+	evOptions := []evidence.ReactorOption{
+		evidence.WithGossipBudget(config.EvidenceGossipBudget, time.Second),
+		evidence.WithStrictEvidenceValidation(config.StrictEvidenceValidation),
+		evidence.WithGossipFanout(config.EvidenceGossipFanout),
+	}
+	if config.EvidenceBroadcastLimit > 0 {
+		evOptions = append(evOptions, evidence.WithMaxBroadcastEvidence(config.EvidenceBroadcastLimit))
+	}
+	kai.evR = evidence.NewReactor(evPool, evOptions...)
 	kai.evR.SetLogger(logger)
 	blockExec := cstate.NewBlockExecutor(stateDB, logger, evPool, bOper)
 	kai.blockExec = blockExec
@@ -185,6 +194,9 @@ func New(stack *node.Node, config *Config) (*Kardiachain, error) {
 	// Make BlockchainReactor. Don't start fast sync if we're doing a state sync first.
 	bcR := bcReactor.NewBlockchainReactor(state, blockExec, bOper, config.FastSync)
 	kai.bcR = bcR
+	if err := config.Consensus.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid consensus config: %w", err)
+	}
 	consensusState := consensus.NewConsensusState(
 		log.New(),
 		config.Consensus,
@@ -193,7 +205,11 @@ func New(stack *node.Node, config *Config) (*Kardiachain, error) {
 		blockExec,
 		evPool,
 	)
-	kai.csManager = consensus.NewConsensusManager(consensusState, config.FastSync)
+	var csOptions []consensus.ManagerOption
+	if config.Instrumentation != nil && config.Instrumentation.Prometheus {
+		csOptions = append(csOptions, consensus.WithMetrics(consensus.PrometheusMetrics(config.Instrumentation.Namespace)))
+	}
+	kai.csManager = consensus.NewConsensusManager(consensusState, config.FastSync, csOptions...)
 	// Set private validator for consensus manager.
 	kai.csManager.SetPrivValidator(privValidator)
 	kai.csManager.SetEventBus(kai.eventBus)
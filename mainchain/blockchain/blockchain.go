@@ -67,6 +67,8 @@ type CacheConfig struct {
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 
+	HeaderWarmup bool // Whether to preload the most recent headers into the header caches on startup
+
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -158,6 +160,11 @@ func NewBlockChain(db kaidb.Database, cacheConfig *CacheConfig, gs *genesis.Gene
 	if err != nil {
 		return nil, err
 	}
+	if cacheConfig.HeaderWarmup {
+		if err := bc.hc.WarmUp(); err != nil {
+			return nil, err
+		}
+	}
 	bc.genesisBlock = bc.GetBlockByHeight(0)
 	if bc.genesisBlock == nil {
 		return nil, ErrNoGenesis
@@ -338,7 +345,9 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 	bc.genesisBlock = genesis
 	bc.writeHeadBlock(bc.genesisBlock)
 	bc.currentBlock.Store(bc.genesisBlock)
-	bc.hc.SetGenesis(bc.genesisBlock.Header())
+	if err := bc.hc.SetGenesis(bc.genesisBlock.Header()); err != nil {
+		return err
+	}
 	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
 
 	return nil
@@ -491,6 +500,9 @@ func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, blockInfo *types.
 // writeBlockAndSetHead is the internal implementation of WriteBlockAndSetHead.
 // This function expects the chain mutex to be held.
 func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, blockInfo *types.BlockInfo, state *state.StateDB) error {
+	if err := bc.hc.ValidateHeader(block.Header()); err != nil {
+		return err
+	}
 	if err := bc.writeBlockWithState(block, blockInfo, state); err != nil {
 		return err
 	}
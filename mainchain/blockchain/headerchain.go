@@ -19,19 +19,49 @@
 package blockchain
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/kardiachain/go-kardia/configs"
 	"github.com/kardiachain/go-kardia/kai/kaidb"
 	"github.com/kardiachain/go-kardia/kai/rawdb"
 	"github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/crypto"
+	"github.com/kardiachain/go-kardia/lib/log"
 	"github.com/kardiachain/go-kardia/types"
 )
 
 const (
-	headerCacheLimit = 512
-	heightCacheLimit = 2048
+	headerCacheLimit    = 512
+	heightCacheLimit    = 2048
+	canonicalCacheLimit = 2048
+)
+
+var (
+	// ErrHeaderTooSoon is returned if a header is produced before the minimum
+	// block interval configured for its height has elapsed.
+	ErrHeaderTooSoon = errors.New("header time is below the minimum block interval")
+
+	// ErrBrokenParentLink is returned if a stored header does not link to the
+	// canonical header right below it.
+	ErrBrokenParentLink = errors.New("broken parent link")
+
+	// ErrCorruptGenesis is returned if the genesis header is missing from a
+	// database which already holds a chain.
+	ErrCorruptGenesis = errors.New("genesis not found in non-empty database")
+
+	// ErrGenesisMismatch is returned if a genesis header other than the one
+	// stored in the database is set.
+	ErrGenesisMismatch = errors.New("genesis does not match the stored chain")
+
+	// ErrReorgTooDeep is returned if a rollback would rewind more blocks than
+	// the maximum reorg depth. Such a rollback needs manual intervention, with
+	// SetHead.
+	ErrReorgTooDeep = errors.New("reorg deeper than the maximum reorg depth")
 )
 
 // TODO(huny@): Add detailed description
@@ -44,32 +74,106 @@ type HeaderChain struct {
 
 	currentHeader     atomic.Value // Current head of the header chain (may be above the block chain!)
 	currentHeaderHash common.Hash  // Hash of the current head of the header chain (prevent recomputing all the time)
+	headFallbackOnce  sync.Once    // Warns about the genesis fallback of CurrentHeader once
+
+	headerCache    *lru.Cache // Cache for the most recent block headers
+	heightCache    *lru.Cache // Cache for the most recent block height
+	canonicalCache *lru.Cache // Cache for the most recent canonical height to hash mappings
 
-	headerCache *lru.Cache // Cache for the most recent block headers
-	heightCache *lru.Cache // Cache for the most recent block height
+	headerReadsMu sync.Mutex
+	headerReads   map[headerKey]*headerRead // Header reads in flight, shared by concurrent misses
+
+	maxReorgDepth uint64 // Maximum number of blocks RollbackToHash may rewind, 0 for no limit
+}
+
+// headerKey identifies a header read from the database.
+type headerKey struct {
+	hash   common.Hash
+	height uint64
+}
+
+// headerRead is a database read of a header, whose result is shared by all
+// the goroutines requesting the header while it is in flight.
+type headerRead struct {
+	done   sync.WaitGroup
+	header *types.Header
+	err    error
 }
 
 // CurrentHeader retrieves the current head header of the canonical chain. The
-// header is retrieved from the HeaderChain's internal cache.
+// header is retrieved from the HeaderChain's internal cache. It falls back to
+// the genesis header if no head was set yet, e.g. on a partially constructed
+// chain.
 func (hc *HeaderChain) CurrentHeader() *types.Header {
-	return hc.currentHeader.Load().(*types.Header)
+	if head, ok := hc.currentHeader.Load().(*types.Header); ok && head != nil {
+		return head
+	}
+	hc.headFallbackOnce.Do(func() {
+		log.Warn("Current header not set, falling back to genesis")
+	})
+	return hc.genesisHeader
+}
+
+// Config retrieves the header chain's chain configuration.
+func (hc *HeaderChain) Config() *configs.ChainConfig {
+	return hc.config
+}
+
+// HeaderChainOption sets an optional parameter on the HeaderChain.
+type HeaderChainOption func(*headerChainOptions)
+
+type headerChainOptions struct {
+	genesis       *types.Block // written to an empty database
+	maxReorgDepth uint64
+}
+
+// WithGenesisBlock makes NewHeaderChain initialize an empty database with the
+// given genesis block instead of failing with ErrNoGenesis.
+func WithGenesisBlock(genesis *types.Block) HeaderChainOption {
+	return func(opts *headerChainOptions) { opts.genesis = genesis }
+}
+
+// WithMaxReorgDepth makes RollbackToHash refuse to rewind more than depth
+// blocks with ErrReorgTooDeep. Zero, the default, sets no limit.
+func WithMaxReorgDepth(depth uint64) HeaderChainOption {
+	return func(opts *headerChainOptions) { opts.maxReorgDepth = depth }
 }
 
 // NewHeaderChain creates a new HeaderChain structure.
-func NewHeaderChain(db kaidb.Database, config *configs.ChainConfig) (*HeaderChain, error) {
+// If the database has no genesis header, ErrNoGenesis is returned when it is
+// empty, unless a genesis block is given, and ErrCorruptGenesis otherwise.
+func NewHeaderChain(db kaidb.Database, config *configs.ChainConfig, options ...HeaderChainOption) (*HeaderChain, error) {
 	headerCache, _ := lru.New(headerCacheLimit)
 	heightCache, _ := lru.New(heightCacheLimit)
+	canonicalCache, _ := lru.New(canonicalCacheLimit)
 
 	hc := &HeaderChain{
-		config:      config,
-		db:          db,
-		headerCache: headerCache,
-		heightCache: heightCache,
+		config:         config,
+		db:             db,
+		headerCache:    headerCache,
+		heightCache:    heightCache,
+		canonicalCache: canonicalCache,
+	}
+	var opts headerChainOptions
+	for _, option := range options {
+		option(&opts)
 	}
+	hc.maxReorgDepth = opts.maxReorgDepth
 
-	hc.genesisHeader = hc.GetHeaderByHeight(0)
+	genesisHeader, err := hc.GetHeaderByHeightErr(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis header: %w", err)
+	}
+	hc.genesisHeader = genesisHeader
 	if hc.genesisHeader == nil {
-		return nil, ErrNoGenesis
+		if !hc.IsEmpty() {
+			return nil, ErrCorruptGenesis
+		}
+		if opts.genesis == nil || opts.genesis.Height() != 0 {
+			return nil, ErrNoGenesis
+		}
+		writeGenesisBlock(db, opts.genesis)
+		hc.genesisHeader = opts.genesis.Header()
 	}
 
 	hc.currentHeader.Store(hc.genesisHeader)
@@ -83,35 +187,132 @@ func NewHeaderChain(db kaidb.Database, config *configs.ChainConfig) (*HeaderChai
 	return hc, nil
 }
 
+// IsEmpty reports whether the database holds no chain yet, i.e. neither a
+// head block nor a canonical genesis hash was written to it.
+func (hc *HeaderChain) IsEmpty() bool {
+	return rawdb.ReadHeadBlockHash(hc.db) == (common.Hash{}) &&
+		rawdb.ReadCanonicalHash(hc.db, 0) == (common.Hash{})
+}
+
+// writeGenesisBlock stores genesis as the canonical block at height 0 and the
+// head of the chain.
+func writeGenesisBlock(db kaidb.Database, genesis *types.Block) {
+	rawdb.WriteBlock(db, genesis, genesis.MakePartSet(types.BlockPartSizeBytes), &types.Commit{})
+	rawdb.WriteCanonicalHash(db, genesis.Hash(), genesis.Height())
+	rawdb.WriteHeadBlockHash(db, genesis.Hash())
+}
+
+// WarmUp preloads the most recent headerCacheLimit/2 canonical headers into
+// the header, height and canonical hash caches, so that the first lookups
+// after a restart do not all go to the database.
+func (hc *HeaderChain) WarmUp() error {
+	var (
+		end   = hc.CurrentHeader().Height
+		start uint64
+	)
+	if end >= headerCacheLimit/2 {
+		start = end - headerCacheLimit/2 + 1
+	}
+	headers, err := hc.GetHeaderByHeightRange(start, end)
+	if err != nil {
+		return err
+	}
+	for _, header := range headers {
+		hash := header.Hash()
+		hc.headerCache.Add(hash, header)
+		hc.heightCache.Add(hash, header.Height)
+		hc.canonicalCache.Add(header.Height, hash)
+	}
+	return nil
+}
+
 // GetHeaderByHeight retrieves a block header from the database by height,
-// caching it (associated with its hash) if found.
+// caching it (associated with its hash) and its canonical hash if found.
 func (hc *HeaderChain) GetHeaderByHeight(height uint64) *types.Header {
-	hash := rawdb.ReadCanonicalHash(hc.db, height)
-	if hash == (common.Hash{}) {
-		return nil
+	header, _ := hc.GetHeaderByHeightErr(height)
+	return header
+}
+
+// GetHeaderByHeightErr is like GetHeaderByHeight, but tells a missing header
+// (nil, nil) from a failed database read (nil, err).
+func (hc *HeaderChain) GetHeaderByHeightErr(height uint64) (*types.Header, error) {
+	if cached, ok := hc.canonicalCache.Get(height); ok {
+		return hc.GetHeaderErr(cached.(common.Hash), height)
+	}
+	hash, err := rawdb.ReadCanonicalHashErr(hc.db, height)
+	if err != nil || hash == (common.Hash{}) {
+		return nil, err
+	}
+	hc.canonicalCache.Add(height, hash)
+	return hc.GetHeaderErr(hash, height)
+}
+
+// GetHeaderByHeightRange retrieves the canonical headers with heights in
+// [start, end], skipping the heights that are not found. The range is read
+// with a single database iteration, whatever the gaps in it. A failed database
+// read is returned as an error.
+func (hc *HeaderChain) GetHeaderByHeightRange(start, end uint64) ([]*types.Header, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid header range: start %d > end %d", start, end)
 	}
-	return hc.GetHeader(hash, height)
+	return rawdb.ReadCanonicalHeaderRange(hc.db, start, end)
 }
 
 // GetHeader retrieves a block header from the database by hash and height,
 // caching it if found.
 func (hc *HeaderChain) GetHeader(hash common.Hash, height uint64) *types.Header {
+	header, _ := hc.GetHeaderErr(hash, height)
+	return header
+}
+
+// GetHeaderErr is like GetHeader, but tells a missing header (nil, nil) from a
+// failed database read (nil, err).
+func (hc *HeaderChain) GetHeaderErr(hash common.Hash, height uint64) (*types.Header, error) {
 	// Short circuit if the header's already in the cache, retrieve otherwise
 	if header, ok := hc.headerCache.Get(hash); ok {
-		return header.(*types.Header)
+		return header.(*types.Header), nil
 	}
-	header := rawdb.ReadHeader(hc.db, height)
-	if header == nil {
-		return nil
+	return hc.readHeader(hash, height)
+}
+
+// readHeader reads a header from the database, caching it if found. Concurrent
+// reads of the same header wait for the first one instead of hitting the
+// database again.
+func (hc *HeaderChain) readHeader(hash common.Hash, height uint64) (*types.Header, error) {
+	key := headerKey{hash, height}
+	hc.headerReadsMu.Lock()
+	if read, ok := hc.headerReads[key]; ok {
+		hc.headerReadsMu.Unlock()
+		read.done.Wait()
+		return read.header, read.err
 	}
-	// Cache the found header for next time and return
-	hc.headerCache.Add(hash, header)
-	return header
+	if hc.headerReads == nil {
+		hc.headerReads = make(map[headerKey]*headerRead)
+	}
+	read := new(headerRead)
+	read.done.Add(1)
+	hc.headerReads[key] = read
+	hc.headerReadsMu.Unlock()
+
+	read.header, read.err = rawdb.ReadHeaderErr(hc.db, height)
+	if read.err == nil && read.header != nil {
+		// Cache the found header for next time
+		hc.headerCache.Add(hash, read.header)
+	}
+	hc.headerReadsMu.Lock()
+	delete(hc.headerReads, key)
+	hc.headerReadsMu.Unlock()
+	read.done.Done()
+	return read.header, read.err
 }
 
 // GetHeaderByHash retrieves a block header from the database by hash, caching it if
 // found.
 func (hc *HeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	// The header cache is keyed by hash, so a hit needs no height lookup.
+	if header, ok := hc.headerCache.Get(hash); ok {
+		return header.(*types.Header)
+	}
 	height := hc.GetBlockHeight(hash)
 	if height == nil {
 		return nil
@@ -122,15 +323,88 @@ func (hc *HeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
 // GetBlockHeight retrieves the block height belonging to the given hash
 // from the cache or database
 func (hc *HeaderChain) GetBlockHeight(hash common.Hash) *uint64 {
+	height, _ := hc.GetBlockHeightErr(hash)
+	return height
+}
+
+// GetBlockHeightErr is like GetBlockHeight, but tells a missing height
+// (nil, nil) from a failed database read (nil, err).
+func (hc *HeaderChain) GetBlockHeightErr(hash common.Hash) (*uint64, error) {
 	if cached, ok := hc.heightCache.Get(hash); ok {
 		height := cached.(uint64)
-		return &height
+		return &height, nil
 	}
-	height := rawdb.ReadHeaderHeight(hc.db, hash)
+	height, err := rawdb.ReadHeaderHeightErr(hc.db, hash)
 	if height != nil {
 		hc.heightCache.Add(hash, *height)
 	}
-	return height
+	return height, err
+}
+
+// ValidateHeader checks the header against the rules enabled by the chain
+// configuration at its height. Headers without a known parent are not checked.
+func (hc *HeaderChain) ValidateHeader(header *types.Header) error {
+	if hc.config == nil || header.Height == 0 {
+		return nil
+	}
+	parent := hc.GetHeader(header.LastBlockID.Hash, header.Height-1)
+	if parent == nil {
+		return nil
+	}
+	if hc.config.IsMinBlockInterval(&header.Height) {
+		minTime := parent.Time.Add(time.Duration(hc.config.Kaicon.MinBlockInterval) * time.Second)
+		if header.Time.Before(minTime) {
+			return fmt.Errorf("%w: height %d, time %v, want >= %v", ErrHeaderTooSoon, header.Height, header.Time, minTime)
+		}
+	}
+	return nil
+}
+
+// VerifyParentLinks checks that every canonical header with a height in
+// (from, to] links back through LastBlockID to the canonical header right
+// below it, reporting the first height whose link is broken.
+func (hc *HeaderChain) VerifyParentLinks(from, to uint64) error {
+	if from > to {
+		return fmt.Errorf("invalid header range: from %d > to %d", from, to)
+	}
+	parent := hc.GetHeaderByHeight(from)
+	if parent == nil {
+		return fmt.Errorf("%w: height %d: header not found", ErrBrokenParentLink, from)
+	}
+	for height := from + 1; height <= to && height > from; height++ {
+		header := hc.GetHeaderByHeight(height)
+		if header == nil {
+			return fmt.Errorf("%w: height %d: header not found", ErrBrokenParentLink, height)
+		}
+		if parentHash := parent.Hash(); header.LastBlockID.Hash != parentHash {
+			return fmt.Errorf("%w: height %d: parent hash %v, want %v", ErrBrokenParentLink, height, header.LastBlockID.Hash, parentHash)
+		}
+		parent = header
+	}
+	return nil
+}
+
+// ChainFingerprint returns a rolling hash over the hashes of the canonical
+// headers with heights in [from, to], in increasing height order. Nodes can
+// compare fingerprints to find out whether their chains diverge in the range
+// without exchanging the headers.
+func (hc *HeaderChain) ChainFingerprint(from, to uint64) (common.Hash, error) {
+	if from > to {
+		return common.Hash{}, fmt.Errorf("invalid header range: from %d > to %d", from, to)
+	}
+	var fingerprint common.Hash
+	for height := from; height <= to && height >= from; height++ {
+		header, err := hc.GetHeaderByHeightErr(height)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to read header %d: %w", height, err)
+		}
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("header %d not found", height)
+		}
+		hash := header.Hash()
+		fingerprint = crypto.Keccak256Hash(fingerprint[:], hash[:])
+	}
+	return fingerprint, nil
 }
 
 // SetCurrentHeader sets the current head header of the canonical chain.
@@ -139,9 +413,24 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) {
 	hc.currentHeaderHash = head.Hash()
 }
 
-// SetGenesis sets a new genesis block header for the chain
-func (hc *HeaderChain) SetGenesis(head *types.Header) {
+// SetGenesis sets a new genesis block header for the chain. The header must be
+// the canonical height 0 header already stored in the database, otherwise
+// ErrGenesisMismatch is returned and the genesis is left unchanged.
+func (hc *HeaderChain) SetGenesis(head *types.Header) error {
+	if head == nil || head.Height != 0 {
+		return fmt.Errorf("%w: not a genesis header", ErrGenesisMismatch)
+	}
+	// Read from the database, the cached mapping may predate a reset.
+	stored, err := rawdb.ReadCanonicalHashErr(hc.db, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read genesis hash: %w", err)
+	}
+	if hash := head.Hash(); hash != stored {
+		return fmt.Errorf("%w: have %v, stored %v", ErrGenesisMismatch, hash.Hex(), stored.Hex())
+	}
+	hc.canonicalCache.Remove(uint64(0))
 	hc.genesisHeader = head
+	return nil
 }
 
 // DeleteCallback is a callback function that is called by SetHead before
@@ -150,33 +439,125 @@ type DeleteCallback func(kaidb.Database, uint64)
 
 // SetHead rewinds the local chain to a new head. Everything above the new head
 // will be deleted and the new one set.
+//
+// The rewind is done in a single pass from the current head downwards, queueing
+// all deletions into one batch that is flushed whenever it grows past the ideal
+// batch size, which keeps memory bounded on very deep rewinds. Setting the head
+// at or above the current one is a no-op.
 func (hc *HeaderChain) SetHead(head uint64, delFn DeleteCallback) {
-	height := uint64(0)
-
-	if hdr := hc.CurrentHeader(); hdr != nil {
+	var (
+		batch  = hc.db.NewBatch()
+		hdr    = hc.CurrentHeader()
+		height uint64
+	)
+	if hdr != nil {
 		height = hdr.Height
 	}
-	for hdr := hc.CurrentHeader(); hdr != nil && hdr.Height > head; hdr = hc.CurrentHeader() {
-		height := hdr.Height
-		if delFn != nil {
-			delFn(hc.db, height)
+	// Nothing to rewind, leave the head and caches alone.
+	if height <= head {
+		return
+	}
+	for ; height > head; height-- {
+		if hdr != nil && hdr.Height == height {
+			if delFn != nil {
+				delFn(hc.db, height)
+			}
+			if blockMeta := rawdb.ReadBlockMeta(hc.db, height); blockMeta != nil {
+				if err := rawdb.DeleteBlockParts(batch, height, blockMeta.BlockID.PartsHeader.Total); err != nil {
+					log.Crit("Failed to delete block parts", "height", height, "err", err)
+				}
+			}
+			rawdb.DeleteBlockMeta(batch, height)
+			hdr = hc.GetHeader(hdr.LastBlockID.Hash, height-1)
+		}
+		// Roll back the canonical chain numbering
+		rawdb.DeleteCanonicalHash(batch, height)
+
+		if batch.ValueSize() >= kaidb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to rewind header chain", "err", err)
+			}
+			batch.Reset()
 		}
-		rawdb.DeleteBlockMeta(hc.db, height)
-		rawdb.DeleteBlockPart(hc.db, height)
-		hc.currentHeader.Store(hc.GetHeader(hdr.LastBlockID.Hash, hdr.Height-1))
 	}
-	// Roll back the canonical chain numbering
-	for i := height; i > head; i-- {
-		rawdb.DeleteCanonicalHash(hc.db, i)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to rewind header chain", "err", err)
 	}
+	// The parent of the new head may be missing, e.g. if it was pruned.
+	if hdr == nil {
+		hdr = hc.genesisHeader
+	}
+	hc.currentHeader.Store(hdr)
 
 	// Clear out any stale content from the caches
 	hc.headerCache.Purge()
 	hc.heightCache.Purge()
+	hc.canonicalCache.Purge()
 
-	if hc.CurrentHeader() == nil {
-		hc.currentHeader.Store(hc.genesisHeader)
-	}
 	hc.currentHeaderHash = hc.CurrentHeader().Hash()
+}
+
+// PruneHeaders deletes the canonical headers below the given height, along with
+// their block parts and hash to height mappings, keeping the genesis header.
+// delFn, if not nil, is called before each height is deleted so that dependent
+// data can be removed with it. Pruning above the current head is refused.
+func (hc *HeaderChain) PruneHeaders(before uint64, delFn DeleteCallback) error {
+	if current := hc.CurrentHeader(); current == nil || before > current.Height {
+		return fmt.Errorf("cannot prune headers below %d above the current head", before)
+	}
+	batch := hc.db.NewBatch()
+	for height := uint64(1); height < before; height++ {
+		hash := rawdb.ReadCanonicalHash(hc.db, height)
+		if hash == (common.Hash{}) {
+			// Already pruned
+			continue
+		}
+		if delFn != nil {
+			delFn(hc.db, height)
+		}
+		if blockMeta := rawdb.ReadBlockMeta(hc.db, height); blockMeta != nil {
+			if err := rawdb.DeleteBlockParts(batch, height, blockMeta.BlockID.PartsHeader.Total); err != nil {
+				return err
+			}
+		}
+		rawdb.DeleteBlockMeta(batch, height)
+		rawdb.DeleteHeader(batch, hash, height)
+		rawdb.DeleteCanonicalHash(batch, height)
+		hc.headerCache.Remove(hash)
+		hc.heightCache.Remove(hash)
+		hc.canonicalCache.Remove(height)
+
+		if batch.ValueSize() >= kaidb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
 
+// RollbackToHash rewinds the local chain to the canonical block with the given
+// hash, like SetHead does for a height. It fails if the hash is unknown, not
+// canonical, or above the current head, and with ErrReorgTooDeep if it is
+// further below the current head than the maximum reorg depth.
+func (hc *HeaderChain) RollbackToHash(hash common.Hash, delFn DeleteCallback) error {
+	height := hc.GetBlockHeight(hash)
+	if height == nil {
+		return fmt.Errorf("unknown block hash %v", hash)
+	}
+	if canonical := rawdb.ReadCanonicalHash(hc.db, *height); canonical != hash {
+		return fmt.Errorf("block %v at height %d is not canonical", hash, *height)
+	}
+	if current := hc.CurrentHeader(); current != nil {
+		if *height > current.Height {
+			return fmt.Errorf("block %v at height %d is above current head %d", hash, *height, current.Height)
+		}
+		if depth := current.Height - *height; hc.maxReorgDepth > 0 && depth > hc.maxReorgDepth {
+			return fmt.Errorf("%w: rewinding %d blocks from head %d, limit %d",
+				ErrReorgTooDeep, depth, current.Height, hc.maxReorgDepth)
+		}
+	}
+	hc.SetHead(*height, delFn)
+	return nil
 }
@@ -0,0 +1,704 @@
+/*
+ *  Copyright 2018 KardiaChain
+ *  This file is part of the go-kardia library.
+ *
+ *  The go-kardia library is free software: you can redistribute it and/or modify
+ *  it under the terms of the GNU Lesser General Public License as published by
+ *  the Free Software Foundation, either version 3 of the License, or
+ *  (at your option) any later version.
+ *
+ *  The go-kardia library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ *  GNU Lesser General Public License for more details.
+ *
+ *  You should have received a copy of the GNU Lesser General Public License
+ *  along with the go-kardia library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package blockchain
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kardiachain/go-kardia/configs"
+	"github.com/kardiachain/go-kardia/kai/kaidb"
+	"github.com/kardiachain/go-kardia/kai/kaidb/memorydb"
+	"github.com/kardiachain/go-kardia/kai/rawdb"
+	"github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/rand"
+	"github.com/kardiachain/go-kardia/trie"
+	"github.com/kardiachain/go-kardia/types"
+)
+
+// writeTestBlock stores a block with the given header as the canonical block
+// at its height.
+func writeTestBlock(db kaidb.Database, header *types.Header) *types.Block {
+	block := types.NewBlock(header, nil, &types.Commit{}, nil, trie.NewStackTrie(nil))
+	rawdb.WriteBlock(db, block, block.MakePartSet(types.BlockPartSizeBytes), &types.Commit{})
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.Height())
+	return block
+}
+
+func TestHeaderChainValidateHeader(t *testing.T) {
+	db := memorydb.New()
+	genesisTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	genesis := writeTestBlock(db, &types.Header{Height: 0, Time: genesisTime})
+
+	forkBlock := uint64(0)
+	config := &configs.ChainConfig{
+		Kaicon: &configs.KaiconConfig{
+			Period:           15,
+			Epoch:            30000,
+			MinBlockInterval: 5,
+			MinIntervalBlock: &forkBlock,
+		},
+	}
+	hc, err := NewHeaderChain(db, config)
+	require.NoError(t, err)
+	assert.Equal(t, config, hc.Config())
+
+	tooSoon := &types.Header{
+		Height:      1,
+		Time:        genesisTime.Add(2 * time.Second),
+		LastBlockID: types.BlockID{Hash: genesis.Hash()},
+	}
+	err = hc.ValidateHeader(tooSoon)
+	assert.True(t, errors.Is(err, ErrHeaderTooSoon), "unexpected error: %v", err)
+
+	onTime := &types.Header{
+		Height:      1,
+		Time:        genesisTime.Add(5 * time.Second),
+		LastBlockID: types.BlockID{Hash: genesis.Hash()},
+	}
+	assert.NoError(t, hc.ValidateHeader(onTime))
+
+	// The rule does not apply before its switch block.
+	forkBlock = 2
+	assert.NoError(t, hc.ValidateHeader(tooSoon))
+}
+
+// newTestHeaderChain stores a canonical chain of the given length on top of a
+// genesis block and returns a header chain positioned at its head.
+func newTestHeaderChain(tb testing.TB, length uint64) (*HeaderChain, kaidb.Database) {
+	db := memorydb.New()
+	genesisTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent := writeTestBlock(db, &types.Header{Height: 0, Time: genesisTime})
+	for height := uint64(1); height <= length; height++ {
+		parent = writeTestBlock(db, &types.Header{
+			Height:      height,
+			Time:        genesisTime.Add(time.Duration(height) * time.Second),
+			LastBlockID: types.BlockID{Hash: parent.Hash()},
+		})
+	}
+	rawdb.WriteHeadBlockHash(db, parent.Hash())
+
+	hc, err := NewHeaderChain(db, configs.TestChainConfig)
+	require.NoError(tb, err)
+	return hc, db
+}
+
+// legacySetHead is the unbatched rewind SetHead used to perform, kept to check
+// that the batched implementation leaves the database in the same state. Block
+// parts are deleted before the block meta they are looked up from.
+func legacySetHead(hc *HeaderChain, head uint64) {
+	height := uint64(0)
+
+	if hdr := hc.CurrentHeader(); hdr != nil {
+		height = hdr.Height
+	}
+	for hdr := hc.CurrentHeader(); hdr != nil && hdr.Height > head; hdr = hc.CurrentHeader() {
+		height := hdr.Height
+		rawdb.DeleteBlockPart(hc.db, height)
+		rawdb.DeleteBlockMeta(hc.db, height)
+		hc.currentHeader.Store(hc.GetHeader(hdr.LastBlockID.Hash, hdr.Height-1))
+	}
+	for i := height; i > head; i-- {
+		rawdb.DeleteCanonicalHash(hc.db, i)
+	}
+	hc.headerCache.Purge()
+	hc.heightCache.Purge()
+	hc.currentHeaderHash = hc.CurrentHeader().Hash()
+}
+
+func dumpDB(t *testing.T, db kaidb.Database) map[string]string {
+	entries := make(map[string]string)
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		entries[string(it.Key())] = string(it.Value())
+	}
+	require.NoError(t, it.Error())
+	return entries
+}
+
+func TestHeaderChainSetHead(t *testing.T) {
+	for _, head := range []uint64{0, 1, 50, 99, 100} {
+		expected, expectedDB := newTestHeaderChain(t, 100)
+		legacySetHead(expected, head)
+
+		hc, db := newTestHeaderChain(t, 100)
+		hc.SetHead(head, nil)
+
+		assert.Equal(t, head, hc.CurrentHeader().Height)
+		assert.Equal(t, expected.CurrentHeader().Hash(), hc.CurrentHeader().Hash())
+		assert.Equal(t, dumpDB(t, expectedDB), dumpDB(t, db), "database mismatch rewinding to %d", head)
+	}
+}
+
+func TestHeaderChainSetHeadAboveHead(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 10)
+	hash := rawdb.ReadCanonicalHash(db, 5)
+	require.NotNil(t, hc.GetHeaderByHash(hash))
+	require.NotNil(t, hc.GetHeaderByHeight(5))
+	cached := []int{hc.headerCache.Len(), hc.heightCache.Len(), hc.canonicalCache.Len()}
+	require.NotContains(t, cached, 0)
+
+	current := hc.CurrentHeader()
+	before := dumpDB(t, db)
+	for _, head := range []uint64{10, 11, 100} {
+		hc.SetHead(head, func(kaidb.Database, uint64) {
+			t.Fatalf("delete callback called setting head to %d", head)
+		})
+		assert.Same(t, current, hc.CurrentHeader())
+		assert.Equal(t, []int{hc.headerCache.Len(), hc.heightCache.Len(), hc.canonicalCache.Len()}, cached)
+	}
+	assert.Equal(t, before, dumpDB(t, db))
+}
+
+func BenchmarkHeaderChainSetHead(b *testing.B) {
+	const length = 100000
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		hc, _ := newTestHeaderChain(b, length)
+		b.StartTimer()
+
+		hc.SetHead(0, nil)
+	}
+}
+
+func TestHeaderChainGetHeaderByHeightRange(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 50)
+	// Punch a few holes in the canonical chain.
+	rawdb.DeleteCanonicalHash(db, 10)
+	rawdb.DeleteBlockMeta(db, 20)
+	rawdb.DeleteCanonicalHash(db, 21)
+	// And a long one, as left by pruning.
+	for height := uint64(30); height < 40; height++ {
+		rawdb.DeleteCanonicalHash(db, height)
+	}
+
+	for _, r := range [][2]uint64{{0, 50}, {5, 25}, {10, 10}, {20, 21}, {25, 45}, {30, 39}, {45, 60}, {60, 70}} {
+		var expected []*types.Header
+		for height := r[0]; height <= r[1]; height++ {
+			if header := hc.GetHeaderByHeight(height); header != nil {
+				expected = append(expected, header)
+			}
+		}
+		headers, err := hc.GetHeaderByHeightRange(r[0], r[1])
+		require.NoError(t, err)
+		require.Equal(t, len(expected), len(headers), "range %v", r)
+		for i := range expected {
+			assert.Equal(t, expected[i].Hash(), headers[i].Hash(), "range %v", r)
+		}
+	}
+
+	_, err := hc.GetHeaderByHeightRange(2, 1)
+	assert.Error(t, err)
+}
+
+func TestHeaderChainGetHeaderByHeightRangeReadErrors(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 20)
+	rawdb.DeleteCanonicalHash(db, 10)
+	failing := &failingDB{Database: db}
+	hc.db = failing
+	hc.headerCache.Purge()
+	hc.canonicalCache.Purge()
+
+	// A failed iteration is returned.
+	failing.failingIter = true
+	headers, err := hc.GetHeaderByHeightRange(0, 20)
+	assert.True(t, errors.Is(err, errTestRead))
+	assert.Nil(t, headers)
+
+	// The gap is skipped by the iteration rather than looked up on its own.
+	failing.failingIter, failing.failing = false, true
+	headers, err = hc.GetHeaderByHeightRange(0, 20)
+	require.NoError(t, err)
+	assert.Len(t, headers, 20)
+}
+
+func BenchmarkHeaderChainGetHeaderByHeightRange(b *testing.B) {
+	const length = 10000
+	hc, _ := newTestHeaderChain(b, length)
+
+	b.Run("Range", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hc.headerCache.Purge()
+			if _, err := hc.GetHeaderByHeightRange(0, length); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("PerHeight", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hc.headerCache.Purge()
+			for height := uint64(0); height <= length; height++ {
+				hc.GetHeaderByHeight(height)
+			}
+		}
+	})
+}
+
+func TestHeaderChainVerifyParentLinks(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 20)
+	require.NoError(t, hc.VerifyParentLinks(0, 20))
+
+	// Overwrite block 12 with one pointing to an unknown parent.
+	header := hc.GetHeaderByHeight(12)
+	header.LastBlockID = types.BlockID{Hash: common.BytesToHash(rand.Bytes(32))}
+	writeTestBlock(db, header)
+	hc.headerCache.Purge()
+
+	require.NoError(t, hc.VerifyParentLinks(0, 11))
+	err := hc.VerifyParentLinks(5, 20)
+	require.True(t, errors.Is(err, ErrBrokenParentLink), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "height 12")
+
+	// A missing header is reported as well.
+	rawdb.DeleteBlockMeta(db, 3)
+	hc.headerCache.Purge()
+	err = hc.VerifyParentLinks(0, 10)
+	require.True(t, errors.Is(err, ErrBrokenParentLink), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "height 3")
+}
+
+func TestHeaderChainFingerprint(t *testing.T) {
+	hc, _ := newTestHeaderChain(t, 10)
+	other, otherDB := newTestHeaderChain(t, 10)
+
+	fingerprint, err := hc.ChainFingerprint(0, 10)
+	require.NoError(t, err)
+	assert.NotEqual(t, common.Hash{}, fingerprint)
+	otherFingerprint, err := other.ChainFingerprint(0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, otherFingerprint)
+
+	// Replace one header of the other chain.
+	header := other.GetHeaderByHeight(6)
+	header.Time = header.Time.Add(time.Millisecond)
+	writeTestBlock(otherDB, header)
+	other.headerCache.Purge()
+	other.canonicalCache.Purge()
+
+	otherFingerprint, err = other.ChainFingerprint(0, 10)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherFingerprint)
+	fingerprint, err = hc.ChainFingerprint(0, 5)
+	require.NoError(t, err)
+	otherFingerprint, err = other.ChainFingerprint(0, 5)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, otherFingerprint)
+
+	_, err = hc.ChainFingerprint(5, 4)
+	assert.Error(t, err)
+	_, err = hc.ChainFingerprint(5, 11)
+	assert.Error(t, err)
+}
+
+func TestHeaderChainRollbackToHash(t *testing.T) {
+	hc, _ := newTestHeaderChain(t, 20)
+	target := hc.GetHeaderByHeight(10)
+
+	// An unknown hash leaves the chain untouched.
+	err := hc.RollbackToHash(common.BytesToHash(rand.Bytes(32)), nil)
+	assert.Error(t, err)
+	assert.EqualValues(t, 20, hc.CurrentHeader().Height)
+
+	var deleted []uint64
+	require.NoError(t, hc.RollbackToHash(target.Hash(), func(_ kaidb.Database, height uint64) {
+		deleted = append(deleted, height)
+	}))
+	assert.Equal(t, target.Hash(), hc.CurrentHeader().Hash())
+	assert.Len(t, deleted, 10)
+	assert.Nil(t, hc.GetHeaderByHeight(11))
+
+	// Hashes above the head are rejected.
+	hc, _ = newTestHeaderChain(t, 20)
+	above := hc.GetHeaderByHeight(15)
+	hc.SetCurrentHeader(hc.GetHeaderByHeight(10))
+	err = hc.RollbackToHash(above.Hash(), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "above current head")
+	assert.EqualValues(t, 10, hc.CurrentHeader().Height)
+	assert.NotNil(t, hc.GetHeaderByHeight(15))
+}
+
+func TestHeaderChainMaxReorgDepth(t *testing.T) {
+	_, db := newTestHeaderChain(t, 20)
+	hc, err := NewHeaderChain(db, configs.TestChainConfig, WithMaxReorgDepth(5))
+	require.NoError(t, err)
+
+	// Rewinding more than 5 blocks is refused.
+	err = hc.RollbackToHash(hc.GetHeaderByHeight(14).Hash(), nil)
+	assert.ErrorIs(t, err, ErrReorgTooDeep)
+	assert.EqualValues(t, 20, hc.CurrentHeader().Height)
+	assert.NotNil(t, hc.GetHeaderByHeight(15))
+
+	require.NoError(t, hc.RollbackToHash(hc.GetHeaderByHeight(15).Hash(), nil))
+	assert.EqualValues(t, 15, hc.CurrentHeader().Height)
+
+	// SetHead is not limited.
+	hc.SetHead(5, nil)
+	assert.EqualValues(t, 5, hc.CurrentHeader().Height)
+}
+
+func TestHeaderChainWarmUp(t *testing.T) {
+	const length = 600
+	hc, db := newTestHeaderChain(t, length)
+	hc.headerCache.Purge()
+	hc.heightCache.Purge()
+	require.NoError(t, hc.WarmUp())
+
+	first := uint64(length - headerCacheLimit/2 + 1)
+	assert.Equal(t, headerCacheLimit/2, hc.headerCache.Len())
+	assert.Equal(t, headerCacheLimit/2, hc.heightCache.Len())
+	for height := uint64(0); height <= length; height++ {
+		hash := rawdb.ReadCanonicalHash(db, height)
+		assert.Equal(t, height >= first, hc.headerCache.Contains(hash), "height %d", height)
+		assert.Equal(t, height >= first, hc.heightCache.Contains(hash), "height %d", height)
+	}
+
+	// Short chains are preloaded entirely.
+	hc, _ = newTestHeaderChain(t, 10)
+	hc.headerCache.Purge()
+	hc.heightCache.Purge()
+	require.NoError(t, hc.WarmUp())
+	assert.Equal(t, 11, hc.headerCache.Len())
+	assert.Equal(t, 11, hc.heightCache.Len())
+}
+
+// countingDB counts the reads going through to the underlying database.
+type countingDB struct {
+	kaidb.Database
+	reads int
+}
+
+func (db *countingDB) Get(key []byte) ([]byte, error) {
+	db.reads++
+	return db.Database.Get(key)
+}
+
+func (db *countingDB) Has(key []byte) (bool, error) {
+	db.reads++
+	return db.Database.Has(key)
+}
+
+func TestHeaderChainGetHeaderByHashCached(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 10)
+	counter := &countingDB{Database: db}
+	hc.db = counter
+	hc.headerCache.Purge()
+	hc.heightCache.Purge()
+
+	hash := rawdb.ReadCanonicalHash(db, 5)
+	require.NotNil(t, hc.GetHeaderByHash(hash))
+	reads := counter.reads
+	assert.NotZero(t, reads)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, hash, hc.GetHeaderByHash(hash).Hash())
+	}
+	assert.Equal(t, reads, counter.reads)
+
+	// A cached header is served even once its height has been evicted.
+	hc.heightCache.Purge()
+	require.Equal(t, hash, hc.GetHeaderByHash(hash).Hash())
+	assert.Equal(t, reads, counter.reads)
+
+	// With only the height cached, the header is read once and cached again.
+	hc.headerCache.Purge()
+	hc.GetBlockHeight(hash)
+	reads = counter.reads
+	require.Equal(t, hash, hc.GetHeaderByHash(hash).Hash())
+	require.Equal(t, hash, hc.GetHeaderByHash(hash).Hash())
+	assert.Equal(t, reads+1, counter.reads)
+}
+
+// blockingDB holds every Get until release is closed, counting them.
+type blockingDB struct {
+	kaidb.Database
+	gets    int32
+	release chan struct{}
+}
+
+func (db *blockingDB) Get(key []byte) ([]byte, error) {
+	atomic.AddInt32(&db.gets, 1)
+	<-db.release
+	return db.Database.Get(key)
+}
+
+func TestHeaderChainConcurrentGetHeader(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 10)
+	hash := rawdb.ReadCanonicalHash(db, 5)
+	hc.headerCache.Purge()
+	blocking := &blockingDB{Database: db, release: make(chan struct{})}
+	hc.db = blocking
+
+	const readers = 32
+	var wg sync.WaitGroup
+	headers := make([]*types.Header, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			headers[i] = hc.GetHeader(hash, 5)
+		}(i)
+	}
+	// Let all readers miss the cache while the first read is held.
+	for atomic.LoadInt32(&blocking.gets) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(blocking.release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&blocking.gets))
+	for _, header := range headers {
+		require.NotNil(t, header)
+		assert.Equal(t, hash, header.Hash())
+	}
+	assert.Empty(t, hc.headerReads)
+}
+
+func TestNewHeaderChainMissingGenesis(t *testing.T) {
+	genesisTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	genesis := types.NewBlock(&types.Header{Height: 0, Time: genesisTime}, nil, &types.Commit{}, nil, trie.NewStackTrie(nil))
+
+	// An empty database is an error unless a genesis block is given...
+	db := memorydb.New()
+	_, err := NewHeaderChain(db, configs.TestChainConfig)
+	assert.Equal(t, ErrNoGenesis, err)
+
+	// ...in which case it is initialized with it.
+	hc, err := NewHeaderChain(db, configs.TestChainConfig, WithGenesisBlock(genesis))
+	require.NoError(t, err)
+	assert.Equal(t, genesis.Hash(), hc.CurrentHeader().Hash())
+	assert.Equal(t, genesis.Hash(), rawdb.ReadCanonicalHash(db, 0))
+	hc, err = NewHeaderChain(db, configs.TestChainConfig)
+	require.NoError(t, err)
+	assert.Equal(t, genesis.Hash(), hc.CurrentHeader().Hash())
+
+	// A database holding blocks but no genesis is corrupt.
+	_, corrupt := newTestHeaderChain(t, 5)
+	rawdb.DeleteBlockMeta(corrupt, 0)
+	_, err = NewHeaderChain(corrupt, configs.TestChainConfig, WithGenesisBlock(genesis))
+	assert.Equal(t, ErrCorruptGenesis, err)
+	assert.Nil(t, rawdb.ReadHeader(corrupt, 0))
+}
+
+func TestHeaderChainIsEmpty(t *testing.T) {
+	db := memorydb.New()
+	hc := &HeaderChain{db: db}
+	assert.True(t, hc.IsEmpty())
+
+	// Data unrelated to the chain does not count.
+	require.NoError(t, db.Put([]byte("unrelated"), []byte{1}))
+	assert.True(t, hc.IsEmpty())
+
+	genesis := types.NewBlock(&types.Header{Height: 0}, nil, &types.Commit{}, nil, trie.NewStackTrie(nil))
+	writeGenesisBlock(db, genesis)
+	assert.False(t, hc.IsEmpty())
+
+	// Neither the head nor the genesis hash alone makes it empty.
+	rawdb.DeleteCanonicalHash(db, 0)
+	assert.False(t, hc.IsEmpty())
+	rawdb.WriteCanonicalHash(db, genesis.Hash(), 0)
+	rawdb.WriteHeadBlockHash(db, common.Hash{})
+	assert.False(t, hc.IsEmpty())
+}
+
+var errTestRead = errors.New("read failed")
+
+// failingDB fails every read while failing is set, and every iteration while
+// failingIter is set.
+type failingDB struct {
+	kaidb.Database
+	failing     bool
+	failingIter bool
+}
+
+func (db *failingDB) NewIterator(prefix []byte, start []byte) kaidb.Iterator {
+	if db.failingIter {
+		return failingIterator{}
+	}
+	return db.Database.NewIterator(prefix, start)
+}
+
+// failingIterator is an iterator whose first step fails.
+type failingIterator struct{}
+
+func (failingIterator) Next() bool    { return false }
+func (failingIterator) Error() error  { return errTestRead }
+func (failingIterator) Key() []byte   { return nil }
+func (failingIterator) Value() []byte { return nil }
+func (failingIterator) Release()      {}
+
+func (db *failingDB) Get(key []byte) ([]byte, error) {
+	if db.failing {
+		return nil, errTestRead
+	}
+	return db.Database.Get(key)
+}
+
+func (db *failingDB) Has(key []byte) (bool, error) {
+	if db.failing {
+		return false, errTestRead
+	}
+	return db.Database.Has(key)
+}
+
+func TestHeaderChainCurrentHeaderFallback(t *testing.T) {
+	hc, _ := newTestHeaderChain(t, 3)
+	assert.EqualValues(t, 3, hc.CurrentHeader().Height)
+
+	hc.currentHeader = atomic.Value{}
+	require.NotPanics(t, func() { hc.CurrentHeader() })
+	assert.Equal(t, hc.genesisHeader, hc.CurrentHeader())
+	assert.EqualValues(t, 0, hc.CurrentHeader().Height)
+}
+
+func TestHeaderChainReadErrors(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 5)
+	failing := &failingDB{Database: db}
+	hc.db = failing
+	hc.headerCache.Purge()
+	hc.heightCache.Purge()
+	hash := rawdb.ReadCanonicalHash(db, 3)
+
+	// Missing entries are not errors.
+	header, err := hc.GetHeaderByHeightErr(10)
+	assert.NoError(t, err)
+	assert.Nil(t, header)
+	height, err := hc.GetBlockHeightErr(common.Hash{0x01})
+	assert.NoError(t, err)
+	assert.Nil(t, height)
+
+	// Failed reads are.
+	failing.failing = true
+	header, err = hc.GetHeaderErr(hash, 3)
+	assert.True(t, errors.Is(err, errTestRead))
+	assert.Nil(t, header)
+	header, err = hc.GetHeaderByHeightErr(3)
+	assert.True(t, errors.Is(err, errTestRead))
+	assert.Nil(t, header)
+	height, err = hc.GetBlockHeightErr(hash)
+	assert.True(t, errors.Is(err, errTestRead))
+	assert.Nil(t, height)
+	assert.Nil(t, hc.GetHeaderByHeight(3))
+
+	// A read error is not mistaken for a missing genesis.
+	_, err = NewHeaderChain(failing, configs.TestChainConfig)
+	assert.True(t, errors.Is(err, errTestRead))
+
+	failing.failing = false
+	header, err = hc.GetHeaderByHeightErr(3)
+	require.NoError(t, err)
+	assert.Equal(t, hash, header.Hash())
+}
+
+func TestHeaderChainPruneHeaders(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 20)
+	genesis := hc.GetHeaderByHeight(0)
+	head := hc.CurrentHeader()
+	prunedHash := rawdb.ReadCanonicalHash(db, 5)
+	require.NotNil(t, hc.GetHeaderByHash(prunedHash))
+
+	assert.Error(t, hc.PruneHeaders(head.Height+1, nil))
+
+	var deleted []uint64
+	require.NoError(t, hc.PruneHeaders(10, func(_ kaidb.Database, height uint64) {
+		deleted = append(deleted, height)
+	}))
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9}, deleted)
+
+	for height := uint64(1); height < 10; height++ {
+		assert.Nil(t, hc.GetHeaderByHeight(height), "height %d", height)
+		assert.Nil(t, rawdb.ReadHeader(db, height), "height %d", height)
+	}
+	assert.Nil(t, hc.GetHeaderByHash(prunedHash))
+	assert.Nil(t, rawdb.ReadHeaderHeight(db, prunedHash))
+
+	// Genesis, the headers from the cutoff and the head are kept.
+	assert.Equal(t, genesis.Hash(), hc.GetHeaderByHeight(0).Hash())
+	for height := uint64(10); height <= head.Height; height++ {
+		assert.NotNil(t, hc.GetHeaderByHeight(height), "height %d", height)
+	}
+	assert.Equal(t, head.Hash(), hc.CurrentHeader().Hash())
+	assert.NoError(t, hc.VerifyParentLinks(10, head.Height))
+
+	// Pruning again only deletes what is left.
+	deleted = nil
+	require.NoError(t, hc.PruneHeaders(head.Height, func(_ kaidb.Database, height uint64) {
+		deleted = append(deleted, height)
+	}))
+	assert.Len(t, deleted, int(head.Height-10))
+	assert.Equal(t, head.Hash(), hc.GetHeaderByHeight(head.Height).Hash())
+}
+
+func TestHeaderChainGetHeaderByHeightCached(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 10)
+	counter := &countingDB{Database: db}
+	hc.db = counter
+	hc.headerCache.Purge()
+	hc.canonicalCache.Purge()
+
+	hash := rawdb.ReadCanonicalHash(db, 5)
+	require.Equal(t, hash, hc.GetHeaderByHeight(5).Hash())
+	reads := counter.reads
+	assert.NotZero(t, reads)
+	for i := 0; i < 3; i++ {
+		require.Equal(t, hash, hc.GetHeaderByHeight(5).Hash())
+	}
+	assert.Equal(t, reads, counter.reads)
+
+	// Missing heights are not cached.
+	assert.Nil(t, hc.GetHeaderByHeight(20))
+	reads = counter.reads
+	assert.Nil(t, hc.GetHeaderByHeight(20))
+	assert.Greater(t, counter.reads, reads)
+
+	// Rewinding drops the cached mappings.
+	hc.SetHead(4, nil)
+	assert.Nil(t, hc.GetHeaderByHeight(5))
+}
+
+func TestHeaderChainSetGenesis(t *testing.T) {
+	hc, db := newTestHeaderChain(t, 3)
+	genesis := hc.genesisHeader
+
+	// A genesis other than the stored one is refused.
+	other := &types.Header{Height: 0, Time: genesis.Time.Add(time.Second)}
+	assert.True(t, errors.Is(hc.SetGenesis(other), ErrGenesisMismatch))
+	assert.True(t, errors.Is(hc.SetGenesis(hc.GetHeaderByHeight(1)), ErrGenesisMismatch))
+	assert.True(t, errors.Is(hc.SetGenesis(nil), ErrGenesisMismatch))
+	assert.Same(t, genesis, hc.genesisHeader)
+
+	// The stored genesis is accepted.
+	stored := rawdb.ReadHeader(db, 0)
+	require.NoError(t, hc.SetGenesis(stored))
+	assert.Same(t, stored, hc.genesisHeader)
+
+	// Once written to the database, a new genesis is accepted as well.
+	hc.GetHeaderByHeight(0)
+	written := writeTestBlock(db, other).Header()
+	require.NoError(t, hc.SetGenesis(written))
+	assert.Equal(t, written.Hash(), hc.GetHeaderByHeight(0).Hash())
+}
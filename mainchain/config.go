@@ -31,18 +31,19 @@ import (
 
 // Defaults contains default settings for use on the Kardia main net.
 var Defaults = Config{
-	NetworkId:               24,
-	TxLookupLimit:           2350000,
-	DatabaseCache:           512,
-	TrieCleanCache:          154,
-	TrieCleanCacheJournal:   "triecache",
-	TrieCleanCacheRejournal: 60 * time.Minute,
-	TrieDirtyCache:          256,
-	TrieTimeout:             60 * time.Minute,
-	SnapshotCache:           102,
-	TxPool:                  tx_pool.DefaultTxPoolConfig,
-	AcceptTxs:               true,
-	GasOracle:               oracles.DefaultOracleConfig(),
+	NetworkId:                24,
+	TxLookupLimit:            2350000,
+	DatabaseCache:            512,
+	TrieCleanCache:           154,
+	TrieCleanCacheJournal:    "triecache",
+	TrieCleanCacheRejournal:  60 * time.Minute,
+	TrieDirtyCache:           256,
+	TrieTimeout:              60 * time.Minute,
+	SnapshotCache:            102,
+	TxPool:                   tx_pool.DefaultTxPoolConfig,
+	AcceptTxs:                true,
+	StrictEvidenceValidation: true,
+	GasOracle:                oracles.DefaultOracleConfig(),
 }
 
 //go:generate gencodec -type Config -field-override configMarshaling -formats toml -out gen_config.go
@@ -89,5 +90,25 @@ type Config struct {
 
 	FastSync *configs.FastSyncConfig `toml:",omitempty"`
 
+	// Instrumentation enables Prometheus metrics for the consensus manager,
+	// none are collected if nil.
+	Instrumentation *configs.InstrumentationConfig `toml:",omitempty"`
+
+	// EvidenceGossipBudget caps the evidence bytes gossiped to each peer per
+	// second, 0 for no limit.
+	EvidenceGossipBudget int `toml:",omitempty"`
+
+	// EvidenceBroadcastLimit caps the evidence sent to each peer per broadcast
+	// interval, 0 for the reactor default.
+	EvidenceBroadcastLimit int `toml:",omitempty"`
+
+	// EvidenceGossipFanout caps the peers each evidence is pushed to, 0 for
+	// every peer.
+	EvidenceGossipFanout int `toml:",omitempty"`
+
+	// StrictEvidenceValidation stops peers sending invalid evidence instead of
+	// only logging and dropping it.
+	StrictEvidenceValidation bool
+
 	GasOracle *oracles.Config `toml:",omitempty"`
 }
@@ -19,6 +19,7 @@
 package tx_pool
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -52,7 +53,7 @@ func decodeMsg(bz []byte) (interface{}, error) {
 		decoded := make([]*types.Transaction, len(txs))
 		for j, txBytes := range txs {
 			tx := &types.Transaction{}
-			if err := rlp.DecodeBytes(txBytes, tx); err != nil {
+			if err := rlp.DecodeStrict(bytes.NewReader(txBytes), tx); err != nil {
 				return message, err
 			}
 
@@ -83,7 +84,7 @@ func decodeMsg(bz []byte) (interface{}, error) {
 		pooledTransactions := make(PooledTransactions, len(txs))
 		for j, txBytes := range txs {
 			tx := &types.Transaction{}
-			if err := rlp.DecodeBytes(txBytes, tx); err != nil {
+			if err := rlp.DecodeStrict(bytes.NewReader(txBytes), tx); err != nil {
 				return message, err
 			}
 
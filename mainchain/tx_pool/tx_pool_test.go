@@ -37,6 +37,8 @@ import (
 	"github.com/kardiachain/go-kardia/lib/crypto"
 	"github.com/kardiachain/go-kardia/lib/event"
 	krand "github.com/kardiachain/go-kardia/lib/rand"
+	"github.com/kardiachain/go-kardia/lib/rlp"
+	prototx "github.com/kardiachain/go-kardia/proto/kardiachain/txpool"
 	"github.com/kardiachain/go-kardia/trie"
 	"github.com/kardiachain/go-kardia/types"
 )
@@ -2027,3 +2029,27 @@ func BenchmarkInsertRemoteWithAllLocals(b *testing.B) {
 		pool.Stop()
 	}
 }
+
+// Tests that transactions received from peers are rejected if their encoding
+// is followed by trailing bytes.
+func TestDecodeMsgTrailingTxBytes(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	txBytes, err := rlp.EncodeToBytes(transaction(0, 100000, key))
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	encode := func(txBytes []byte) []byte {
+		msg := prototx.Message{Sum: &prototx.Message_Txs{Txs: &prototx.Txs{Txs: [][]byte{txBytes}}}}
+		bz, err := msg.Marshal()
+		if err != nil {
+			t.Fatalf("failed to encode message: %v", err)
+		}
+		return bz
+	}
+	if _, err := decodeMsg(encode(txBytes)); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+	if _, err := decodeMsg(encode(append(txBytes, 0x80))); err != rlp.ErrMoreThanOneValue {
+		t.Fatalf("trailing bytes error mismatch: have %v, want %v", err, rlp.ErrMoreThanOneValue)
+	}
+}
@@ -580,7 +580,7 @@ func makeNodeInfo(
 		Version:       nodeVersion,
 		Channels: []byte{
 			cs.StateChannel, cs.DataChannel, cs.VoteChannel, cs.VoteSetBitsChannel,
-			evidence.EvidenceChannel, tx_pool.TxpoolChannel,
+			evidence.EvidenceChannel, evidence.EvidenceRequestChannel, tx_pool.TxpoolChannel,
 		},
 		Moniker: config.Name,
 		Other: p2p.DefaultNodeInfoOther{
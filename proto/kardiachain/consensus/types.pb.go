@@ -28,11 +28,13 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 // NewRoundStep is sent for every step taken in the ConsensusState.
 // For every height/round/step transition
 type NewRoundStep struct {
-	Height                uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
-	Round                 uint32 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
-	Step                  uint32 `protobuf:"varint,3,opt,name=step,proto3" json:"step,omitempty"`
-	SecondsSinceStartTime uint64 `protobuf:"varint,4,opt,name=seconds_since_start_time,json=secondsSinceStartTime,proto3" json:"seconds_since_start_time,omitempty"`
-	LastCommitRound       uint32 `protobuf:"varint,5,opt,name=last_commit_round,json=lastCommitRound,proto3" json:"last_commit_round,omitempty"`
+	Height                uint64        `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Round                 uint32        `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	Step                  uint32        `protobuf:"varint,3,opt,name=step,proto3" json:"step,omitempty"`
+	SecondsSinceStartTime uint64        `protobuf:"varint,4,opt,name=seconds_since_start_time,json=secondsSinceStartTime,proto3" json:"seconds_since_start_time,omitempty"`
+	LastCommitRound       uint32        `protobuf:"varint,5,opt,name=last_commit_round,json=lastCommitRound,proto3" json:"last_commit_round,omitempty"`
+	StartTime             int64         `protobuf:"varint,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	CommitHeader          *types.Header `protobuf:"bytes,7,opt,name=commit_header,json=commitHeader,proto3" json:"commit_header,omitempty"`
 }
 
 func (m *NewRoundStep) Reset()         { *m = NewRoundStep{} }
@@ -103,6 +105,20 @@ func (m *NewRoundStep) GetLastCommitRound() uint32 {
 	return 0
 }
 
+func (m *NewRoundStep) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *NewRoundStep) GetCommitHeader() *types.Header {
+	if m != nil {
+		return m.CommitHeader
+	}
+	return nil
+}
+
 // NewValidBlock is sent when a validator observes a valid block B in some round r,
 // i.e., there is a Proposal for block B and 2/3+ prevotes for the block B in the round r.
 // In case the block is also committed, then IsCommit flag is set to true.
@@ -609,6 +625,53 @@ func (m *VoteSetBits) GetVotes() bits.BitArray {
 	return bits.BitArray{}
 }
 
+// NewRoundStepRequest asks a peer to send its current NewRoundStep.
+type NewRoundStepRequest struct {
+	// commit_headers asks the peer to attach the header of the block being
+	// committed to the NewRoundStep messages it sends us.
+	CommitHeaders bool `protobuf:"varint,1,opt,name=commit_headers,json=commitHeaders,proto3" json:"commit_headers,omitempty"`
+}
+
+func (m *NewRoundStepRequest) Reset()         { *m = NewRoundStepRequest{} }
+func (m *NewRoundStepRequest) String() string { return proto.CompactTextString(m) }
+func (*NewRoundStepRequest) ProtoMessage()    {}
+func (*NewRoundStepRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f187ebe8a20aa92, []int{9}
+}
+func (m *NewRoundStepRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NewRoundStepRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NewRoundStepRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *NewRoundStepRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NewRoundStepRequest.Merge(m, src)
+}
+func (m *NewRoundStepRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *NewRoundStepRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NewRoundStepRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NewRoundStepRequest proto.InternalMessageInfo
+
+func (m *NewRoundStepRequest) GetCommitHeaders() bool {
+	if m != nil {
+		return m.CommitHeaders
+	}
+	return false
+}
+
 type Message struct {
 	// Types that are valid to be assigned to Sum:
 	//	*Message_NewRoundStep
@@ -620,6 +683,7 @@ type Message struct {
 	//	*Message_HasVote
 	//	*Message_VoteSetMaj23
 	//	*Message_VoteSetBits
+	//	*Message_NewRoundStepRequest
 	Sum isMessage_Sum `protobuf_oneof:"sum"`
 }
 
@@ -627,7 +691,7 @@ func (m *Message) Reset()         { *m = Message{} }
 func (m *Message) String() string { return proto.CompactTextString(m) }
 func (*Message) ProtoMessage()    {}
 func (*Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8f187ebe8a20aa92, []int{9}
+	return fileDescriptor_8f187ebe8a20aa92, []int{10}
 }
 func (m *Message) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -689,16 +753,20 @@ type Message_VoteSetMaj23 struct {
 type Message_VoteSetBits struct {
 	VoteSetBits *VoteSetBits `protobuf:"bytes,9,opt,name=vote_set_bits,json=voteSetBits,proto3,oneof" json:"vote_set_bits,omitempty"`
 }
+type Message_NewRoundStepRequest struct {
+	NewRoundStepRequest *NewRoundStepRequest `protobuf:"bytes,10,opt,name=new_round_step_request,json=newRoundStepRequest,proto3,oneof" json:"new_round_step_request,omitempty"`
+}
 
-func (*Message_NewRoundStep) isMessage_Sum()  {}
-func (*Message_NewValidBlock) isMessage_Sum() {}
-func (*Message_Proposal) isMessage_Sum()      {}
-func (*Message_ProposalPol) isMessage_Sum()   {}
-func (*Message_BlockPart) isMessage_Sum()     {}
-func (*Message_Vote) isMessage_Sum()          {}
-func (*Message_HasVote) isMessage_Sum()       {}
-func (*Message_VoteSetMaj23) isMessage_Sum()  {}
-func (*Message_VoteSetBits) isMessage_Sum()   {}
+func (*Message_NewRoundStep) isMessage_Sum()        {}
+func (*Message_NewValidBlock) isMessage_Sum()       {}
+func (*Message_Proposal) isMessage_Sum()            {}
+func (*Message_ProposalPol) isMessage_Sum()         {}
+func (*Message_BlockPart) isMessage_Sum()           {}
+func (*Message_Vote) isMessage_Sum()                {}
+func (*Message_HasVote) isMessage_Sum()             {}
+func (*Message_VoteSetMaj23) isMessage_Sum()        {}
+func (*Message_VoteSetBits) isMessage_Sum()         {}
+func (*Message_NewRoundStepRequest) isMessage_Sum() {}
 
 func (m *Message) GetSum() isMessage_Sum {
 	if m != nil {
@@ -770,6 +838,13 @@ func (m *Message) GetVoteSetBits() *VoteSetBits {
 	return nil
 }
 
+func (m *Message) GetNewRoundStepRequest() *NewRoundStepRequest {
+	if x, ok := m.GetSum().(*Message_NewRoundStepRequest); ok {
+		return x.NewRoundStepRequest
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Message) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -782,6 +857,7 @@ func (*Message) XXX_OneofWrappers() []interface{} {
 		(*Message_HasVote)(nil),
 		(*Message_VoteSetMaj23)(nil),
 		(*Message_VoteSetBits)(nil),
+		(*Message_NewRoundStepRequest)(nil),
 	}
 }
 
@@ -795,67 +871,73 @@ func init() {
 	proto.RegisterType((*HasVote)(nil), "kardiachain.consensus.HasVote")
 	proto.RegisterType((*VoteSetMaj23)(nil), "kardiachain.consensus.VoteSetMaj23")
 	proto.RegisterType((*VoteSetBits)(nil), "kardiachain.consensus.VoteSetBits")
+	proto.RegisterType((*NewRoundStepRequest)(nil), "kardiachain.consensus.NewRoundStepRequest")
 	proto.RegisterType((*Message)(nil), "kardiachain.consensus.Message")
 }
 
 func init() { proto.RegisterFile("kardiachain/consensus/types.proto", fileDescriptor_8f187ebe8a20aa92) }
 
 var fileDescriptor_8f187ebe8a20aa92 = []byte{
-	// 855 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x56, 0x4f, 0x6f, 0xe3, 0x44,
-	0x14, 0xb7, 0x59, 0xb7, 0x49, 0x9f, 0x9b, 0x16, 0x46, 0x5b, 0x88, 0xba, 0x22, 0x2d, 0x86, 0xc3,
-	0x8a, 0x3f, 0x8e, 0x48, 0x91, 0x38, 0x2c, 0x48, 0xac, 0x41, 0xe0, 0x6a, 0xb7, 0xdd, 0xc8, 0x59,
-	0x55, 0x82, 0x8b, 0xe5, 0xc4, 0x23, 0x67, 0x58, 0xc7, 0x63, 0x79, 0x26, 0x29, 0x3d, 0xf3, 0x05,
-	0xf8, 0x02, 0x7c, 0x0c, 0x2e, 0x7c, 0x82, 0x3d, 0xee, 0x91, 0xd3, 0x0a, 0xa5, 0xdf, 0x01, 0x8e,
-	0xa0, 0x79, 0x9e, 0x24, 0x0e, 0x72, 0xb6, 0xf4, 0x82, 0xc4, 0xcd, 0x33, 0xef, 0xbd, 0xdf, 0xbc,
-	0xf9, 0xbd, 0xf7, 0x7e, 0x1e, 0x78, 0xe7, 0x59, 0x54, 0xc4, 0x2c, 0x1a, 0x8d, 0x23, 0x96, 0x75,
-	0x47, 0x3c, 0x13, 0x34, 0x13, 0x53, 0xd1, 0x95, 0x57, 0x39, 0x15, 0x6e, 0x5e, 0x70, 0xc9, 0xc9,
-	0x41, 0xc5, 0xc5, 0x5d, 0xba, 0x1c, 0xde, 0x4d, 0x78, 0xc2, 0xd1, 0xa3, 0xab, 0xbe, 0x4a, 0xe7,
-	0xc3, 0xb7, 0xab, 0x78, 0x88, 0x52, 0xc5, 0x3a, 0x5c, 0x3b, 0x2e, 0x65, 0x43, 0xd1, 0x1d, 0x32,
-	0xb9, 0xe6, 0xe2, 0xfc, 0x62, 0xc2, 0xee, 0x39, 0xbd, 0x0c, 0xf8, 0x34, 0x8b, 0x07, 0x92, 0xe6,
-	0xe4, 0x4d, 0xd8, 0x1e, 0x53, 0x96, 0x8c, 0x65, 0xdb, 0x3c, 0x36, 0xef, 0x5b, 0x81, 0x5e, 0x91,
-	0xbb, 0xb0, 0x55, 0x28, 0xa7, 0xf6, 0x6b, 0xc7, 0xe6, 0xfd, 0x56, 0x50, 0x2e, 0x08, 0x01, 0x4b,
-	0x48, 0x9a, 0xb7, 0xef, 0xe0, 0x26, 0x7e, 0x93, 0x4f, 0xa1, 0x2d, 0xe8, 0x88, 0x67, 0xb1, 0x08,
-	0x05, 0xcb, 0x46, 0x34, 0x14, 0x32, 0x2a, 0x64, 0x28, 0xd9, 0x84, 0xb6, 0x2d, 0xc4, 0x3c, 0xd0,
-	0xf6, 0x81, 0x32, 0x0f, 0x94, 0xf5, 0x29, 0x9b, 0x50, 0xf2, 0x3e, 0xbc, 0x91, 0x46, 0x42, 0x86,
-	0x23, 0x3e, 0x99, 0x30, 0x19, 0x96, 0xc7, 0x6d, 0x21, 0xf2, 0xbe, 0x32, 0x7c, 0x89, 0xfb, 0x98,
-	0xaa, 0xf3, 0xa7, 0x09, 0xad, 0x73, 0x7a, 0x79, 0x11, 0xa5, 0x2c, 0xf6, 0x52, 0x3e, 0x7a, 0x76,
-	0xcb, 0xc4, 0xbf, 0x85, 0x83, 0xa1, 0x0a, 0x0b, 0x73, 0x95, 0x9b, 0xa0, 0x32, 0x1c, 0xd3, 0x28,
-	0xa6, 0x05, 0xde, 0xc4, 0xee, 0x1d, 0xbb, 0xd5, 0x32, 0x94, 0x84, 0xf5, 0xa3, 0x42, 0x0e, 0xa8,
-	0xf4, 0xd1, 0xcf, 0xb3, 0x9e, 0xbf, 0x3c, 0x32, 0x02, 0x82, 0x20, 0x6b, 0x16, 0xf2, 0x05, 0xd8,
-	0x2b, 0x68, 0x81, 0x57, 0xb6, 0x7b, 0x47, 0x6b, 0x80, 0xaa, 0x16, 0xae, 0xaa, 0x85, 0xeb, 0x31,
-	0xf9, 0xb0, 0x28, 0xa2, 0xab, 0x00, 0x96, 0x48, 0x82, 0xdc, 0x83, 0x1d, 0x26, 0x34, 0x0d, 0x48,
-	0x40, 0x33, 0x68, 0x32, 0x51, 0x5e, 0xdf, 0x39, 0x85, 0x66, 0xbf, 0xe0, 0x39, 0x17, 0x51, 0x4a,
-	0x3e, 0x87, 0x66, 0xae, 0xbf, 0xf1, 0xd6, 0x76, 0xef, 0x5e, 0x5d, 0xe2, 0xda, 0x45, 0xe7, 0xbc,
-	0x0c, 0x71, 0x7e, 0x36, 0xc1, 0x5e, 0x18, 0xfb, 0x4f, 0x1e, 0x6f, 0xa4, 0xf0, 0x43, 0x20, 0x8b,
-	0x98, 0x30, 0xe7, 0x69, 0x58, 0xe5, 0xf3, 0xf5, 0x85, 0xa5, 0xcf, 0x53, 0x2c, 0x0d, 0xf1, 0x61,
-	0xb7, 0xea, 0xad, 0x19, 0xbd, 0x89, 0x00, 0x9d, 0x9c, 0x5d, 0x81, 0x73, 0x52, 0xd8, 0xf1, 0x16,
-	0xac, 0xdc, 0xb2, 0xbe, 0x1f, 0x83, 0xa5, 0xe8, 0xd7, 0x87, 0xbf, 0xb5, 0xa1, 0x9c, 0xfa, 0x50,
-	0x74, 0x75, 0x4e, 0xc0, 0xba, 0xe0, 0x92, 0x92, 0x0f, 0xc0, 0x9a, 0x71, 0x49, 0x35, 0xa1, 0x75,
-	0xa1, 0xca, 0x2d, 0x40, 0x27, 0xe7, 0x47, 0x13, 0x1a, 0x7e, 0x24, 0x30, 0xf0, 0x76, 0x19, 0x7e,
-	0x02, 0x96, 0x42, 0xc3, 0x0c, 0xf7, 0x6a, 0x1b, 0x6e, 0xc0, 0x92, 0x8c, 0xc6, 0x67, 0x22, 0x79,
-	0x7a, 0x95, 0xd3, 0x00, 0xbd, 0x15, 0x16, 0xcb, 0x62, 0xfa, 0x03, 0xb6, 0x55, 0x2b, 0x28, 0x17,
-	0xce, 0xaf, 0x26, 0xec, 0xaa, 0x14, 0x06, 0x54, 0x9e, 0x45, 0xdf, 0xf7, 0x4e, 0xfe, 0x93, 0x54,
-	0xbe, 0x86, 0x66, 0xd9, 0xe7, 0x2c, 0xd6, 0x4d, 0x7e, 0x58, 0x13, 0x89, 0x05, 0x3c, 0xfd, 0xca,
-	0xdb, 0x57, 0x4c, 0xcf, 0x5f, 0x1e, 0x35, 0xf4, 0x46, 0xd0, 0xc0, 0xe0, 0xd3, 0xd8, 0xf9, 0xc3,
-	0x04, 0x5b, 0x27, 0xef, 0x31, 0x29, 0xfe, 0x4f, 0xb9, 0x93, 0x07, 0xb0, 0xa5, 0xda, 0x40, 0xe0,
-	0x94, 0xfe, 0xeb, 0x26, 0x2f, 0x63, 0x9c, 0xbf, 0x2c, 0x68, 0x9c, 0x51, 0x21, 0xa2, 0x84, 0x92,
-	0x47, 0xb0, 0x97, 0xd1, 0xcb, 0x72, 0xb2, 0x42, 0x94, 0xd4, 0xb2, 0xfd, 0xde, 0x75, 0x6b, 0xff,
-	0x07, 0x6e, 0x55, 0xb3, 0x7d, 0x23, 0xd8, 0xcd, 0xaa, 0x1a, 0x7e, 0x0e, 0xfb, 0x0a, 0x6c, 0xa6,
-	0xc4, 0x31, 0xc4, 0x54, 0x91, 0x33, 0xbb, 0xf7, 0xde, 0x66, 0xb4, 0x95, 0x92, 0xfa, 0x46, 0xd0,
-	0xca, 0xd6, 0xa4, 0xb5, 0x2a, 0x33, 0x75, 0xd3, 0xbc, 0x02, 0x5a, 0xa8, 0x89, 0x5f, 0x91, 0x19,
-	0xf2, 0xcd, 0x3f, 0x04, 0xa1, 0x24, 0xdc, 0xb9, 0x01, 0xa2, 0xff, 0xe4, 0xb1, 0xbf, 0xae, 0x07,
-	0xe4, 0x21, 0xc0, 0x4a, 0x59, 0x35, 0xe5, 0xc7, 0x1b, 0x60, 0x96, 0xc2, 0xe1, 0x1b, 0xc1, 0xce,
-	0x52, 0x5b, 0x95, 0x2e, 0xe0, 0x70, 0x6f, 0xd7, 0xa8, 0xe5, 0x2a, 0x58, 0xb5, 0xa3, 0x6f, 0x94,
-	0x23, 0x4e, 0x1e, 0x40, 0x73, 0x1c, 0x89, 0x10, 0xc3, 0x1a, 0x18, 0xd6, 0xd9, 0x10, 0xa6, 0x85,
-	0xc0, 0x37, 0x82, 0xc6, 0x58, 0x6b, 0xc2, 0x23, 0xd8, 0x53, 0x81, 0xf8, 0x87, 0x99, 0xa8, 0xd1,
-	0x6c, 0x37, 0x5f, 0x59, 0xd7, 0xea, 0x14, 0xab, 0xba, 0xce, 0xaa, 0x53, 0xed, 0x43, 0x6b, 0x09,
-	0xa6, 0xfa, 0xaa, 0xbd, 0xf3, 0x4a, 0x26, 0x2b, 0x43, 0xa5, 0x98, 0x9c, 0xad, 0x96, 0xde, 0x16,
-	0xdc, 0x11, 0xd3, 0x89, 0x77, 0xf1, 0x7c, 0xde, 0x31, 0x5f, 0xcc, 0x3b, 0xe6, 0xef, 0xf3, 0x8e,
-	0xf9, 0xd3, 0x75, 0xc7, 0x78, 0x71, 0xdd, 0x31, 0x7e, 0xbb, 0xee, 0x18, 0xdf, 0x7d, 0x96, 0x30,
-	0x39, 0x9e, 0x0e, 0xdd, 0x11, 0x9f, 0x74, 0xab, 0xaf, 0x88, 0x84, 0x7f, 0x54, 0x2e, 0xbb, 0xe5,
-	0x63, 0xa4, 0xf6, 0x41, 0x33, 0xdc, 0x46, 0xe3, 0xc9, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x98,
-	0x63, 0xf3, 0xc0, 0xf0, 0x08, 0x00, 0x00,
+	// 929 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x56, 0x4f, 0x6f, 0x1b, 0x45,
+	0x14, 0xdf, 0xad, 0xed, 0xd8, 0x7e, 0xb6, 0x13, 0x98, 0x36, 0x65, 0x49, 0x55, 0xc7, 0x2c, 0x20,
+	0x45, 0x05, 0x6c, 0xe1, 0x20, 0x71, 0x68, 0x41, 0xd4, 0x20, 0xd8, 0x88, 0x26, 0xb5, 0xc6, 0x55,
+	0x24, 0xb8, 0xac, 0xd6, 0xde, 0x91, 0x3d, 0xd4, 0xde, 0x59, 0x76, 0xc6, 0x09, 0x39, 0xf3, 0x05,
+	0x38, 0x70, 0xe5, 0x8b, 0xf0, 0x09, 0x7a, 0xec, 0x91, 0x53, 0x85, 0x9c, 0xef, 0x00, 0x57, 0x34,
+	0x7f, 0x6c, 0x8f, 0xc1, 0x2e, 0xf5, 0x05, 0x89, 0xdb, 0xcc, 0xbc, 0xf7, 0x7e, 0xf3, 0xdb, 0xdf,
+	0x7b, 0xfb, 0xdb, 0x85, 0xb7, 0x9e, 0x46, 0x59, 0x4c, 0xa3, 0xc1, 0x28, 0xa2, 0x49, 0x6b, 0xc0,
+	0x12, 0x4e, 0x12, 0x3e, 0xe5, 0x2d, 0x71, 0x95, 0x12, 0xde, 0x4c, 0x33, 0x26, 0x18, 0xda, 0xb7,
+	0x52, 0x9a, 0x8b, 0x94, 0x83, 0x5b, 0x43, 0x36, 0x64, 0x2a, 0xa3, 0x25, 0x57, 0x3a, 0xf9, 0xe0,
+	0xae, 0x8d, 0xa7, 0x50, 0x6c, 0xac, 0x83, 0x95, 0xeb, 0xc6, 0xb4, 0xcf, 0x5b, 0x7d, 0x2a, 0x56,
+	0x52, 0xfc, 0x9f, 0x6f, 0x40, 0xf5, 0x8c, 0x5c, 0x62, 0x36, 0x4d, 0xe2, 0x9e, 0x20, 0x29, 0xba,
+	0x0d, 0x3b, 0x23, 0x42, 0x87, 0x23, 0xe1, 0xb9, 0x0d, 0xf7, 0x28, 0x8f, 0xcd, 0x0e, 0xdd, 0x82,
+	0x42, 0x26, 0x93, 0xbc, 0x1b, 0x0d, 0xf7, 0xa8, 0x86, 0xf5, 0x06, 0x21, 0xc8, 0x73, 0x41, 0x52,
+	0x2f, 0xa7, 0x0e, 0xd5, 0x1a, 0x7d, 0x0c, 0x1e, 0x27, 0x03, 0x96, 0xc4, 0x3c, 0xe4, 0x34, 0x19,
+	0x90, 0x90, 0x8b, 0x28, 0x13, 0xa1, 0xa0, 0x13, 0xe2, 0xe5, 0x15, 0xe6, 0xbe, 0x89, 0xf7, 0x64,
+	0xb8, 0x27, 0xa3, 0x4f, 0xe8, 0x84, 0xa0, 0x7b, 0xf0, 0xfa, 0x38, 0xe2, 0x22, 0x1c, 0xb0, 0xc9,
+	0x84, 0x8a, 0x50, 0x5f, 0x57, 0x50, 0xc8, 0x7b, 0x32, 0xf0, 0xb9, 0x3a, 0x57, 0x54, 0xd1, 0x5d,
+	0x00, 0x0b, 0x76, 0xa7, 0xe1, 0x1e, 0xe5, 0x70, 0x99, 0x2f, 0xa0, 0x3e, 0x85, 0x9a, 0x41, 0x19,
+	0x91, 0x28, 0x26, 0x99, 0x57, 0x6c, 0xb8, 0x47, 0x95, 0xf6, 0x9b, 0x4d, 0x5b, 0x5d, 0xad, 0x43,
+	0xa0, 0x12, 0x70, 0x55, 0xe7, 0xeb, 0x9d, 0xff, 0xa7, 0x0b, 0xb5, 0x33, 0x72, 0x79, 0x1e, 0x8d,
+	0x69, 0xdc, 0x19, 0xb3, 0xc1, 0xd3, 0x2d, 0x75, 0xf9, 0x06, 0xf6, 0xfb, 0xb2, 0x2c, 0x4c, 0x25,
+	0x47, 0x4e, 0x16, 0x3c, 0x72, 0x8a, 0x47, 0x63, 0x0d, 0x8f, 0x6e, 0x94, 0x89, 0x1e, 0x31, 0x04,
+	0x3a, 0xf9, 0x67, 0x2f, 0x0e, 0x1d, 0x8c, 0x14, 0xc8, 0x4a, 0x04, 0x7d, 0x06, 0x95, 0x25, 0x34,
+	0x57, 0x8a, 0x56, 0xda, 0x87, 0x2b, 0x80, 0xb2, 0xd5, 0x4d, 0xd9, 0xea, 0x66, 0x87, 0x8a, 0x87,
+	0x59, 0x16, 0x5d, 0x61, 0x58, 0x20, 0x71, 0x74, 0x07, 0xca, 0x94, 0x1b, 0x95, 0x95, 0xbe, 0x25,
+	0x5c, 0xa2, 0x5c, 0xab, 0xeb, 0x9f, 0x40, 0xa9, 0x9b, 0xb1, 0x94, 0xf1, 0x68, 0x8c, 0x3e, 0x81,
+	0x52, 0x6a, 0xd6, 0xea, 0xa9, 0x2b, 0xed, 0x3b, 0xeb, 0x88, 0x9b, 0x14, 0xc3, 0x79, 0x51, 0xe2,
+	0xff, 0xe2, 0x42, 0x65, 0x1e, 0xec, 0x3e, 0x7e, 0xb4, 0x51, 0xc2, 0xf7, 0x01, 0xcd, 0x6b, 0xc2,
+	0x94, 0x8d, 0x43, 0x5b, 0xcf, 0xd7, 0xe6, 0x91, 0x2e, 0x1b, 0xeb, 0xce, 0x07, 0x50, 0xb5, 0xb3,
+	0xbd, 0xdc, 0x2b, 0x09, 0x60, 0xc8, 0x55, 0x2c, 0x38, 0x7f, 0x0c, 0xe5, 0xce, 0x5c, 0x95, 0x2d,
+	0xfb, 0xfb, 0x21, 0xe4, 0xa5, 0xfc, 0xe6, 0xf2, 0x37, 0x36, 0xb4, 0xd3, 0x5c, 0xaa, 0x52, 0xfd,
+	0x63, 0xc8, 0x9f, 0x33, 0x41, 0xd0, 0x7b, 0x90, 0xbf, 0x60, 0x82, 0x78, 0xee, 0xc6, 0x52, 0x99,
+	0x86, 0x55, 0x92, 0xff, 0xa3, 0x0b, 0xc5, 0x20, 0xe2, 0xaa, 0x70, 0x3b, 0x86, 0x1f, 0x41, 0x5e,
+	0xa2, 0x29, 0x86, 0xbb, 0x6b, 0x07, 0xae, 0x47, 0x87, 0x09, 0x89, 0x4f, 0xf9, 0xf0, 0xc9, 0x55,
+	0x4a, 0xb0, 0xca, 0x96, 0x58, 0x34, 0x89, 0xc9, 0x0f, 0x6a, 0xac, 0x6a, 0x58, 0x6f, 0xfc, 0x5f,
+	0x5d, 0xa8, 0x4a, 0x0a, 0x3d, 0x22, 0x4e, 0xa3, 0xef, 0xda, 0xc7, 0xff, 0x09, 0x95, 0x2f, 0xa1,
+	0xa4, 0xe7, 0x9c, 0xc6, 0x66, 0xc8, 0x0f, 0xd6, 0x54, 0xaa, 0x06, 0x9e, 0x7c, 0xd1, 0xd9, 0x93,
+	0x4a, 0xcf, 0x5e, 0x1c, 0x16, 0xcd, 0x01, 0x2e, 0xaa, 0xe2, 0x93, 0xd8, 0xff, 0xc3, 0x85, 0x8a,
+	0x21, 0xdf, 0xa1, 0x82, 0xff, 0x9f, 0xb8, 0xa3, 0xfb, 0x50, 0x90, 0x63, 0xc0, 0xbd, 0xc2, 0x36,
+	0x43, 0xae, 0x6b, 0xfc, 0x07, 0x70, 0xd3, 0x76, 0x76, 0x4c, 0xbe, 0x9f, 0x12, 0x2e, 0xd0, 0xbb,
+	0xb0, 0xbb, 0x62, 0x8d, 0x5c, 0xe9, 0x50, 0xc2, 0x35, 0xdb, 0x00, 0xb9, 0x3f, 0x2b, 0x40, 0xf1,
+	0x94, 0x70, 0x1e, 0x0d, 0x09, 0xfa, 0x1a, 0x76, 0x13, 0x72, 0xa9, 0xdf, 0xcb, 0x50, 0xf9, 0xbd,
+	0x1e, 0xde, 0xb7, 0x9b, 0x6b, 0x3f, 0x56, 0x4d, 0xfb, 0xda, 0xc0, 0xc1, 0xd5, 0xc4, 0xda, 0xa3,
+	0x33, 0xd8, 0x93, 0x60, 0x17, 0xd2, 0x5a, 0x43, 0xf5, 0xa0, 0x4a, 0xf1, 0x4a, 0xfb, 0x9d, 0xcd,
+	0x68, 0x4b, 0x1f, 0x0e, 0x1c, 0x5c, 0x4b, 0xec, 0x83, 0x15, 0x93, 0x5a, 0xe7, 0x05, 0x4b, 0xa0,
+	0xb9, 0x17, 0x05, 0x96, 0x49, 0xa1, 0xaf, 0xfe, 0x66, 0x27, 0xba, 0x5d, 0xfe, 0xbf, 0x40, 0x74,
+	0x1f, 0x3f, 0x0a, 0x56, 0xdd, 0x04, 0x3d, 0x04, 0x58, 0xfa, 0xb2, 0x69, 0x58, 0x63, 0x03, 0xcc,
+	0xc2, 0x76, 0x02, 0x07, 0x97, 0x17, 0xce, 0x2c, 0x5d, 0x45, 0x59, 0xc3, 0xce, 0x1a, 0xaf, 0x5d,
+	0x16, 0xcb, 0x61, 0x0e, 0x1c, 0x6d, 0x10, 0xe8, 0x3e, 0x94, 0x46, 0x11, 0x0f, 0x55, 0x99, 0xfe,
+	0xc6, 0xd5, 0x37, 0x94, 0x19, 0x1b, 0x09, 0x1c, 0x5c, 0x1c, 0xe9, 0xa5, 0xec, 0xab, 0x2c, 0x54,
+	0xdf, 0xa7, 0x89, 0x7c, 0xb1, 0xbd, 0xd2, 0x4b, 0xfb, 0x6a, 0x7b, 0x80, 0xec, 0xeb, 0x85, 0xb5,
+	0x47, 0x01, 0xd4, 0x16, 0x60, 0x72, 0x2a, 0xbd, 0xf2, 0x4b, 0x95, 0xb4, 0x5e, 0x49, 0xa9, 0xe4,
+	0xc5, 0x72, 0x8b, 0x22, 0xb8, 0xbd, 0x3a, 0x6e, 0x61, 0xa6, 0x67, 0xd7, 0x03, 0x05, 0x79, 0xef,
+	0x15, 0xc6, 0xce, 0x4c, 0x7b, 0xe0, 0xe0, 0x9b, 0xc9, 0x3f, 0x8f, 0x3b, 0x05, 0xc8, 0xf1, 0xe9,
+	0xa4, 0x73, 0xfe, 0x6c, 0x56, 0x77, 0x9f, 0xcf, 0xea, 0xee, 0xef, 0xb3, 0xba, 0xfb, 0xd3, 0x75,
+	0xdd, 0x79, 0x7e, 0x5d, 0x77, 0x7e, 0xbb, 0xae, 0x3b, 0xdf, 0x3e, 0x18, 0x52, 0x31, 0x9a, 0xf6,
+	0x9b, 0x03, 0x36, 0x69, 0xd9, 0x7f, 0x51, 0x43, 0xf6, 0x81, 0xde, 0xb6, 0xf4, 0xcf, 0xd8, 0xda,
+	0x1f, 0xba, 0xfe, 0x8e, 0x0a, 0x1e, 0xff, 0x35, 0x00, 0x1f, 0x35, 0x33, 0x32, 0xf0, 0x09, 0x00,
+	0x00,
 }
 
 func (m *NewRoundStep) Marshal() (dAtA []byte, err error) {
@@ -878,6 +960,23 @@ func (m *NewRoundStep) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.CommitHeader != nil {
+		{
+			size, err := m.CommitHeader.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.StartTime != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.StartTime))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.LastCommitRound != 0 {
 		i = encodeVarintTypes(dAtA, i, uint64(m.LastCommitRound))
 		i--
@@ -1274,6 +1373,39 @@ func (m *VoteSetBits) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *NewRoundStepRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NewRoundStepRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *NewRoundStepRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.CommitHeaders {
+		i--
+		if m.CommitHeaders {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Message) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1495,6 +1627,27 @@ func (m *Message_VoteSetBits) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	return len(dAtA) - i, nil
 }
+func (m *Message_NewRoundStepRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_NewRoundStepRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.NewRoundStepRequest != nil {
+		{
+			size, err := m.NewRoundStepRequest.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	return len(dAtA) - i, nil
+}
 func encodeVarintTypes(dAtA []byte, offset int, v uint64) int {
 	offset -= sovTypes(v)
 	base := offset
@@ -1527,6 +1680,13 @@ func (m *NewRoundStep) Size() (n int) {
 	if m.LastCommitRound != 0 {
 		n += 1 + sovTypes(uint64(m.LastCommitRound))
 	}
+	if m.StartTime != 0 {
+		n += 1 + sovTypes(uint64(m.StartTime))
+	}
+	if m.CommitHeader != nil {
+		l = m.CommitHeader.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
 	return n
 }
 
@@ -1675,6 +1835,18 @@ func (m *VoteSetBits) Size() (n int) {
 	return n
 }
 
+func (m *NewRoundStepRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.CommitHeaders {
+		n += 2
+	}
+	return n
+}
+
 func (m *Message) Size() (n int) {
 	if m == nil {
 		return 0
@@ -1795,6 +1967,18 @@ func (m *Message_VoteSetBits) Size() (n int) {
 	}
 	return n
 }
+func (m *Message_NewRoundStepRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.NewRoundStepRequest != nil {
+		l = m.NewRoundStepRequest.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	return n
+}
 
 func sovTypes(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
@@ -1926,6 +2110,61 @@ func (m *NewRoundStep) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
+			}
+			m.StartTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CommitHeader", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.CommitHeader == nil {
+				m.CommitHeader = &types.Header{}
+			}
+			if err := m.CommitHeader.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -2974,6 +3213,76 @@ func (m *VoteSetBits) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *NewRoundStepRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NewRoundStepRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NewRoundStepRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CommitHeaders", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CommitHeaders = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *Message) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -3318,6 +3627,41 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 			}
 			m.Sum = &Message_VoteSetBits{v}
 			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewRoundStepRequest", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &NewRoundStepRequest{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Sum = &Message_NewRoundStepRequest{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
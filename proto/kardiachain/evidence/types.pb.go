@@ -140,36 +140,94 @@ func (m *Info) GetTotalVotingPower() int64 {
 	return 0
 }
 
+// EvidenceRequest asks a peer for its pending evidence with a height between
+// from_height and to_height inclusive.
+type EvidenceRequest struct {
+	FromHeight uint64 `protobuf:"varint,1,opt,name=from_height,json=fromHeight,proto3" json:"from_height,omitempty"`
+	ToHeight   uint64 `protobuf:"varint,2,opt,name=to_height,json=toHeight,proto3" json:"to_height,omitempty"`
+}
+
+func (m *EvidenceRequest) Reset()         { *m = EvidenceRequest{} }
+func (m *EvidenceRequest) String() string { return proto.CompactTextString(m) }
+func (*EvidenceRequest) ProtoMessage()    {}
+func (*EvidenceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ee531333972ce6d0, []int{2}
+}
+func (m *EvidenceRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EvidenceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EvidenceRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EvidenceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EvidenceRequest.Merge(m, src)
+}
+func (m *EvidenceRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *EvidenceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EvidenceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EvidenceRequest proto.InternalMessageInfo
+
+func (m *EvidenceRequest) GetFromHeight() uint64 {
+	if m != nil {
+		return m.FromHeight
+	}
+	return 0
+}
+
+func (m *EvidenceRequest) GetToHeight() uint64 {
+	if m != nil {
+		return m.ToHeight
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*List)(nil), "kardiachain.evidence.List")
 	proto.RegisterType((*Info)(nil), "kardiachain.evidence.Info")
+	proto.RegisterType((*EvidenceRequest)(nil), "kardiachain.evidence.EvidenceRequest")
 }
 
 func init() { proto.RegisterFile("kardiachain/evidence/types.proto", fileDescriptor_ee531333972ce6d0) }
 
 var fileDescriptor_ee531333972ce6d0 = []byte{
-	// 336 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0x31, 0x4b, 0xc3, 0x40,
-	0x14, 0xc7, 0x73, 0x36, 0x48, 0x39, 0x17, 0x39, 0x3a, 0x84, 0x2a, 0x69, 0xed, 0xd4, 0x41, 0xef,
-	0x40, 0x07, 0x05, 0x15, 0xa1, 0xe0, 0x20, 0x38, 0x48, 0xd0, 0x0e, 0x2e, 0xe5, 0xda, 0x5e, 0xaf,
-	0x87, 0x6d, 0x5e, 0x48, 0xae, 0x15, 0xbf, 0x45, 0x3f, 0x56, 0xc7, 0x8e, 0x4e, 0x2a, 0xed, 0xe8,
-	0x97, 0x90, 0xdc, 0x35, 0x31, 0xc5, 0x82, 0x5b, 0x5e, 0xde, 0xef, 0xfd, 0xf2, 0xfe, 0xb9, 0xc3,
-	0xf5, 0x17, 0x1e, 0xf7, 0x15, 0xef, 0x0d, 0xb9, 0x0a, 0x99, 0x98, 0xaa, 0xbe, 0x08, 0x7b, 0x82,
-	0xe9, 0xb7, 0x48, 0x24, 0x34, 0x8a, 0x41, 0x03, 0xa9, 0x14, 0x08, 0x9a, 0x11, 0xd5, 0x8a, 0x04,
-	0x09, 0x06, 0x60, 0xe9, 0x93, 0x65, 0xab, 0x1b, 0x36, 0x23, 0xc9, 0x9d, 0x6b, 0xa2, 0x26, 0x01,
-	0xe4, 0x48, 0x30, 0x53, 0x75, 0x27, 0x03, 0xa6, 0xd5, 0x58, 0x24, 0x9a, 0x8f, 0xa3, 0x35, 0x70,
-	0xf4, 0x57, 0x31, 0xe5, 0x23, 0xd5, 0xe7, 0x1a, 0x62, 0x8b, 0x34, 0x6e, 0xb0, 0x7b, 0xaf, 0x12,
-	0x4d, 0xce, 0x71, 0x39, 0xb3, 0x7b, 0xa8, 0x5e, 0x6a, 0xee, 0x9d, 0x1e, 0xd0, 0xe2, 0xb2, 0x36,
-	0xc5, 0xed, 0x1a, 0x09, 0x72, 0xb8, 0xf1, 0x8d, 0xb0, 0x7b, 0x17, 0x0e, 0x80, 0x5c, 0x6f, 0x18,
-	0xd0, 0x3f, 0x86, 0x96, 0x3b, 0xff, 0xa8, 0x39, 0xbf, 0x1e, 0x72, 0x81, 0xdd, 0x74, 0x7d, 0x6f,
-	0xc7, 0x8c, 0x56, 0xa9, 0xcd, 0x46, 0xb3, 0x6c, 0xf4, 0x31, 0xcb, 0xd6, 0x2a, 0xa7, 0x93, 0xb3,
-	0xcf, 0x1a, 0x0a, 0xcc, 0x04, 0xb9, 0xc2, 0x38, 0x4f, 0x95, 0x78, 0x25, 0xb3, 0xfc, 0xe1, 0x96,
-	0x4f, 0xb7, 0x33, 0x28, 0x28, 0xf0, 0xe4, 0x18, 0x13, 0x0d, 0x9a, 0x8f, 0x3a, 0x53, 0xd0, 0x2a,
-	0x94, 0x9d, 0x08, 0x5e, 0x45, 0xec, 0xb9, 0x75, 0xd4, 0x2c, 0x05, 0xfb, 0xa6, 0xd3, 0x36, 0x8d,
-	0x87, 0xf4, 0x7d, 0xeb, 0x69, 0xbe, 0xf4, 0xd1, 0x62, 0xe9, 0xa3, 0xaf, 0xa5, 0x8f, 0x66, 0x2b,
-	0xdf, 0x59, 0xac, 0x7c, 0xe7, 0x7d, 0xe5, 0x3b, 0xcf, 0x97, 0x52, 0xe9, 0xe1, 0xa4, 0x4b, 0x7b,
-	0x30, 0x66, 0xc5, 0xdf, 0x2e, 0xe1, 0xc4, 0x96, 0xf6, 0x98, 0xd8, 0xb6, 0x3b, 0xd2, 0xdd, 0x35,
-	0xbd, 0xb3, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x10, 0x7c, 0x60, 0xaf, 0x42, 0x02, 0x00, 0x00,
+	// 378 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0xc1, 0x4a, 0xeb, 0x40,
+	0x14, 0x86, 0x33, 0x6d, 0xb8, 0xf4, 0x4e, 0x17, 0xf7, 0x32, 0x74, 0x11, 0x5a, 0x49, 0x6a, 0x57,
+	0x5d, 0x68, 0x02, 0xba, 0x50, 0x50, 0x11, 0x0a, 0x82, 0x82, 0xa0, 0x04, 0xed, 0xc2, 0x4d, 0x49,
+	0xdb, 0xe9, 0x64, 0xb0, 0xc9, 0x89, 0xc9, 0xb4, 0xe2, 0x5b, 0xf4, 0xb1, 0xba, 0xec, 0xd2, 0x95,
+	0x4a, 0xbb, 0xf4, 0x25, 0x24, 0x33, 0x4d, 0x4c, 0xb1, 0xe0, 0x2e, 0xe7, 0xfc, 0xdf, 0xf9, 0x73,
+	0xfe, 0x9c, 0xe0, 0xe6, 0xa3, 0x17, 0x0f, 0xb9, 0x37, 0xf0, 0x3d, 0x1e, 0x3a, 0x74, 0xca, 0x87,
+	0x34, 0x1c, 0x50, 0x47, 0xbc, 0x44, 0x34, 0xb1, 0xa3, 0x18, 0x04, 0x90, 0x5a, 0x81, 0xb0, 0x33,
+	0xa2, 0x5e, 0x63, 0xc0, 0x40, 0x02, 0x4e, 0xfa, 0xa4, 0xd8, 0xfa, 0x86, 0x9b, 0x34, 0xc9, 0x3d,
+	0xd7, 0x84, 0xc5, 0x00, 0xd8, 0x98, 0x3a, 0xb2, 0xea, 0x4f, 0x46, 0x8e, 0xe0, 0x01, 0x4d, 0x84,
+	0x17, 0x44, 0x6b, 0x60, 0xf7, 0xa7, 0xc5, 0xd4, 0x1b, 0xf3, 0xa1, 0x27, 0x20, 0x56, 0x48, 0xeb,
+	0x1c, 0xeb, 0xd7, 0x3c, 0x11, 0xe4, 0x08, 0x57, 0x32, 0x77, 0x03, 0x35, 0xcb, 0xed, 0xea, 0x41,
+	0xc3, 0x2e, 0x2e, 0xab, 0x52, 0x5c, 0xac, 0x11, 0x37, 0x87, 0x5b, 0x9f, 0x08, 0xeb, 0x57, 0xe1,
+	0x08, 0xc8, 0xd9, 0x86, 0x03, 0xfa, 0xc5, 0xa1, 0xa3, 0xcf, 0xdf, 0x2c, 0xed, 0xdb, 0x87, 0x1c,
+	0x63, 0x3d, 0x5d, 0xdf, 0x28, 0xc9, 0xd1, 0xba, 0xad, 0xb2, 0xd9, 0x59, 0x36, 0xfb, 0x2e, 0xcb,
+	0xd6, 0xa9, 0xa4, 0x93, 0xb3, 0x77, 0x0b, 0xb9, 0x72, 0x82, 0x9c, 0x62, 0x9c, 0xa7, 0x4a, 0x8c,
+	0xb2, 0x5c, 0x7e, 0x67, 0xcb, 0xab, 0xbb, 0x19, 0xe4, 0x16, 0x78, 0xb2, 0x87, 0x89, 0x00, 0xe1,
+	0x8d, 0x7b, 0x53, 0x10, 0x3c, 0x64, 0xbd, 0x08, 0x9e, 0x69, 0x6c, 0xe8, 0x4d, 0xd4, 0x2e, 0xbb,
+	0xff, 0xa5, 0xd2, 0x95, 0xc2, 0x6d, 0xda, 0x6f, 0xdd, 0xe0, 0x7f, 0xf9, 0x37, 0xa0, 0x4f, 0x13,
+	0x9a, 0x08, 0x62, 0xe1, 0xea, 0x28, 0x86, 0xa0, 0xe7, 0x53, 0xce, 0x7c, 0x21, 0xa3, 0xeb, 0x2e,
+	0x4e, 0x5b, 0x97, 0xb2, 0x43, 0x1a, 0xf8, 0xaf, 0x80, 0x4c, 0x2e, 0x49, 0xb9, 0x22, 0x40, 0x89,
+	0x9d, 0xfb, 0xf9, 0xd2, 0x44, 0x8b, 0xa5, 0x89, 0x3e, 0x96, 0x26, 0x9a, 0xad, 0x4c, 0x6d, 0xb1,
+	0x32, 0xb5, 0xd7, 0x95, 0xa9, 0x3d, 0x9c, 0x30, 0x2e, 0xfc, 0x49, 0xdf, 0x1e, 0x40, 0xe0, 0x14,
+	0xef, 0xc8, 0x60, 0x5f, 0x95, 0xea, 0xee, 0xce, 0xb6, 0x9f, 0xae, 0xff, 0x47, 0x6a, 0x87, 0x5f,
+	0x03, 0x00, 0xa1, 0x2c, 0x52, 0xdd, 0x93, 0x02, 0x00, 0x00,
 }
 
 func (m *List) Marshal() (dAtA []byte, err error) {
@@ -269,6 +327,39 @@ func (m *Info) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *EvidenceRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EvidenceRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EvidenceRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.ToHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.ToHeight))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.FromHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.FromHeight))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintTypes(dAtA []byte, offset int, v uint64) int {
 	offset -= sovTypes(v)
 	base := offset
@@ -317,6 +408,21 @@ func (m *Info) Size() (n int) {
 	return n
 }
 
+func (m *EvidenceRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FromHeight != 0 {
+		n += 1 + sovTypes(uint64(m.FromHeight))
+	}
+	if m.ToHeight != 0 {
+		n += 1 + sovTypes(uint64(m.ToHeight))
+	}
+	return n
+}
+
 func sovTypes(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -576,6 +682,94 @@ func (m *Info) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *EvidenceRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EvidenceRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EvidenceRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromHeight", wireType)
+			}
+			m.FromHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FromHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ToHeight", wireType)
+			}
+			m.ToHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ToHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipTypes(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
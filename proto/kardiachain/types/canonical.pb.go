@@ -139,6 +139,7 @@ type CanonicalProposal struct {
 	BlockID   *CanonicalBlockID `protobuf:"bytes,5,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
 	Timestamp time.Time         `protobuf:"bytes,6,opt,name=timestamp,proto3,stdtime" json:"timestamp"`
 	ChainID   string            `protobuf:"bytes,7,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Version   uint32            `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
 }
 
 func (m *CanonicalProposal) Reset()         { *m = CanonicalProposal{} }
@@ -223,6 +224,13 @@ func (m *CanonicalProposal) GetChainID() string {
 	return ""
 }
 
+func (m *CanonicalProposal) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type CanonicalVote struct {
 	Type      SignedMsgType     `protobuf:"varint,1,opt,name=type,proto3,enum=kardiachain.types.SignedMsgType" json:"type,omitempty"`
 	Height    uint64            `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
@@ -317,38 +325,39 @@ func init() {
 func init() { proto.RegisterFile("kardiachain/types/canonical.proto", fileDescriptor_ce6d5d96318ac9f8) }
 
 var fileDescriptor_ce6d5d96318ac9f8 = []byte{
-	// 490 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x53, 0x4f, 0x6f, 0xd3, 0x30,
-	0x1c, 0x6d, 0x4a, 0xfa, 0xcf, 0x5b, 0x81, 0x59, 0xd3, 0x14, 0x55, 0x22, 0x29, 0x45, 0x42, 0xdd,
-	0x81, 0x44, 0x1a, 0x1c, 0x38, 0x67, 0x1c, 0xa8, 0x00, 0x31, 0x79, 0x13, 0x48, 0x5c, 0x2a, 0x37,
-	0x31, 0x4e, 0xb4, 0x34, 0xb6, 0x12, 0xf7, 0xb0, 0x13, 0x5f, 0x61, 0x1f, 0x84, 0x0f, 0xb2, 0xe3,
-	0x8e, 0x48, 0x48, 0x05, 0xa5, 0x5f, 0x04, 0xf9, 0xe7, 0xfe, 0x93, 0x3a, 0xb8, 0x80, 0xb8, 0x44,
-	0x7e, 0x7e, 0xcf, 0xbf, 0xf7, 0xf4, 0x62, 0xa3, 0xc7, 0x97, 0xb4, 0x88, 0x53, 0x1a, 0x25, 0x34,
-	0xcd, 0x03, 0x75, 0x25, 0x59, 0x19, 0x44, 0x34, 0x17, 0x79, 0x1a, 0xd1, 0xcc, 0x97, 0x85, 0x50,
-	0x02, 0x1f, 0x6c, 0x49, 0x7c, 0x90, 0xf4, 0x0e, 0xb9, 0xe0, 0x02, 0xd8, 0x40, 0xaf, 0x8c, 0xb0,
-	0xe7, 0x71, 0x21, 0x78, 0xc6, 0x02, 0x40, 0x93, 0xd9, 0xe7, 0x40, 0xa5, 0x53, 0x56, 0x2a, 0x3a,
-	0x95, 0x4b, 0xc1, 0xa3, 0x5d, 0x33, 0xf8, 0x1a, 0x7a, 0xf0, 0x05, 0x3d, 0x3c, 0x5d, 0x79, 0x87,
-	0x99, 0x88, 0x2e, 0x47, 0xaf, 0x30, 0x46, 0x76, 0x42, 0xcb, 0xc4, 0xb1, 0xfa, 0xd6, 0x70, 0x9f,
-	0xc0, 0x1a, 0x7f, 0x44, 0x0f, 0x24, 0x2d, 0xd4, 0xb8, 0x64, 0x6a, 0x9c, 0x30, 0x1a, 0xb3, 0xc2,
-	0xa9, 0xf7, 0xad, 0xe1, 0xde, 0xc9, 0xb1, 0xbf, 0x13, 0xd5, 0x5f, 0x4f, 0x3c, 0xa3, 0x85, 0x3a,
-	0x67, 0xea, 0x35, 0x1c, 0x08, 0xed, 0x9b, 0xb9, 0x57, 0x23, 0x5d, 0xb9, 0xbd, 0x39, 0x08, 0xd1,
-	0xd1, 0xdd, 0x72, 0x7c, 0x88, 0x1a, 0x4a, 0x28, 0x9a, 0x41, 0x8e, 0x2e, 0x31, 0x60, 0x1d, 0xae,
-	0xbe, 0x09, 0x37, 0xf8, 0x5e, 0x47, 0x07, 0x9b, 0x21, 0x85, 0x90, 0xa2, 0xa4, 0x19, 0x7e, 0x81,
-	0x6c, 0x1d, 0x07, 0x8e, 0xdf, 0x3f, 0xe9, 0xdf, 0x91, 0xf3, 0x3c, 0xe5, 0x39, 0x8b, 0xdf, 0x95,
-	0xfc, 0xe2, 0x4a, 0x32, 0x02, 0x6a, 0x7c, 0x84, 0x9a, 0x09, 0x4b, 0x79, 0xa2, 0xc0, 0xc1, 0x26,
-	0x4b, 0xa4, 0xd3, 0x14, 0x62, 0x96, 0xc7, 0xce, 0x3d, 0x93, 0x06, 0x00, 0x3e, 0x46, 0x1d, 0x29,
-	0xb2, 0xb1, 0x61, 0x6c, 0xcd, 0x84, 0xfb, 0xd5, 0xdc, 0x6b, 0x9f, 0xbd, 0x7f, 0x4b, 0xf4, 0x1e,
-	0x69, 0x4b, 0x91, 0xc1, 0x0a, 0xbf, 0x41, 0xed, 0x89, 0x2e, 0x78, 0x9c, 0xc6, 0x4e, 0x03, 0xaa,
-	0x7b, 0xf2, 0xa7, 0xea, 0x96, 0x3f, 0x23, 0xdc, 0xab, 0xe6, 0x5e, 0x6b, 0x09, 0x48, 0x0b, 0x26,
-	0x8c, 0x62, 0x1c, 0xa2, 0xce, 0xfa, 0x47, 0x3b, 0x4d, 0x98, 0xd6, 0xf3, 0xcd, 0x55, 0xf0, 0x57,
-	0x57, 0xc1, 0xbf, 0x58, 0x29, 0xc2, 0xb6, 0x6e, 0xfe, 0xfa, 0x87, 0x67, 0x91, 0xcd, 0x31, 0xfc,
-	0x14, 0xb5, 0xc1, 0x59, 0x07, 0x6a, 0xf5, 0xad, 0x61, 0xc7, 0x78, 0x9d, 0xea, 0x3d, 0xed, 0x05,
-	0xe4, 0x28, 0x1e, 0x7c, 0xad, 0xa3, 0xee, 0x3a, 0xd6, 0x07, 0xa1, 0xd8, 0x7f, 0x69, 0x76, 0xbb,
-	0x2e, 0xfb, 0x9f, 0xd6, 0xd5, 0xf8, 0xfb, 0xba, 0x9a, 0xbf, 0xaf, 0x2b, 0x24, 0x37, 0x95, 0x6b,
-	0xdd, 0x56, 0xae, 0xf5, 0xb3, 0x72, 0xad, 0xeb, 0x85, 0x5b, 0xbb, 0x5d, 0xb8, 0xb5, 0x6f, 0x0b,
-	0xb7, 0xf6, 0xe9, 0x25, 0x4f, 0x55, 0x32, 0x9b, 0xf8, 0x91, 0x98, 0x06, 0xdb, 0xaf, 0x92, 0x8b,
-	0x67, 0x06, 0x9a, 0x57, 0x1c, 0xec, 0xbc, 0xd8, 0x49, 0x13, 0x88, 0xe7, 0xbf, 0x02, 0x00, 0x00,
-	0xff, 0xff, 0x77, 0x17, 0x27, 0xa8, 0x3a, 0x04, 0x00, 0x00,
+	// 502 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x53, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0x8d, 0x83, 0x93, 0x38, 0xdb, 0x06, 0xe8, 0xaa, 0xaa, 0xac, 0x48, 0xd8, 0x21, 0x48, 0x28,
+	0x3d, 0x60, 0x4b, 0x85, 0x03, 0x67, 0x97, 0x03, 0x11, 0x20, 0xaa, 0x6d, 0x05, 0x12, 0x97, 0x68,
+	0x63, 0x2f, 0xb6, 0x55, 0xc7, 0x63, 0xd9, 0x1b, 0xa4, 0x9e, 0xf8, 0x0b, 0xfd, 0x21, 0xfc, 0x90,
+	0x1e, 0x7b, 0xe4, 0x14, 0x90, 0xf3, 0x1b, 0xb8, 0xa3, 0x1d, 0xe7, 0x4b, 0x4a, 0xe0, 0x02, 0xea,
+	0xc5, 0xda, 0xb7, 0xf3, 0x76, 0xde, 0xf3, 0xdb, 0x1d, 0xf2, 0xf8, 0x92, 0xe7, 0x41, 0xcc, 0xfd,
+	0x88, 0xc7, 0xa9, 0x2b, 0xaf, 0x32, 0x51, 0xb8, 0x3e, 0x4f, 0x21, 0x8d, 0x7d, 0x9e, 0x38, 0x59,
+	0x0e, 0x12, 0xe8, 0xc1, 0x06, 0xc5, 0x41, 0x4a, 0xf7, 0x30, 0x84, 0x10, 0xb0, 0xea, 0xaa, 0x55,
+	0x45, 0xec, 0xda, 0x21, 0x40, 0x98, 0x08, 0x17, 0xd1, 0x78, 0xfa, 0xd9, 0x95, 0xf1, 0x44, 0x14,
+	0x92, 0x4f, 0xb2, 0x05, 0xe1, 0xd1, 0xb6, 0x18, 0x7e, 0xab, 0x72, 0xff, 0x2b, 0x79, 0x78, 0xba,
+	0xd4, 0xf6, 0x12, 0xf0, 0x2f, 0x87, 0xaf, 0x28, 0x25, 0x7a, 0xc4, 0x8b, 0xc8, 0xd4, 0x7a, 0xda,
+	0x60, 0x9f, 0xe1, 0x9a, 0x7e, 0x24, 0x0f, 0x32, 0x9e, 0xcb, 0x51, 0x21, 0xe4, 0x28, 0x12, 0x3c,
+	0x10, 0xb9, 0x59, 0xef, 0x69, 0x83, 0xbd, 0x93, 0x63, 0x67, 0xcb, 0xaa, 0xb3, 0xea, 0x78, 0xc6,
+	0x73, 0x79, 0x2e, 0xe4, 0x6b, 0x3c, 0xe0, 0xe9, 0x37, 0x33, 0xbb, 0xc6, 0x3a, 0xd9, 0xe6, 0x66,
+	0xdf, 0x23, 0x47, 0xbb, 0xe9, 0xf4, 0x90, 0x34, 0x24, 0x48, 0x9e, 0xa0, 0x8f, 0x0e, 0xab, 0xc0,
+	0xca, 0x5c, 0x7d, 0x6d, 0xae, 0xff, 0xab, 0x4e, 0x0e, 0xd6, 0x4d, 0x72, 0xc8, 0xa0, 0xe0, 0x09,
+	0x7d, 0x41, 0x74, 0x65, 0x07, 0x8f, 0xdf, 0x3f, 0xe9, 0xed, 0xf0, 0x79, 0x1e, 0x87, 0xa9, 0x08,
+	0xde, 0x15, 0xe1, 0xc5, 0x55, 0x26, 0x18, 0xb2, 0xe9, 0x11, 0x69, 0x46, 0x22, 0x0e, 0x23, 0x89,
+	0x0a, 0x3a, 0x5b, 0x20, 0xe5, 0x26, 0x87, 0x69, 0x1a, 0x98, 0xf7, 0x2a, 0x37, 0x08, 0xe8, 0x31,
+	0x69, 0x67, 0x90, 0x8c, 0xaa, 0x8a, 0xae, 0x2a, 0xde, 0x7e, 0x39, 0xb3, 0x8d, 0xb3, 0xf7, 0x6f,
+	0x99, 0xda, 0x63, 0x46, 0x06, 0x09, 0xae, 0xe8, 0x1b, 0x62, 0x8c, 0x55, 0xc0, 0xa3, 0x38, 0x30,
+	0x1b, 0x18, 0xdd, 0x93, 0xbf, 0x45, 0xb7, 0xb8, 0x0c, 0x6f, 0xaf, 0x9c, 0xd9, 0xad, 0x05, 0x60,
+	0x2d, 0xec, 0x30, 0x0c, 0xa8, 0x47, 0xda, 0xab, 0x8b, 0x36, 0x9b, 0xd8, 0xad, 0xeb, 0x54, 0x4f,
+	0xc1, 0x59, 0x3e, 0x05, 0xe7, 0x62, 0xc9, 0xf0, 0x0c, 0x95, 0xfc, 0xf5, 0x0f, 0x5b, 0x63, 0xeb,
+	0x63, 0xf4, 0x29, 0x31, 0x50, 0x59, 0x19, 0x6a, 0xf5, 0xb4, 0x41, 0xbb, 0xd2, 0x3a, 0x55, 0x7b,
+	0x4a, 0x0b, 0x8b, 0xc3, 0x80, 0x9a, 0xa4, 0xf5, 0x45, 0xe4, 0x45, 0x0c, 0xa9, 0x69, 0xe0, 0xbf,
+	0x2f, 0x61, 0xff, 0x5b, 0x9d, 0x74, 0x56, 0x86, 0x3f, 0x80, 0x14, 0x77, 0x92, 0xf9, 0x66, 0x90,
+	0xfa, 0x7f, 0x0d, 0xb2, 0xf1, 0xef, 0x41, 0x36, 0xff, 0x1c, 0xa4, 0xc7, 0x6e, 0x4a, 0x4b, 0xbb,
+	0x2d, 0x2d, 0xed, 0x67, 0x69, 0x69, 0xd7, 0x73, 0xab, 0x76, 0x3b, 0xb7, 0x6a, 0xdf, 0xe7, 0x56,
+	0xed, 0xd3, 0xcb, 0x30, 0x96, 0xd1, 0x74, 0xec, 0xf8, 0x30, 0x71, 0x37, 0xe7, 0x35, 0x84, 0x67,
+	0x15, 0xac, 0xe6, 0xdb, 0xdd, 0x9a, 0xe5, 0x71, 0x13, 0x0b, 0xcf, 0x7f, 0x0f, 0x00, 0x0c, 0x35,
+	0xc7, 0x68, 0x54, 0x04, 0x00, 0x00,
 }
 
 func (m *CanonicalBlockID) Marshal() (dAtA []byte, err error) {
@@ -446,6 +455,11 @@ func (m *CanonicalProposal) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Version != 0 {
+		i = encodeVarintCanonical(dAtA, i, uint64(m.Version))
+		i--
+		dAtA[i] = 0x40
+	}
 	if len(m.ChainID) > 0 {
 		i -= len(m.ChainID)
 		copy(dAtA[i:], m.ChainID)
@@ -631,6 +645,9 @@ func (m *CanonicalProposal) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovCanonical(uint64(l))
 	}
+	if m.Version != 0 {
+		n += 1 + sovCanonical(uint64(m.Version))
+	}
 	return n
 }
 
@@ -1094,6 +1111,25 @@ func (m *CanonicalProposal) Unmarshal(dAtA []byte) error {
 			}
 			m.ChainID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCanonical
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCanonical(dAtA[iNdEx:])
@@ -655,13 +655,15 @@ func (m *CommitSig) GetSignature() []byte {
 }
 
 type Proposal struct {
-	Type      SignedMsgType `protobuf:"varint,1,opt,name=type,proto3,enum=kardiachain.types.SignedMsgType" json:"type,omitempty"`
-	Height    uint64        `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
-	Round     uint32        `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
-	PolRound  uint32        `protobuf:"varint,4,opt,name=pol_round,json=polRound,proto3" json:"pol_round,omitempty"`
-	BlockID   BlockID       `protobuf:"bytes,5,opt,name=block_id,json=blockId,proto3" json:"block_id"`
-	Timestamp time.Time     `protobuf:"bytes,6,opt,name=timestamp,proto3,stdtime" json:"timestamp"`
-	Signature []byte        `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+	Type       SignedMsgType `protobuf:"varint,1,opt,name=type,proto3,enum=kardiachain.types.SignedMsgType" json:"type,omitempty"`
+	Height     uint64        `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Round      uint32        `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	PolRound   uint32        `protobuf:"varint,4,opt,name=pol_round,json=polRound,proto3" json:"pol_round,omitempty"`
+	BlockID    BlockID       `protobuf:"bytes,5,opt,name=block_id,json=blockId,proto3" json:"block_id"`
+	Timestamp  time.Time     `protobuf:"bytes,6,opt,name=timestamp,proto3,stdtime" json:"timestamp"`
+	Signature  []byte        `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+	Version    uint32        `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	Signatures [][]byte      `protobuf:"bytes,9,rep,name=signatures,proto3" json:"signatures,omitempty"`
 }
 
 func (m *Proposal) Reset()         { *m = Proposal{} }
@@ -746,6 +748,20 @@ func (m *Proposal) GetSignature() []byte {
 	return nil
 }
 
+func (m *Proposal) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Proposal) GetSignatures() [][]byte {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
 type SignedHeader struct {
 	Header *Header `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	Commit *Commit `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
@@ -868,80 +884,81 @@ func init() {
 func init() { proto.RegisterFile("kardiachain/types/types.proto", fileDescriptor_6f03c926763cb388) }
 
 var fileDescriptor_6f03c926763cb388 = []byte{
-	// 1153 bytes of a gzipped FileDescriptorProto
+	// 1174 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0xcd, 0x6e, 0xdb, 0x46,
-	0x17, 0x15, 0x25, 0x5a, 0x3f, 0x57, 0x96, 0x25, 0x13, 0x4e, 0x22, 0x2b, 0xf9, 0x64, 0x42, 0x1f,
-	0xda, 0x3a, 0xfd, 0x91, 0x92, 0xb4, 0x45, 0xd3, 0xa5, 0x65, 0x3b, 0x89, 0x10, 0x5b, 0x12, 0x28,
-	0xc5, 0x45, 0xbb, 0x21, 0x46, 0xe2, 0x98, 0x22, 0x4c, 0x71, 0x08, 0x72, 0xe4, 0xda, 0x6f, 0x50,
-	0x08, 0x5d, 0xe4, 0x05, 0xb4, 0x6a, 0x17, 0x5d, 0x17, 0xe8, 0x0b, 0x74, 0x95, 0x65, 0x76, 0xed,
-	0xca, 0x2d, 0xec, 0x5d, 0x9f, 0xa2, 0x98, 0x19, 0x8a, 0xa2, 0x2c, 0x19, 0x29, 0x9a, 0xa0, 0x1b,
-	0x41, 0x73, 0xef, 0x39, 0xc3, 0x7b, 0xcf, 0x3d, 0x33, 0x24, 0xfc, 0xef, 0x04, 0x79, 0x86, 0x85,
-	0xfa, 0x03, 0x64, 0x39, 0x35, 0x7a, 0xee, 0x62, 0x5f, 0xfc, 0x56, 0x5d, 0x8f, 0x50, 0xa2, 0xac,
-	0x47, 0xd2, 0x55, 0x9e, 0x28, 0x6d, 0x98, 0xc4, 0x24, 0x3c, 0x5b, 0x63, 0xff, 0x04, 0xb0, 0x54,
-	0x8e, 0xee, 0xd3, 0xf7, 0xce, 0x5d, 0x4a, 0x6a, 0xae, 0x47, 0xc8, 0x71, 0x90, 0xdf, 0x32, 0x09,
-	0x31, 0x6d, 0x5c, 0xe3, 0xab, 0xde, 0xe8, 0xb8, 0x46, 0xad, 0x21, 0xf6, 0x29, 0x1a, 0xba, 0x02,
-	0x50, 0xf9, 0x12, 0x72, 0x6d, 0xe4, 0xd1, 0x0e, 0xa6, 0xcf, 0x30, 0x32, 0xb0, 0xa7, 0x6c, 0xc0,
-	0x0a, 0x25, 0x14, 0xd9, 0x45, 0x49, 0x95, 0xb6, 0x73, 0x9a, 0x58, 0x28, 0x0a, 0xc8, 0x03, 0xe4,
-	0x0f, 0x8a, 0x71, 0x55, 0xda, 0x5e, 0xd5, 0xf8, 0xff, 0x8a, 0x05, 0x32, 0xa3, 0x32, 0x86, 0xe5,
-	0x18, 0xf8, 0x6c, 0xca, 0xe0, 0x0b, 0x16, 0xed, 0x9d, 0x53, 0xec, 0x07, 0x14, 0xb1, 0x50, 0x3e,
-	0x87, 0x15, 0x5e, 0x5e, 0x31, 0xa1, 0x4a, 0xdb, 0xd9, 0x47, 0x9b, 0xd5, 0x68, 0xa3, 0xa2, 0xfe,
-	0x6a, 0x9b, 0x01, 0xea, 0xf2, 0xab, 0x8b, 0xad, 0x98, 0x26, 0xd0, 0x95, 0x21, 0xa4, 0xea, 0x36,
-	0xe9, 0x9f, 0x34, 0xf6, 0xc2, 0x4a, 0xa4, 0x59, 0x25, 0x4a, 0x13, 0xf2, 0x2e, 0xf2, 0xa8, 0xee,
-	0x63, 0xaa, 0x0f, 0x78, 0x1b, 0xfc, 0xa9, 0xd9, 0x47, 0x6a, 0x75, 0x41, 0xc8, 0xea, 0x5c, 0xbb,
-	0xc1, 0x63, 0x72, 0x6e, 0x34, 0x58, 0xf9, 0x59, 0x86, 0x64, 0x20, 0xc7, 0xfb, 0x90, 0xe6, 0x64,
-	0xdd, 0x32, 0xf8, 0x9e, 0x99, 0x7a, 0xf6, 0xf2, 0x62, 0x2b, 0xb5, 0xcb, 0x62, 0x8d, 0x3d, 0x2d,
-	0xc5, 0x93, 0x0d, 0x43, 0xb9, 0x0d, 0xc9, 0x01, 0xb6, 0xcc, 0x01, 0xe5, 0x9d, 0xc9, 0x5a, 0xb0,
-	0x52, 0xee, 0x42, 0xc6, 0x44, 0xbe, 0x6e, 0x5b, 0x43, 0x8b, 0x16, 0xf3, 0x3c, 0x95, 0x36, 0x91,
-	0x7f, 0xc0, 0xd6, 0xca, 0x63, 0x90, 0xd9, 0x3c, 0x8a, 0x32, 0x2f, 0xb6, 0x54, 0x15, 0xc3, 0xaa,
-	0x4e, 0x87, 0x55, 0xed, 0x4e, 0x87, 0x55, 0x4f, 0xb3, 0x32, 0x5f, 0xfe, 0xb1, 0x25, 0x69, 0x9c,
-	0xa1, 0xec, 0x41, 0xce, 0x46, 0x3e, 0xd5, 0x7b, 0x4c, 0x15, 0x56, 0xdb, 0x4a, 0xb0, 0xc5, 0x62,
-	0xbf, 0x81, 0x70, 0x41, 0xa7, 0x59, 0x46, 0x13, 0x21, 0x43, 0xd9, 0x86, 0x02, 0xdf, 0xa5, 0x4f,
-	0x86, 0x43, 0x8b, 0xea, 0x5c, 0xd7, 0x24, 0xd7, 0x75, 0x8d, 0xc5, 0x77, 0x79, 0xf8, 0x19, 0x53,
-	0xf8, 0x2e, 0x64, 0x0c, 0x44, 0x91, 0x80, 0xa4, 0x38, 0x24, 0xcd, 0x02, 0x3c, 0xf9, 0x01, 0xe4,
-	0x4f, 0x91, 0x6d, 0x19, 0x88, 0x12, 0xcf, 0x17, 0x90, 0xb4, 0xd8, 0x65, 0x16, 0xe6, 0xc0, 0x07,
-	0xb0, 0xe1, 0xe0, 0x33, 0xaa, 0x5f, 0x47, 0x67, 0x38, 0x5a, 0x61, 0xb9, 0xa3, 0x79, 0xc6, 0x7b,
-	0xb0, 0xd6, 0x27, 0x8e, 0x8f, 0x1d, 0x7f, 0x14, 0x60, 0x81, 0x63, 0x73, 0x61, 0x94, 0xc3, 0x36,
-	0x21, 0x8d, 0x5c, 0x57, 0x00, 0xb2, 0x1c, 0x90, 0x42, 0xae, 0xcb, 0x53, 0xff, 0x87, 0x1c, 0x3e,
-	0xb5, 0x0c, 0xec, 0xf4, 0xb1, 0xc8, 0xe7, 0x78, 0x7e, 0x75, 0x1a, 0xe4, 0xa0, 0xfb, 0x50, 0x70,
-	0x3d, 0xe2, 0x12, 0x1f, 0x7b, 0x3a, 0x32, 0x0c, 0x0f, 0xfb, 0x7e, 0x71, 0x8d, 0xe3, 0xf2, 0xd3,
-	0xf8, 0x8e, 0x08, 0x2b, 0x77, 0x20, 0xe5, 0x8c, 0x86, 0x3a, 0x3d, 0xf3, 0x8b, 0x05, 0x31, 0x69,
-	0x67, 0x34, 0xec, 0x9e, 0xf9, 0x95, 0xbf, 0xe2, 0x20, 0x1f, 0x11, 0x8a, 0x95, 0xcf, 0x40, 0x66,
-	0xca, 0x73, 0x87, 0xae, 0x2d, 0xb5, 0x60, 0xc7, 0x32, 0x1d, 0x6c, 0x1c, 0xfa, 0x66, 0xf7, 0xdc,
-	0xc5, 0x1a, 0x47, 0x47, 0x0c, 0x14, 0x9f, 0x33, 0xd0, 0x06, 0xac, 0x78, 0x64, 0xe4, 0x18, 0xdc,
-	0x57, 0x39, 0x4d, 0x2c, 0x94, 0x27, 0x90, 0x0e, 0x47, 0x2f, 0xbf, 0x71, 0xf4, 0x79, 0x36, 0x7a,
-	0x66, 0xdb, 0x20, 0xa0, 0xa5, 0x7a, 0x81, 0x03, 0xea, 0x90, 0x09, 0x6f, 0x84, 0xd0, 0x43, 0xff,
-	0xc4, 0x86, 0x33, 0x9a, 0xf2, 0x11, 0xac, 0x87, 0x03, 0x0d, 0xd5, 0x13, 0x36, 0x2a, 0x84, 0x89,
-	0xa9, 0x7c, 0x51, 0xaf, 0xe8, 0xe2, 0xda, 0x48, 0xf1, 0xc6, 0x66, 0x5e, 0x69, 0xf0, 0xfb, 0xe3,
-	0x1e, 0x64, 0x7c, 0xcb, 0x74, 0x10, 0x1d, 0x79, 0x38, 0xb0, 0xd3, 0x2c, 0x50, 0xf9, 0x55, 0x82,
-	0xa4, 0xb0, 0x67, 0x44, 0x38, 0x69, 0xb9, 0x70, 0xf1, 0x9b, 0x84, 0x4b, 0xbc, 0x95, 0x70, 0x10,
-	0x56, 0xe3, 0x17, 0x65, 0x35, 0xb1, 0x9d, 0x7d, 0x74, 0x6f, 0xc9, 0x4e, 0xa2, 0xc8, 0x8e, 0x65,
-	0x06, 0xe7, 0x2f, 0xc2, 0xaa, 0x5c, 0x48, 0x90, 0x09, 0xf3, 0x4a, 0x1d, 0x72, 0xd3, 0xca, 0xf4,
-	0x63, 0x1b, 0x99, 0x81, 0x7f, 0xca, 0x37, 0x97, 0xf7, 0xc4, 0x46, 0xa6, 0x96, 0x0d, 0x2a, 0x62,
-	0x8b, 0xe5, 0xa3, 0x88, 0xdf, 0x30, 0x8a, 0xb9, 0xd9, 0x27, 0xfe, 0xdd, 0xec, 0xe7, 0xa6, 0x24,
-	0x5f, 0x9f, 0xd2, 0x2f, 0x71, 0x48, 0xb7, 0xf9, 0xf9, 0x41, 0xf6, 0x7f, 0x72, 0x2c, 0xee, 0x42,
-	0xc6, 0x25, 0xb6, 0x2e, 0x32, 0x32, 0xcf, 0xa4, 0x5d, 0x62, 0x6b, 0x0b, 0xa3, 0x5f, 0x79, 0x57,
-	0x67, 0x26, 0xf9, 0x0e, 0x74, 0x4b, 0x5d, 0xd7, 0x8d, 0xc2, 0xaa, 0xd0, 0x22, 0x78, 0x09, 0x3d,
-	0x64, 0x22, 0xf0, 0xd7, 0x9a, 0xb4, 0xe4, 0xb5, 0x29, 0xea, 0x16, 0x50, 0x2d, 0x00, 0x32, 0x8a,
-	0xb8, 0xd5, 0x83, 0x37, 0xe1, 0xe6, 0x8d, 0xde, 0xd4, 0x02, 0x60, 0xe5, 0x7b, 0x09, 0x32, 0xbc,
-	0xd9, 0x43, 0x4c, 0xd1, 0x9c, 0x5a, 0xd2, 0x5b, 0xa8, 0xf5, 0x45, 0x58, 0x7b, 0xe2, 0x0d, 0xb5,
-	0x07, 0x27, 0x24, 0x80, 0x7f, 0xf8, 0x9b, 0x04, 0xd9, 0x88, 0xd1, 0x95, 0x87, 0x70, 0xab, 0x7e,
-	0xd0, 0xda, 0x7d, 0xae, 0x37, 0xf6, 0xf4, 0x27, 0x07, 0x3b, 0x4f, 0xf5, 0x17, 0xcd, 0xe7, 0xcd,
-	0xd6, 0x57, 0xcd, 0x42, 0xac, 0x74, 0x7b, 0x3c, 0x51, 0x95, 0x08, 0xf6, 0x85, 0x73, 0xe2, 0x90,
-	0x6f, 0x1d, 0xa5, 0x06, 0x1b, 0xf3, 0x94, 0x9d, 0x7a, 0x67, 0xbf, 0xd9, 0x2d, 0x48, 0xa5, 0x5b,
-	0xe3, 0x89, 0xba, 0x1e, 0x61, 0xec, 0xf4, 0x7c, 0xec, 0xd0, 0x45, 0xc2, 0x6e, 0xeb, 0xf0, 0xb0,
-	0xd1, 0x2d, 0xc4, 0x17, 0x08, 0xc1, 0xe5, 0x73, 0x1f, 0xd6, 0xe7, 0x09, 0xcd, 0xc6, 0x41, 0x21,
-	0x51, 0x52, 0xc6, 0x13, 0x75, 0x2d, 0x82, 0x6e, 0x5a, 0x76, 0x29, 0xfd, 0xdd, 0x0f, 0xe5, 0xd8,
-	0x4f, 0x3f, 0x96, 0x25, 0xd6, 0x59, 0x6e, 0xce, 0xeb, 0xca, 0xc7, 0x70, 0xa7, 0xd3, 0x78, 0xda,
-	0xdc, 0xdf, 0xd3, 0x0f, 0x3b, 0x4f, 0xf5, 0xee, 0xd7, 0xed, 0xfd, 0x48, 0x77, 0xf9, 0xf1, 0x44,
-	0xcd, 0x06, 0x2d, 0xdd, 0x84, 0x6e, 0x6b, 0xfb, 0x47, 0xad, 0xee, 0x7e, 0x41, 0x12, 0xe8, 0xb6,
-	0x87, 0x4f, 0x09, 0xc5, 0x1c, 0xfd, 0x00, 0x36, 0x97, 0xa0, 0xc3, 0xc6, 0xd6, 0xc7, 0x13, 0x35,
-	0xd7, 0xf6, 0xb0, 0x30, 0x01, 0x67, 0x54, 0xa1, 0xb8, 0xc8, 0x68, 0xb5, 0x5b, 0x9d, 0x9d, 0x83,
-	0x82, 0x5a, 0x2a, 0x8c, 0x27, 0xea, 0xea, 0xf4, 0x54, 0x33, 0xfc, 0xac, 0xb3, 0xba, 0xf6, 0xea,
-	0xb2, 0x2c, 0xbd, 0xbe, 0x2c, 0x4b, 0x7f, 0x5e, 0x96, 0xa5, 0x97, 0x57, 0xe5, 0xd8, 0xeb, 0xab,
-	0x72, 0xec, 0xf7, 0xab, 0x72, 0xec, 0x9b, 0xc7, 0xa6, 0x45, 0x07, 0xa3, 0x5e, 0xb5, 0x4f, 0x86,
-	0xb5, 0xe8, 0x37, 0xab, 0x49, 0x3e, 0x11, 0x4b, 0xf1, 0x89, 0x5a, 0x5b, 0xf8, 0x2e, 0xee, 0x25,
-	0x79, 0xe2, 0xd3, 0xbf, 0x03, 0x00, 0x00, 0xff, 0xff, 0xce, 0x5f, 0xb4, 0xa6, 0x33, 0x0b, 0x00,
-	0x00,
+	0x17, 0x15, 0x25, 0xea, 0x6f, 0x64, 0x5a, 0xf2, 0xc0, 0x49, 0x18, 0x25, 0x9f, 0x4c, 0xe8, 0x43,
+	0x5b, 0xa5, 0x3f, 0x52, 0x92, 0xb6, 0x68, 0xba, 0xb4, 0x6c, 0x27, 0x11, 0x62, 0x4b, 0x02, 0xa5,
+	0xa4, 0x68, 0x37, 0xc4, 0x48, 0x9c, 0x50, 0x44, 0x28, 0x0e, 0x41, 0x8e, 0x5c, 0xfb, 0x0d, 0x0a,
+	0xa1, 0x8b, 0xbc, 0x80, 0x56, 0xed, 0xa2, 0xeb, 0x3e, 0x42, 0x57, 0x59, 0x66, 0xd7, 0xae, 0xdc,
+	0xc2, 0xde, 0x75, 0xd3, 0x57, 0x28, 0x66, 0x86, 0xa2, 0x28, 0x4b, 0x46, 0xd0, 0x26, 0xe8, 0xc6,
+	0xd0, 0xbd, 0xf7, 0x9c, 0xf1, 0xbd, 0xe7, 0x9e, 0x21, 0x09, 0xfe, 0xf7, 0x02, 0xf9, 0xa6, 0x8d,
+	0x86, 0x23, 0x64, 0xbb, 0x0d, 0x7a, 0xea, 0xe1, 0x40, 0xfc, 0xad, 0x7b, 0x3e, 0xa1, 0x04, 0x6e,
+	0xc5, 0xca, 0x75, 0x5e, 0x28, 0x6f, 0x5b, 0xc4, 0x22, 0xbc, 0xda, 0x60, 0xbf, 0x04, 0xb0, 0x5c,
+	0x89, 0x9f, 0x33, 0xf4, 0x4f, 0x3d, 0x4a, 0x1a, 0x9e, 0x4f, 0xc8, 0xf3, 0xb0, 0xbe, 0x63, 0x11,
+	0x62, 0x39, 0xb8, 0xc1, 0xa3, 0xc1, 0xe4, 0x79, 0x83, 0xda, 0x63, 0x1c, 0x50, 0x34, 0xf6, 0x04,
+	0xa0, 0xfa, 0x25, 0x50, 0xba, 0xc8, 0xa7, 0x3d, 0x4c, 0x1f, 0x63, 0x64, 0x62, 0x1f, 0x6e, 0x83,
+	0x34, 0x25, 0x14, 0x39, 0xaa, 0xa4, 0x49, 0x35, 0x45, 0x17, 0x01, 0x84, 0x40, 0x1e, 0xa1, 0x60,
+	0xa4, 0x26, 0x35, 0xa9, 0xb6, 0xa1, 0xf3, 0xdf, 0x55, 0x1b, 0xc8, 0x8c, 0xca, 0x18, 0xb6, 0x6b,
+	0xe2, 0x93, 0x39, 0x83, 0x07, 0x2c, 0x3b, 0x38, 0xa5, 0x38, 0x08, 0x29, 0x22, 0x80, 0x9f, 0x83,
+	0x34, 0x6f, 0x4f, 0x4d, 0x69, 0x52, 0xad, 0x70, 0xff, 0x66, 0x3d, 0x3e, 0xa8, 0xe8, 0xbf, 0xde,
+	0x65, 0x80, 0xa6, 0xfc, 0xea, 0x6c, 0x27, 0xa1, 0x0b, 0x74, 0x75, 0x0c, 0xb2, 0x4d, 0x87, 0x0c,
+	0x5f, 0xb4, 0xf6, 0xa3, 0x4e, 0xa4, 0x45, 0x27, 0xb0, 0x0d, 0x8a, 0x1e, 0xf2, 0xa9, 0x11, 0x60,
+	0x6a, 0x8c, 0xf8, 0x18, 0xfc, 0xbf, 0x16, 0xee, 0x6b, 0xf5, 0x15, 0x21, 0xeb, 0x4b, 0xe3, 0x86,
+	0xff, 0x46, 0xf1, 0xe2, 0xc9, 0xea, 0xcf, 0x32, 0xc8, 0x84, 0x72, 0xbc, 0x0f, 0x72, 0x9c, 0x6c,
+	0xd8, 0x26, 0x3f, 0x33, 0xdf, 0x2c, 0x9c, 0x9f, 0xed, 0x64, 0xf7, 0x58, 0xae, 0xb5, 0xaf, 0x67,
+	0x79, 0xb1, 0x65, 0xc2, 0xeb, 0x20, 0x33, 0xc2, 0xb6, 0x35, 0xa2, 0x7c, 0x32, 0x59, 0x0f, 0x23,
+	0x78, 0x0b, 0xe4, 0x2d, 0x14, 0x18, 0x8e, 0x3d, 0xb6, 0xa9, 0x5a, 0xe4, 0xa5, 0x9c, 0x85, 0x82,
+	0x43, 0x16, 0xc3, 0x07, 0x40, 0x66, 0xfb, 0x50, 0x65, 0xde, 0x6c, 0xb9, 0x2e, 0x96, 0x55, 0x9f,
+	0x2f, 0xab, 0xde, 0x9f, 0x2f, 0xab, 0x99, 0x63, 0x6d, 0xbe, 0xfc, 0x7d, 0x47, 0xd2, 0x39, 0x03,
+	0xee, 0x03, 0xc5, 0x41, 0x01, 0x35, 0x06, 0x4c, 0x15, 0xd6, 0x5b, 0x3a, 0x3c, 0x62, 0x75, 0xde,
+	0x50, 0xb8, 0x70, 0xd2, 0x02, 0xa3, 0x89, 0x94, 0x09, 0x6b, 0xa0, 0xc4, 0x4f, 0x19, 0x92, 0xf1,
+	0xd8, 0xa6, 0x06, 0xd7, 0x35, 0xc3, 0x75, 0xdd, 0x64, 0xf9, 0x3d, 0x9e, 0x7e, 0xcc, 0x14, 0xbe,
+	0x05, 0xf2, 0x26, 0xa2, 0x48, 0x40, 0xb2, 0x1c, 0x92, 0x63, 0x09, 0x5e, 0xfc, 0x00, 0x14, 0x8f,
+	0x91, 0x63, 0x9b, 0x88, 0x12, 0x3f, 0x10, 0x90, 0x9c, 0x38, 0x65, 0x91, 0xe6, 0xc0, 0xbb, 0x60,
+	0xdb, 0xc5, 0x27, 0xd4, 0xb8, 0x8c, 0xce, 0x73, 0x34, 0x64, 0xb5, 0x67, 0xcb, 0x8c, 0xf7, 0xc0,
+	0xe6, 0x90, 0xb8, 0x01, 0x76, 0x83, 0x49, 0x88, 0x05, 0x1c, 0xab, 0x44, 0x59, 0x0e, 0xbb, 0x09,
+	0x72, 0xc8, 0xf3, 0x04, 0xa0, 0xc0, 0x01, 0x59, 0xe4, 0x79, 0xbc, 0xf4, 0x7f, 0xa0, 0xe0, 0x63,
+	0xdb, 0xc4, 0xee, 0x10, 0x8b, 0xba, 0xc2, 0xeb, 0x1b, 0xf3, 0x24, 0x07, 0xdd, 0x01, 0x25, 0xcf,
+	0x27, 0x1e, 0x09, 0xb0, 0x6f, 0x20, 0xd3, 0xf4, 0x71, 0x10, 0xa8, 0x9b, 0x1c, 0x57, 0x9c, 0xe7,
+	0x77, 0x45, 0x1a, 0xde, 0x00, 0x59, 0x77, 0x32, 0x36, 0xe8, 0x49, 0xa0, 0x96, 0xc4, 0xa6, 0xdd,
+	0xc9, 0xb8, 0x7f, 0x12, 0x54, 0xff, 0x4c, 0x02, 0xf9, 0x19, 0xa1, 0x18, 0x7e, 0x06, 0x64, 0xa6,
+	0x3c, 0x77, 0xe8, 0xe6, 0x5a, 0x0b, 0xf6, 0x6c, 0xcb, 0xc5, 0xe6, 0x51, 0x60, 0xf5, 0x4f, 0x3d,
+	0xac, 0x73, 0x74, 0xcc, 0x40, 0xc9, 0x25, 0x03, 0x6d, 0x83, 0xb4, 0x4f, 0x26, 0xae, 0xc9, 0x7d,
+	0xa5, 0xe8, 0x22, 0x80, 0x0f, 0x41, 0x2e, 0x5a, 0xbd, 0xfc, 0xc6, 0xd5, 0x17, 0xd9, 0xea, 0x99,
+	0x6d, 0xc3, 0x84, 0x9e, 0x1d, 0x84, 0x0e, 0x68, 0x82, 0x7c, 0xf4, 0x44, 0x50, 0xd3, 0xff, 0xc0,
+	0x86, 0x0b, 0x1a, 0xfc, 0x08, 0x6c, 0x45, 0x0b, 0x8d, 0xd4, 0x13, 0x36, 0x2a, 0x45, 0x85, 0xb9,
+	0x7c, 0x71, 0xaf, 0x18, 0xe2, 0xb1, 0x91, 0xe5, 0x83, 0x2d, 0xbc, 0xd2, 0x62, 0x59, 0x78, 0x1b,
+	0xe4, 0x03, 0xdb, 0x72, 0x11, 0x9d, 0xf8, 0x38, 0xb4, 0xd3, 0x22, 0x51, 0xfd, 0x45, 0x02, 0x19,
+	0x61, 0xcf, 0x98, 0x70, 0xd2, 0x7a, 0xe1, 0x92, 0x57, 0x09, 0x97, 0x7a, 0x2b, 0xe1, 0x40, 0xd4,
+	0x4d, 0xa0, 0xca, 0x5a, 0xaa, 0x56, 0xb8, 0x7f, 0x7b, 0xcd, 0x49, 0xa2, 0xc9, 0x9e, 0x6d, 0x85,
+	0xf7, 0x2f, 0xc6, 0xaa, 0x9e, 0x49, 0x20, 0x1f, 0xd5, 0x61, 0x13, 0x28, 0xf3, 0xce, 0x8c, 0xe7,
+	0x0e, 0xb2, 0x42, 0xff, 0x54, 0xae, 0x6e, 0xef, 0xa1, 0x83, 0x2c, 0xbd, 0x10, 0x76, 0xc4, 0x82,
+	0xf5, 0xab, 0x48, 0x5e, 0xb1, 0x8a, 0xa5, 0xdd, 0xa7, 0xfe, 0xdd, 0xee, 0x97, 0xb6, 0x24, 0x5f,
+	0xde, 0xd2, 0x5f, 0x49, 0x90, 0xeb, 0xf2, 0xfb, 0x83, 0x9c, 0xff, 0xe4, 0x5a, 0xdc, 0x02, 0x79,
+	0x8f, 0x38, 0x86, 0xa8, 0xc8, 0xbc, 0x92, 0xf3, 0x88, 0xa3, 0xaf, 0xac, 0x3e, 0xfd, 0xae, 0xee,
+	0x4c, 0xe6, 0x1d, 0xe8, 0x96, 0xbd, 0xa4, 0x1b, 0x54, 0x41, 0xf6, 0x18, 0xfb, 0x81, 0x4d, 0x5c,
+	0xee, 0x7c, 0x45, 0x9f, 0x87, 0xb0, 0xb2, 0x64, 0xbb, 0xbc, 0x96, 0xaa, 0x6d, 0x2c, 0x59, 0x8a,
+	0x82, 0x0d, 0xa1, 0x62, 0xf8, 0xfa, 0xba, 0xc7, 0xe4, 0x63, 0xbf, 0x54, 0x69, 0xcd, 0x0b, 0x57,
+	0x4c, 0x2c, 0xa0, 0x7a, 0x66, 0x14, 0x51, 0xc4, 0xfb, 0x40, 0x4d, 0x5e, 0x49, 0x11, 0xae, 0xd5,
+	0x43, 0x60, 0xf5, 0x7b, 0x09, 0xe4, 0xb9, 0x4c, 0x47, 0x98, 0xa2, 0x25, 0x9d, 0xa5, 0xb7, 0xd0,
+	0xf9, 0x8b, 0xa8, 0xf7, 0xd4, 0x1b, 0x7a, 0x0f, 0xef, 0x56, 0x08, 0xff, 0xf0, 0x57, 0x09, 0x14,
+	0x62, 0x57, 0x04, 0xde, 0x03, 0xd7, 0x9a, 0x87, 0x9d, 0xbd, 0x27, 0x46, 0x6b, 0xdf, 0x78, 0x78,
+	0xb8, 0xfb, 0xc8, 0x78, 0xda, 0x7e, 0xd2, 0xee, 0x7c, 0xd5, 0x2e, 0x25, 0xca, 0xd7, 0xa7, 0x33,
+	0x0d, 0xc6, 0xb0, 0x4f, 0xdd, 0x17, 0x2e, 0xf9, 0xd6, 0x85, 0x0d, 0xb0, 0xbd, 0x4c, 0xd9, 0x6d,
+	0xf6, 0x0e, 0xda, 0xfd, 0x92, 0x54, 0xbe, 0x36, 0x9d, 0x69, 0x5b, 0x31, 0xc6, 0xee, 0x20, 0xc0,
+	0x2e, 0x5d, 0x25, 0xec, 0x75, 0x8e, 0x8e, 0x5a, 0xfd, 0x52, 0x72, 0x85, 0x10, 0x3e, 0xb6, 0xee,
+	0x80, 0xad, 0x65, 0x42, 0xbb, 0x75, 0x58, 0x4a, 0x95, 0xe1, 0x74, 0xa6, 0x6d, 0xc6, 0xd0, 0x6d,
+	0xdb, 0x29, 0xe7, 0xbe, 0xfb, 0xa1, 0x92, 0xf8, 0xe9, 0xc7, 0x8a, 0xc4, 0x26, 0x53, 0x96, 0x6e,
+	0x09, 0xfc, 0x18, 0xdc, 0xe8, 0xb5, 0x1e, 0xb5, 0x0f, 0xf6, 0x8d, 0xa3, 0xde, 0x23, 0xa3, 0xff,
+	0x75, 0xf7, 0x20, 0x36, 0x5d, 0x71, 0x3a, 0xd3, 0x0a, 0xe1, 0x48, 0x57, 0xa1, 0xbb, 0xfa, 0xc1,
+	0xb3, 0x4e, 0xff, 0xa0, 0x24, 0x09, 0x74, 0xd7, 0xc7, 0xc7, 0x84, 0x62, 0x8e, 0xbe, 0x0b, 0x6e,
+	0xae, 0x41, 0x47, 0x83, 0x6d, 0x4d, 0x67, 0x9a, 0xd2, 0xf5, 0xb1, 0x30, 0x01, 0x67, 0xd4, 0x81,
+	0xba, 0xca, 0xe8, 0x74, 0x3b, 0xbd, 0xdd, 0xc3, 0x92, 0x56, 0x2e, 0x4d, 0x67, 0xda, 0xc6, 0xfc,
+	0x79, 0xc0, 0xf0, 0x8b, 0xc9, 0x9a, 0xfa, 0xab, 0xf3, 0x8a, 0xf4, 0xfa, 0xbc, 0x22, 0xfd, 0x71,
+	0x5e, 0x91, 0x5e, 0x5e, 0x54, 0x12, 0xaf, 0x2f, 0x2a, 0x89, 0xdf, 0x2e, 0x2a, 0x89, 0x6f, 0x1e,
+	0x58, 0x36, 0x1d, 0x4d, 0x06, 0xf5, 0x21, 0x19, 0x37, 0xe2, 0x5f, 0xbb, 0x16, 0xf9, 0x44, 0x84,
+	0xe2, 0xe3, 0xb6, 0xb1, 0xf2, 0x45, 0x3d, 0xc8, 0xf0, 0xc2, 0xa7, 0x7f, 0x0f, 0x00, 0x8f, 0x93,
+	0xda, 0xaf, 0x6d, 0x0b, 0x00, 0x00,
 }
 
 func (m *PartSetHeader) Marshal() (dAtA []byte, err error) {
@@ -1387,6 +1404,20 @@ func (m *Proposal) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Signatures) > 0 {
+		for iNdEx := len(m.Signatures) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Signatures[iNdEx])
+			copy(dAtA[i:], m.Signatures[iNdEx])
+			i = encodeVarintTypes(dAtA, i, uint64(len(m.Signatures[iNdEx])))
+			i--
+			dAtA[i] = 0x4a
+		}
+	}
+	if m.Version != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.Version))
+		i--
+		dAtA[i] = 0x40
+	}
 	if len(m.Signature) > 0 {
 		i -= len(m.Signature)
 		copy(dAtA[i:], m.Signature)
@@ -1747,6 +1778,15 @@ func (m *Proposal) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTypes(uint64(l))
 	}
+	if m.Version != 0 {
+		n += 1 + sovTypes(uint64(m.Version))
+	}
+	if len(m.Signatures) > 0 {
+		for _, b := range m.Signatures {
+			l = len(b)
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -3409,6 +3449,57 @@ func (m *Proposal) Unmarshal(dAtA []byte) error {
 				m.Signature = []byte{}
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Signatures", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Signatures = append(m.Signatures, make([]byte, postIndex-iNdEx))
+			copy(m.Signatures[len(m.Signatures)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
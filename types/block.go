@@ -19,6 +19,7 @@
 package types
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -664,14 +665,44 @@ func NewZeroBlockID() BlockID {
 	return BlockID{}
 }
 
-func (blockID *BlockID) IsZero() bool {
+// IsZero reports whether the BlockID is the zero BlockID, which stands for
+// no block, e.g. in a nil vote or a proposal without a POL.
+func (blockID BlockID) IsZero() bool {
 	return blockID.Hash.IsZero() && blockID.PartsHeader.IsZero()
 }
 
-func (blockID *BlockID) Equal(other BlockID) bool {
+// Equal reports whether both BlockIDs have the same hash and parts header.
+func (blockID BlockID) Equal(other BlockID) bool {
 	return blockID.Hash.Equal(other.Hash) && blockID.PartsHeader.Equals(other.PartsHeader)
 }
 
+// blockIDJSON is the JSON form of a BlockID.
+type blockIDJSON struct {
+	Hash        common.Hash   `json:"hash"`
+	PartsHeader PartSetHeader `json:"parts"`
+}
+
+// MarshalJSON encodes the BlockID with hex hashes. The zero BlockID is encoded
+// like any other, so that it decodes back to the zero BlockID.
+func (blockID BlockID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(blockIDJSON(blockID))
+}
+
+// UnmarshalJSON decodes a BlockID encoded by MarshalJSON. null decodes to the
+// zero BlockID.
+func (blockID *BlockID) UnmarshalJSON(input []byte) error {
+	if string(input) == "null" {
+		*blockID = BlockID{}
+		return nil
+	}
+	var dec blockIDJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*blockID = BlockID(dec)
+	return nil
+}
+
 // Key returns a machine-readable string representation of the BlockID
 func (blockID *BlockID) Key() string {
 	return string(blockID.Hash.String() + blockID.PartsHeader.Hash.String())
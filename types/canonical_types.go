@@ -58,6 +58,7 @@ func CreateCanonicalProposal(chainID string, proposal *kproto.Proposal) kproto.C
 		BlockID:   CanonicalizeBlockID(proposal.BlockID),
 		Timestamp: proposal.Timestamp,
 		ChainID:   chainID,
+		Version:   proposal.Version,
 	}
 }
 
@@ -69,6 +69,13 @@ func (e ErrNotEnoughVotingPowerSigned) Error() string {
 	return fmt.Sprintf("invalid commit -- insufficient voting power: got %d, needed more than %d", e.Got, e.Needed)
 }
 
+// Proposal
+
+var (
+	ErrProposalInvalidSignature    = errors.New("invalid proposal signature")
+	ErrProposalNotEnoughSignatures = errors.New("not enough proposal signatures")
+)
+
 // Vote
 
 var (
@@ -37,3 +37,13 @@ type ErrEvidenceAlreadyStored struct{}
 func (e ErrEvidenceAlreadyStored) Error() string {
 	return "evidence is already stored"
 }
+
+// ErrEvidenceListTooLarge returns when a message carries more evidence than allowed
+type ErrEvidenceListTooLarge struct {
+	Got int
+	Max int
+}
+
+func (e ErrEvidenceListTooLarge) Error() string {
+	return fmt.Sprintf("evidence list is too large: %d, max: %d", e.Got, e.Max)
+}
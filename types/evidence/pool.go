@@ -167,9 +167,10 @@ func (evpool *Pool) Size() uint32 {
 // than set by the evidence consensus parameters
 func (evpool *Pool) isExpired(height uint64, time time.Time) bool {
 	var (
-		params       = evpool.State().ConsensusParams.Evidence
-		ageDuration  = evpool.State().LastBlockTime.Sub(time)
-		ageNumBlocks = evpool.State().LastBlockHeight - height
+		state        = evpool.StateSnapshot()
+		params       = state.EvidenceParams
+		ageDuration  = state.LastBlockTime.Sub(time)
+		ageNumBlocks = state.LastBlockHeight - height
 	)
 	return ageNumBlocks > uint64(params.MaxAgeNumBlocks) &&
 		ageDuration > params.MaxAgeDuration
@@ -195,6 +196,19 @@ func (evpool *Pool) isCommitted(evidence types.Evidence) bool {
 	return ok
 }
 
+// EvidenceInRange returns the pending evidence with a height between from and
+// to inclusive, in the order it was added to the pool.
+func (evpool *Pool) EvidenceInRange(from, to uint64) []types.Evidence {
+	var evidence []types.Evidence
+	for e := evpool.evidenceList.Front(); e != nil; e = e.Next() {
+		ev := e.Value.(types.Evidence)
+		if height := ev.Height(); height >= from && height <= to {
+			evidence = append(evidence, ev)
+		}
+	}
+	return evidence
+}
+
 // EvidenceFront ...
 func (evpool *Pool) EvidenceFront() *clist.CElement {
 	return evpool.evidenceList.Front()
@@ -223,6 +237,26 @@ func (evpool *Pool) State() cstate.LatestBlockState {
 	return evpool.state
 }
 
+// StateSnapshot is the part of the pool's state which decides how old evidence
+// may be, read at once.
+type StateSnapshot struct {
+	LastBlockHeight uint64
+	LastBlockTime   time.Time
+	EvidenceParams  kproto.EvidenceParams
+}
+
+// StateSnapshot returns the height, time and evidence parameters of the
+// current state, consistent with each other.
+func (evpool *Pool) StateSnapshot() StateSnapshot {
+	evpool.mtx.Lock()
+	defer evpool.mtx.Unlock()
+	return StateSnapshot{
+		LastBlockHeight: evpool.state.LastBlockHeight,
+		LastBlockTime:   evpool.state.LastBlockTime,
+		EvidenceParams:  evpool.state.ConsensusParams.Evidence,
+	}
+}
+
 func (evpool *Pool) removeEvidenceFromList(
 	blockEvidenceMap map[string]struct{}) {
 
@@ -293,10 +327,10 @@ func (evpool *Pool) removeExpiredPendingEvidence() (uint64, time.Time) {
 			if len(blockEvidenceMap) != 0 {
 				evpool.removeEvidenceFromList(blockEvidenceMap)
 			}
-			maxAgeNumBlocks := evpool.State().ConsensusParams.Evidence.MaxAgeNumBlocks
+			params := evpool.StateSnapshot().EvidenceParams
 			// return the height and time with which this evidence will have expired so we know when to prune next
-			return ev.Height() + uint64(maxAgeNumBlocks) + 1,
-				ev.Time().Add(evpool.State().ConsensusParams.Evidence.MaxAgeDuration).Add(time.Second)
+			return ev.Height() + uint64(params.MaxAgeNumBlocks) + 1,
+				ev.Time().Add(params.MaxAgeDuration).Add(time.Second)
 		}
 		evpool.removePendingEvidence(ev)
 		blockEvidenceMap[evMapKey(ev)] = struct{}{}
@@ -305,7 +339,8 @@ func (evpool *Pool) removeExpiredPendingEvidence() (uint64, time.Time) {
 	if len(blockEvidenceMap) != 0 {
 		evpool.removeEvidenceFromList(blockEvidenceMap)
 	}
-	return evpool.State().LastBlockHeight, evpool.State().LastBlockTime
+	state := evpool.StateSnapshot()
+	return state.LastBlockHeight, state.LastBlockTime
 }
 
 // AddEvidence checks the evidence is valid and adds it to the pool.
@@ -119,3 +119,33 @@ func TestEvidencePool(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, pool.evidenceList.Len())
 }
+
+func TestPoolStateSnapshotConsistent(t *testing.T) {
+	stateAt := func(height uint64) cState.LatestBlockState {
+		var state cState.LatestBlockState
+		state.LastBlockHeight = height
+		state.LastBlockTime = defaultEvidenceTime.Add(time.Duration(height) * time.Second)
+		state.ConsensusParams.Evidence.MaxAgeNumBlocks = int64(height)
+		return state
+	}
+	evpool := &Pool{state: stateAt(1)}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for height := uint64(2); height <= 1000; height++ {
+			evpool.updateState(stateAt(height))
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		snapshot := evpool.StateSnapshot()
+		require.Equal(t, stateAt(snapshot.LastBlockHeight).LastBlockTime, snapshot.LastBlockTime)
+		require.EqualValues(t, snapshot.LastBlockHeight, snapshot.EvidenceParams.MaxAgeNumBlocks)
+	}
+	assert.EqualValues(t, 1000, evpool.StateSnapshot().LastBlockHeight)
+}
@@ -19,10 +19,15 @@
 package evidence
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/kardiachain/go-kardia/lib/clist"
+	"github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/common/lru"
 	"github.com/kardiachain/go-kardia/lib/log"
 
 	"github.com/kardiachain/go-kardia/lib/p2p"
@@ -33,26 +38,155 @@ import (
 )
 
 const (
-	EvidenceChannel = byte(0x38)
+	EvidenceChannel        = byte(0x38)
+	EvidenceRequestChannel = byte(0x39)
 
-	maxMsgSize = 1048576 // 1MB TODO make it configurable
+	maxMsgSize        = 1048576 // 1MB TODO make it configurable
+	maxRequestMsgSize = 32      // an EvidenceRequest with two varint heights
+
+	maxRequestedEvidence     = 256 // maximum number of evidence sent in answer to a request
+	evidenceRequestIntervalS = 10  // requests from a peer are answered at most this often
+
+	defaultMaxEvidenceListSize  = 128 // maximum number of evidence accepted in a single message
+	defaultMaxBroadcastEvidence = 256 // maximum number of evidence sent to a peer per broadcast interval
+
+	pushedEvidenceCacheSize = 4096 // evidence whose pushes are tracked for the gossip fanout
+
+	defaultChannelPriority = 6
+	maxChannelPriority     = 20
+
+	minRecvMessageCapacity = 1024     // 1KB
+	maxRecvMessageCapacity = 22020096 // 21MB, the connection default
 
 	broadcastEvidenceIntervalS = 10 // broadcast uncommitted evidence this often
 	peerRetryMessageIntervalMS = 100
+	evidenceWaitIntervalS      = 1   // re-check the peer and reactor while the pool is empty this often
+	peerCatchupIntervalMS      = 500 // start from the beginning this often until a new peer reports its height
 )
 
 // Reactor handles evpool evidence broadcasting amongst peers.
 type Reactor struct {
 	p2p.BaseReactor
 	evpool *Pool
+
+	maxEvidenceListSize int
+	codec               EvidenceCodec
+
+	channelPriority     int
+	recvMessageCapacity int
+
+	gossipBudget         int // evidence bytes gossiped to a peer per interval, 0 for no limit
+	gossipBudgetInterval time.Duration
+	maxBroadcastEvidence int  // evidence sent to a peer per broadcast interval
+	strictValidation     bool // stop peers sending invalid evidence
+
+	fanout    int // peers each evidence is pushed to, 0 for all
+	pushedMtx sync.Mutex
+	pushed    *lru.Cache[common.Hash, map[p2p.ID]struct{}] // peers each evidence was pushed to
+
+	requestsMtx  sync.Mutex
+	lastRequests map[p2p.ID]time.Time // when the last answered evidence request of each peer came in
+
+	done     chan struct{}  // closed on stop, before the quit channel
+	routines sync.WaitGroup // running broadcast routines
+}
+
+// ReactorOption sets an optional parameter on the Reactor.
+type ReactorOption func(*Reactor)
+
+// WithMaxEvidenceListSize sets the maximum number of evidence a peer may send
+// in a single message.
+func WithMaxEvidenceListSize(size int) ReactorOption {
+	return func(evR *Reactor) { evR.maxEvidenceListSize = size }
+}
+
+// WithEvidenceCodec sets the codec used to encode and decode evidence messages.
+// The proto codec is used by default.
+func WithEvidenceCodec(codec EvidenceCodec) ReactorOption {
+	return func(evR *Reactor) { evR.codec = codec }
+}
+
+// WithChannelPriority sets the priority of the evidence channel relative to
+// the other channels of the switch.
+func WithChannelPriority(priority int) ReactorOption {
+	return func(evR *Reactor) { evR.channelPriority = priority }
+}
+
+// WithRecvMessageCapacity sets the maximum size of a message received on the
+// evidence channel.
+func WithRecvMessageCapacity(capacity int) ReactorOption {
+	return func(evR *Reactor) { evR.recvMessageCapacity = capacity }
+}
+
+// WithGossipBudget limits the evidence gossiped to each peer to budget bytes
+// per interval. Gossip to a peer pauses once its budget is spent and resumes
+// in the next interval. A zero budget disables the limit.
+func WithGossipBudget(budget int, interval time.Duration) ReactorOption {
+	return func(evR *Reactor) {
+		evR.gossipBudget = budget
+		evR.gossipBudgetInterval = interval
+	}
+}
+
+// WithMaxBroadcastEvidence sets the maximum number of evidence sent to each
+// peer per broadcast interval. The rest is sent in the following intervals.
+func WithMaxBroadcastEvidence(max int) ReactorOption {
+	return func(evR *Reactor) { evR.maxBroadcastEvidence = max }
+}
+
+// WithStrictEvidenceValidation sets whether a peer sending invalid evidence is
+// stopped, the default, or the evidence is only logged and dropped. Messages
+// which cannot be decoded or hold too much evidence always stop the peer.
+func WithStrictEvidenceValidation(strict bool) ReactorOption {
+	return func(evR *Reactor) { evR.strictValidation = strict }
+}
+
+// WithGossipFanout limits the peers each evidence is pushed to, the others
+// relying on it being gossiped on by those peers. A zero fanout pushes all
+// evidence to every peer.
+func WithGossipFanout(fanout int) ReactorOption {
+	return func(evR *Reactor) { evR.fanout = fanout }
 }
 
 // NewReactor returns a new Reactor with the given config and evpool.
-func NewReactor(evpool *Pool) *Reactor {
+// It panics if the configured channel priority or capacity is out of range.
+func NewReactor(evpool *Pool, options ...ReactorOption) *Reactor {
 	evR := &Reactor{
-		evpool: evpool,
+		evpool:               evpool,
+		maxEvidenceListSize:  defaultMaxEvidenceListSize,
+		maxBroadcastEvidence: defaultMaxBroadcastEvidence,
+		strictValidation:     true,
+		channelPriority:      defaultChannelPriority,
+		recvMessageCapacity:  maxMsgSize,
+		lastRequests:         make(map[p2p.ID]time.Time),
+		done:                 make(chan struct{}),
 	}
 	evR.BaseReactor = *p2p.NewBaseReactor("Evidence", evR)
+	for _, option := range options {
+		option(evR)
+	}
+	if evR.codec == nil {
+		evR.codec = protoCodec{maxListSize: evR.maxEvidenceListSize}
+	}
+	if evR.channelPriority < 1 || evR.channelPriority > maxChannelPriority {
+		panic(fmt.Sprintf("evidence channel priority %d out of range [1, %d]", evR.channelPriority, maxChannelPriority))
+	}
+	if evR.recvMessageCapacity < minRecvMessageCapacity || evR.recvMessageCapacity > maxRecvMessageCapacity {
+		panic(fmt.Sprintf("evidence channel capacity %d out of range [%d, %d]",
+			evR.recvMessageCapacity, minRecvMessageCapacity, maxRecvMessageCapacity))
+	}
+	if evR.gossipBudget < 0 || (evR.gossipBudget > 0 && evR.gossipBudgetInterval <= 0) {
+		panic(fmt.Sprintf("invalid evidence gossip budget %d per %v", evR.gossipBudget, evR.gossipBudgetInterval))
+	}
+	if evR.maxBroadcastEvidence < 1 {
+		panic(fmt.Sprintf("invalid maximum broadcast evidence %d", evR.maxBroadcastEvidence))
+	}
+	if evR.fanout < 0 {
+		panic(fmt.Sprintf("invalid evidence gossip fanout %d", evR.fanout))
+	}
+	if evR.fanout > 0 {
+		evR.pushed = lru.NewCache[common.Hash, map[p2p.ID]struct{}](pushedEvidenceCacheSize)
+	}
 	return evR
 }
 
@@ -68,32 +202,88 @@ func (evR *Reactor) GetChannels() []*p2p.ChannelDescriptor {
 	return []*p2p.ChannelDescriptor{
 		{
 			ID:                  EvidenceChannel,
-			Priority:            6,
-			RecvMessageCapacity: maxMsgSize,
+			Priority:            evR.channelPriority,
+			RecvMessageCapacity: evR.recvMessageCapacity,
 			RecvBufferCapacity:  4096,
 		},
+		{
+			ID:                  EvidenceRequestChannel,
+			Priority:            evR.channelPriority,
+			RecvMessageCapacity: maxRequestMsgSize,
+		},
 	}
 }
 
+// OnStop implements service.Service by stopping the broadcast routines and
+// waiting for them to return.
+func (evR *Reactor) OnStop() {
+	close(evR.done)
+	evR.routines.Wait()
+}
+
 // AddPeer implements Reactor.
 func (evR *Reactor) AddPeer(peer p2p.Peer) {
-	go evR.broadcastEvidenceRoutine(peer)
+	evR.routines.Add(1)
+	go func() {
+		defer evR.routines.Done()
+		evR.broadcastEvidenceRoutine(peer)
+	}()
+}
+
+// RemovePeer implements Reactor by forgetting the peer's evidence requests and
+// freeing its slots in the gossip fanout, so the evidence it was pushed is
+// pushed to another peer.
+func (evR *Reactor) RemovePeer(peer p2p.Peer, reason interface{}) {
+	evR.requestsMtx.Lock()
+	delete(evR.lastRequests, peer.ID())
+	evR.requestsMtx.Unlock()
+
+	if evR.pushed == nil {
+		return
+	}
+	evR.pushedMtx.Lock()
+	defer evR.pushedMtx.Unlock()
+	for _, hash := range evR.pushed.Keys() {
+		if peers, ok := evR.pushed.Peek(hash); ok {
+			delete(peers, peer.ID())
+		}
+	}
 }
 
 // Receive implements Reactor.
 // It adds any received evidence to the evpool.
 func (evR *Reactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
-	evis, err := decodeMsg(msgBytes)
+	switch chID {
+	case EvidenceChannel:
+	case EvidenceRequestChannel:
+		evR.receiveRequest(src, msgBytes)
+		return
+	default:
+		evR.Logger.Error(fmt.Sprintf("Unknown chId %X", chID), "src", src)
+		return
+	}
+	evis, err := evR.codec.Decode(msgBytes)
 	if err != nil {
 		evR.Logger.Error("Error decoding message", "src", src, "chId", chID, "err", err, "bytes", msgBytes)
 		evR.Switch.StopPeerForError(src, err)
 		return
 	}
+	if err := validateEvidenceList(evis, evR.maxEvidenceListSize); err != nil {
+		evR.Logger.Error("Invalid evidence message", "src", src, "chId", chID, "err", err, "bytes", msgBytes)
+		var tooLarge ErrEvidenceListTooLarge
+		if evR.strictValidation || errors.As(err, &tooLarge) {
+			evR.Switch.StopPeerForError(src, err)
+		}
+		return
+	}
 	for _, ev := range evis {
 		err := evR.evpool.AddEvidence(ev)
 		switch err.(type) {
 		case *types.ErrEvidenceInvalid:
-			evR.Logger.Error(err.Error())
+			evR.Logger.Error(err.Error(), "src", src)
+			if !evR.strictValidation {
+				continue
+			}
 			// punish peer
 			evR.Switch.StopPeerForError(src, err)
 			return
@@ -105,50 +295,167 @@ func (evR *Reactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 	}
 }
 
+// RequestEvidence asks peer for the evidence it has pending between the given
+// heights, which lets a recovering node catch up without waiting for the
+// next broadcast cycle. Returns whether the request was sent.
+func (evR *Reactor) RequestEvidence(peer p2p.Peer, fromHeight, toHeight uint64) bool {
+	msg := &EvidenceRequestMessage{FromHeight: fromHeight, ToHeight: toHeight}
+	if err := msg.ValidateBasic(); err != nil {
+		evR.Logger.Error("Invalid evidence request", "err", err)
+		return false
+	}
+	bz, err := msg.ToProto().Marshal()
+	if err != nil {
+		evR.Logger.Error("Failed to encode evidence request", "err", err)
+		return false
+	}
+	return peer.Send(EvidenceRequestChannel, bz)
+}
+
+// receiveRequest answers an EvidenceRequestMessage with the pending evidence in
+// the requested range, split into lists no peer would reject as too large.
+// Requests coming in less than evidenceRequestIntervalS after the last one
+// answered for the peer are ignored, and at most maxRequestedEvidence are sent
+// back. The answer is dropped rather than blocking the receive routine if the
+// peer's send queue is full.
+func (evR *Reactor) receiveRequest(src p2p.Peer, msgBytes []byte) {
+	pb := &ep.EvidenceRequest{}
+	err := pb.Unmarshal(msgBytes)
+	msg := EvidenceRequestMessageFromProto(pb)
+	if err == nil {
+		err = msg.ValidateBasic()
+	}
+	if err != nil {
+		evR.Logger.Error("Error decoding evidence request", "src", src, "err", err, "bytes", msgBytes)
+		evR.Switch.StopPeerForError(src, err)
+		return
+	}
+	if !evR.allowRequest(src.ID(), time.Now()) {
+		evR.Logger.Debug("Ignoring evidence request, the last one was answered recently", "src", src)
+		return
+	}
+
+	evis := evR.evpool.EvidenceInRange(msg.FromHeight, msg.ToHeight)
+	evR.Logger.Debug("Received evidence request", "src", src,
+		"from", msg.FromHeight, "to", msg.ToHeight, "evidence", len(evis))
+	if len(evis) > maxRequestedEvidence {
+		evis = evis[:maxRequestedEvidence]
+	}
+	for len(evis) > 0 {
+		n := len(evis)
+		if n > evR.maxEvidenceListSize {
+			n = evR.maxEvidenceListSize
+		}
+		if !evR.sendEvidence(src, evis[:n]) {
+			return
+		}
+		evis = evis[n:]
+	}
+}
+
+// allowRequest reports whether an evidence request from the peer at now is to
+// be answered, recording it if so.
+func (evR *Reactor) allowRequest(id p2p.ID, now time.Time) bool {
+	evR.requestsMtx.Lock()
+	defer evR.requestsMtx.Unlock()
+	if last, ok := evR.lastRequests[id]; ok && now.Sub(last) < evidenceRequestIntervalS*time.Second {
+		return false
+	}
+	evR.lastRequests[id] = now
+	return true
+}
+
 // Modeled after the mempool routine.
 // - Evidence accumulates in a clist.
 // - Each peer has a routine that iterates through the clist,
 // sending available evidence to the peer.
 // - If we're waiting for new evidence and the list is not empty,
 // start iterating from the beginning again.
+// - When starting from the beginning, the evidence already in the list is
+// sent oldest first, as it is the closest to expiring, before waiting for
+// evidence added after it.
+// - Evidence which fails to send is kept aside and retried before moving on,
+// so it is not lost if its element is removed from the clist meanwhile.
+// - Evidence over the peer's gossip budget, or over the maximum number of
+// evidence per broadcast interval, is kept aside the same way until the next
+// interval.
+// - With a gossip fanout, evidence already pushed to enough other peers is
+// skipped.
+// - A newly connected peer has not reported its height yet, so no evidence is
+// eligible for it. Until it does, we start from the beginning every
+// peerCatchupIntervalMS rather than every tick, so it gets the evidence
+// already in the list promptly.
 func (evR *Reactor) broadcastEvidenceRoutine(peer p2p.Peer) {
-	var next *clist.CElement
+	if !evR.waitForPeerState(peer) {
+		return
+	}
+
+	var (
+		next    *clist.CElement
+		queue   []types.Evidence // evidence up to next, oldest first
+		pending []types.Evidence // evidence whose last send failed
+		budget  = &gossipBudget{limit: evR.gossipBudget, interval: evR.gossipBudgetInterval}
+		quota   = &gossipBudget{limit: evR.maxBroadcastEvidence, interval: broadcastEvidenceIntervalS * time.Second}
+	)
 	for {
 
 		if !peer.IsRunning() || !evR.IsRunning() {
 			return
 		}
 
-		// This happens because the CElement we were looking at got garbage
-		// collected (removed). That is, .NextWait() returned nil. Go ahead and
-		// start from the beginning.
-		if next == nil {
-			select {
-			case <-evR.evpool.EvidenceWaitChan(): // Wait until evidence is available
-				if next = evR.evpool.EvidenceFront(); next == nil {
+		if pending != nil {
+			if wait, ok := evR.gossipEvidence(peer, budget, quota, pending); !ok {
+				evR.pause(peer, wait)
+				continue
+			}
+			pending = nil
+			if len(queue) > 0 {
+				continue
+			}
+		} else if len(queue) > 0 {
+			ev := queue[0]
+			queue = queue[1:]
+			if wait, ok := evR.pushEvidence(peer, budget, quota, ev); !ok {
+				pending = []types.Evidence{ev}
+				evR.pause(peer, wait)
+				continue
+			}
+			if len(queue) > 0 {
+				continue
+			}
+		} else {
+			// This happens because the CElement we were looking at got garbage
+			// collected (removed). That is, .NextWait() returned nil. Go ahead and
+			// start from the beginning.
+			if next == nil {
+				select {
+				case <-evR.evpool.EvidenceWaitChan(): // Wait until evidence is available
+					if next = evR.evpool.EvidenceFront(); next == nil {
+						continue
+					}
+				case <-peer.Quit():
+					return
+				case <-evR.done:
+					return
+				case <-time.After(time.Second * evidenceWaitIntervalS):
 					continue
 				}
-			case <-peer.Quit():
-				return
-			case <-evR.Quit():
-				return
-			}
-		}
-		ev := next.Value.(types.Evidence)
-		evis := evR.prepareEvidenceMessage(peer, ev)
-		if evis != nil {
-			msgBytes, err := encodeMsg(evis)
-			if err != nil {
-				panic(err)
+				queue, next = evidenceByAge(next)
+				continue
 			}
-			success := peer.Send(EvidenceChannel, msgBytes)
-			if !success {
-				time.Sleep(peerRetryMessageIntervalMS * time.Millisecond)
+			ev := next.Value.(types.Evidence)
+			if wait, ok := evR.pushEvidence(peer, budget, quota, ev); !ok {
+				pending = []types.Evidence{ev}
+				evR.pause(peer, wait)
 				continue
 			}
 		}
 
-		afterCh := time.After(time.Second * broadcastEvidenceIntervalS)
+		interval := time.Second * broadcastEvidenceIntervalS
+		if !peerHeightKnown(peer) {
+			interval = time.Millisecond * peerCatchupIntervalMS
+		}
+		afterCh := time.After(interval)
 		select {
 		case <-afterCh:
 			// start from the beginning every tick.
@@ -159,15 +466,177 @@ func (evR *Reactor) broadcastEvidenceRoutine(peer p2p.Peer) {
 			next = next.Next()
 		case <-peer.Quit():
 			return
-		case <-evR.Quit():
+		case <-evR.done:
 			return
 		}
 	}
 }
 
-// Returns the message to send the peer, or nil if the evidence is invalid for the peer.
-// If message is nil, return true if we should sleep and try again.
-func (evR Reactor) prepareEvidenceMessage(
+// waitForPeerState waits until the peer has a peer state telling which
+// evidence it may accept. It returns false if the peer or the reactor stopped
+// first.
+func (evR *Reactor) waitForPeerState(peer p2p.Peer) bool {
+	for {
+		if _, ok := peer.Get(types.PeerStateKey).(PeerState); ok {
+			return true
+		}
+		select {
+		case <-time.After(time.Millisecond * peerCatchupIntervalMS):
+		case <-peer.Quit():
+			return false
+		case <-evR.done:
+			return false
+		}
+	}
+}
+
+// peerHeightKnown reports whether the peer has reported its height. A peer
+// which lost its peer state was removed and will not report it, so it is
+// treated as known.
+func peerHeightKnown(peer p2p.Peer) bool {
+	peerState, ok := peer.Get(types.PeerStateKey).(PeerState)
+	return !ok || peerState.GetHeight() > 0
+}
+
+// evidenceByAge returns the evidence from front to the end of the list, oldest
+// first, along with the last element.
+func evidenceByAge(front *clist.CElement) ([]types.Evidence, *clist.CElement) {
+	var (
+		evis []types.Evidence
+		last = front
+	)
+	for e := front; e != nil; e = e.Next() {
+		evis = append(evis, e.Value.(types.Evidence))
+		last = e
+	}
+	sort.SliceStable(evis, func(i, j int) bool {
+		return evis[i].Time().Before(evis[j].Time())
+	})
+	return evis, last
+}
+
+// pushEvidence gossips ev to the peer if it is eligible for the peer and
+// within the gossip fanout. If it was not sent, it returns how long to wait
+// before trying again.
+func (evR *Reactor) pushEvidence(peer p2p.Peer, budget, quota *gossipBudget, ev types.Evidence) (time.Duration, bool) {
+	evis := evR.prepareEvidenceMessage(peer, ev)
+	if evis == nil || !evR.claimPush(peer, ev) {
+		return 0, true
+	}
+	return evR.gossipEvidence(peer, budget, quota, evis)
+}
+
+// sendEvidence encodes the evidence with the reactor's codec and sends it to
+// the peer without blocking, returning whether the message was queued.
+func (evR *Reactor) sendEvidence(peer p2p.Peer, evis []types.Evidence) bool {
+	msgBytes, err := evR.codec.Encode(evis)
+	if err != nil {
+		panic(err)
+	}
+	return peer.TrySend(EvidenceChannel, msgBytes)
+}
+
+// gossipEvidence sends the evidence to the peer if it fits in both the peer's
+// gossip budget in bytes and its quota of evidence. If it was not sent, it
+// returns how long to wait before trying again.
+func (evR *Reactor) gossipEvidence(peer p2p.Peer, budget, quota *gossipBudget, evis []types.Evidence) (time.Duration, bool) {
+	msgBytes, err := evR.codec.Encode(evis)
+	if err != nil {
+		panic(err)
+	}
+	now := time.Now()
+	if wait, ok := quota.reserve(len(evis), now); !ok {
+		evR.Logger.Debug("Evidence broadcast quota spent, pausing", "peer", peer, "wait", wait)
+		return wait, false
+	}
+	if wait, ok := budget.reserve(len(msgBytes), now); !ok {
+		quota.release(len(evis))
+		evR.Logger.Debug("Evidence gossip budget spent, pausing", "peer", peer, "wait", wait)
+		return wait, false
+	}
+	if !peer.Send(EvidenceChannel, msgBytes) {
+		quota.release(len(evis))
+		budget.release(len(msgBytes))
+		return peerRetryMessageIntervalMS * time.Millisecond, false
+	}
+	return 0, true
+}
+
+// claimPush reports whether ev may be pushed to the peer under the gossip
+// fanout, counting the peer among those it is pushed to if so.
+func (evR *Reactor) claimPush(peer p2p.Peer, ev types.Evidence) bool {
+	if evR.pushed == nil {
+		return true
+	}
+	evR.pushedMtx.Lock()
+	defer evR.pushedMtx.Unlock()
+	hash := ev.Hash()
+	peers, ok := evR.pushed.Get(hash)
+	if !ok {
+		peers = make(map[p2p.ID]struct{}, evR.fanout)
+		evR.pushed.Add(hash, peers)
+	}
+	if _, ok := peers[peer.ID()]; ok {
+		return true
+	}
+	if len(peers) >= evR.fanout {
+		return false
+	}
+	peers[peer.ID()] = struct{}{}
+	return true
+}
+
+// pause waits for d, returning early if the peer or the reactor stops.
+func (evR *Reactor) pause(peer p2p.Peer, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-peer.Quit():
+	case <-evR.done:
+	}
+}
+
+// gossipBudget tracks how much evidence, in bytes or in number, is gossiped
+// to a peer in fixed intervals.
+type gossipBudget struct {
+	limit    int // per interval, 0 for no limit
+	interval time.Duration
+
+	start time.Time // start of the current interval
+	used  int       // reserved in the current interval
+}
+
+// reserve reports whether n more fit in the current interval at now,
+// counting them if so, and otherwise how long until the next interval. A
+// message larger than the whole budget still goes out alone at the start of
+// an interval, so that it is not withheld forever.
+func (b *gossipBudget) reserve(n int, now time.Time) (time.Duration, bool) {
+	if b.limit <= 0 {
+		return 0, true
+	}
+	if b.start.IsZero() {
+		b.start = now
+	} else if elapsed := now.Sub(b.start); elapsed >= b.interval {
+		// Keep the intervals back to back
+		b.start = now.Add(-elapsed % b.interval)
+		b.used = 0
+	}
+	if b.used > 0 && b.used+n > b.limit {
+		return b.start.Add(b.interval).Sub(now), false
+	}
+	b.used += n
+	return 0, true
+}
+
+// release gives back n reserved for a message which was not sent.
+func (b *gossipBudget) release(n int) {
+	if b.used -= n; b.used < 0 {
+		b.used = 0
+	}
+}
+
+// Returns the message to send the peer, or nil if the evidence is not to be
+// sent to the peer yet.
+func (evR *Reactor) prepareEvidenceMessage(
 	peer p2p.Peer,
 	ev types.Evidence,
 ) (evis []types.Evidence) {
@@ -176,11 +645,8 @@ func (evR Reactor) prepareEvidenceMessage(
 	evHeight := ev.Height()
 	peerState, ok := peer.Get(types.PeerStateKey).(PeerState)
 	if !ok {
-		// Peer does not have a state yet. We set it in the consensus reactor, but
-		// when we add peer in Switch, the order we call reactors#AddPeer is
-		// different every time due to us using a map. Sometimes other reactors
-		// will be initialized before the consensus reactor. We should wait a few
-		// milliseconds and retry.
+		// The broadcast routine waits for the peer state before sending, so
+		// the peer lost it on removal and is about to stop.
 		return nil
 	}
 
@@ -189,12 +655,17 @@ func (evR Reactor) prepareEvidenceMessage(
 	// and
 	// lastBlockTime - maxDuration < evidenceTime
 	var (
+		state        = evR.evpool.StateSnapshot()
 		peerHeight   = peerState.GetHeight()
-		params       = evR.evpool.State().ConsensusParams.Evidence
+		params       = state.EvidenceParams
 		ageNumBlocks = int64(peerHeight) - int64(evHeight)
 	)
 
-	if peerHeight <= evHeight { // peer is behind. sleep while he catches up
+	if evHeight > state.LastBlockHeight { // we have not committed this height ourselves yet
+		evR.Logger.Debug("Not sending evidence above our height",
+			"evHeight", evHeight, "height", state.LastBlockHeight, "peer", peer)
+		return nil
+	} else if peerHeight <= evHeight { // peer is behind. sleep while he catches up
 		return nil
 	} else if ageNumBlocks > params.MaxAgeNumBlocks { // evidence is too old, skip
 
@@ -204,7 +675,7 @@ func (evR Reactor) prepareEvidenceMessage(
 			"peerHeight", peerHeight,
 			"evHeight", evHeight,
 			"maxAgeNumBlocks", params.MaxAgeNumBlocks,
-			"lastBlockTime", evR.evpool.State().LastBlockTime,
+			"lastBlockTime", state.LastBlockTime,
 			"maxAgeDuration", params.MaxAgeDuration,
 			"peer", peer,
 		)
@@ -224,6 +695,71 @@ type PeerState interface {
 //-----------------------------------------------------------------------------
 // Messages
 
+// EvidenceRequestMessage asks a peer for its pending evidence with a height
+// between FromHeight and ToHeight inclusive. It is sent as an ep.EvidenceRequest.
+type EvidenceRequestMessage struct {
+	FromHeight uint64
+	ToHeight   uint64
+}
+
+// ValidateBasic performs basic validation.
+func (m *EvidenceRequestMessage) ValidateBasic() error {
+	if m.FromHeight > m.ToHeight {
+		return fmt.Errorf("invalid evidence request range [%d, %d]", m.FromHeight, m.ToHeight)
+	}
+	return nil
+}
+
+// ToProto converts the message to its proto form.
+func (m *EvidenceRequestMessage) ToProto() *ep.EvidenceRequest {
+	return &ep.EvidenceRequest{FromHeight: m.FromHeight, ToHeight: m.ToHeight}
+}
+
+// EvidenceRequestMessageFromProto converts an ep.EvidenceRequest to an
+// EvidenceRequestMessage.
+func EvidenceRequestMessageFromProto(pb *ep.EvidenceRequest) *EvidenceRequestMessage {
+	return &EvidenceRequestMessage{FromHeight: pb.GetFromHeight(), ToHeight: pb.GetToHeight()}
+}
+
+// EvidenceCodec encodes and decodes the evidence lists exchanged with peers.
+type EvidenceCodec interface {
+	Encode(evis []types.Evidence) ([]byte, error)
+	Decode(bz []byte) ([]types.Evidence, error)
+}
+
+// protoCodec is the default EvidenceCodec, encoding evidence lists as ep.List.
+type protoCodec struct {
+	maxListSize int
+}
+
+// Encode implements EvidenceCodec.
+func (c protoCodec) Encode(evis []types.Evidence) ([]byte, error) {
+	return encodeMsg(evis)
+}
+
+// Decode implements EvidenceCodec. Lists larger than maxListSize are rejected
+// before any of their evidence is converted.
+func (c protoCodec) Decode(bz []byte) ([]types.Evidence, error) {
+	lm := ep.List{}
+	if err := lm.Unmarshal(bz); err != nil {
+		return nil, err
+	}
+
+	if len(lm.Evidence) > c.maxListSize {
+		return nil, ErrEvidenceListTooLarge{Got: len(lm.Evidence), Max: c.maxListSize}
+	}
+
+	evis := make([]types.Evidence, len(lm.Evidence))
+	for i := 0; i < len(lm.Evidence); i++ {
+		ev, err := types.EvidenceFromProto(lm.Evidence[i])
+		if err != nil {
+			return nil, err
+		}
+		evis[i] = ev
+	}
+	return evis, nil
+}
+
 // encodemsg takes a array of evidence
 // returns the byte encoding of the List Message
 func encodeMsg(evis []types.Evidence) ([]byte, error) {
@@ -243,28 +779,69 @@ func encodeMsg(evis []types.Evidence) ([]byte, error) {
 	return epl.Marshal()
 }
 
-// decodemsg takes an array of bytes
+// decodemsg takes an array of bytes and the maximum number of evidence allowed
 // returns an array of evidence
-func decodeMsg(bz []byte) (evis []types.Evidence, err error) {
-	lm := ep.List{}
-	if err := lm.Unmarshal(bz); err != nil {
+func decodeMsg(bz []byte, maxListSize int) (evis []types.Evidence, err error) {
+	evis, err = protoCodec{maxListSize: maxListSize}.Decode(bz)
+	if err != nil {
 		return nil, err
 	}
-
-	evis = make([]types.Evidence, len(lm.Evidence))
-	for i := 0; i < len(lm.Evidence); i++ {
-		ev, err := types.EvidenceFromProto(lm.Evidence[i])
-		if err != nil {
-			return nil, err
-		}
-		evis[i] = ev
+	if err := validateEvidenceList(evis, maxListSize); err != nil {
+		return nil, err
 	}
+	return evis, nil
+}
 
+// validateEvidenceList checks a decoded evidence list regardless of the codec
+// it was decoded with.
+func validateEvidenceList(evis []types.Evidence, maxListSize int) error {
+	if len(evis) > maxListSize {
+		return ErrEvidenceListTooLarge{Got: len(evis), Max: maxListSize}
+	}
 	for i, ev := range evis {
 		if err := ev.ValidateBasic(); err != nil {
-			return nil, fmt.Errorf("invalid evidence (#%d): %v", i, err)
+			return fmt.Errorf("invalid evidence (#%d): %v", i, err)
+		}
+		if err := validateEvidenceByType(ev); err != nil {
+			return fmt.Errorf("invalid evidence (#%d): %v", i, err)
 		}
 	}
+	return nil
+}
 
-	return evis, nil
+// validateEvidenceByType runs the structural checks specific to the concrete
+// evidence type, catching malformed evidence before it reaches the pool.
+func validateEvidenceByType(ev types.Evidence) error {
+	switch ev := ev.(type) {
+	case *types.DuplicateVoteEvidence:
+		return validateDuplicateVoteEvidence(ev)
+	default:
+		return fmt.Errorf("evidence is not recognized: %T", ev)
+	}
+}
+
+// validateDuplicateVoteEvidence checks that both votes are from the same
+// validator at the same H/R/S but for distinct blocks.
+func validateDuplicateVoteEvidence(dve *types.DuplicateVoteEvidence) error {
+	if dve.VoteA == nil || dve.VoteB == nil {
+		return fmt.Errorf("one or both of the votes are empty %v, %v", dve.VoteA, dve.VoteB)
+	}
+	if dve.VoteA.Height != dve.VoteB.Height ||
+		dve.VoteA.Round != dve.VoteB.Round ||
+		dve.VoteA.Type != dve.VoteB.Type {
+		return fmt.Errorf("H/R/S does not match. Got %v and %v", dve.VoteA, dve.VoteB)
+	}
+	if !dve.VoteA.ValidatorAddress.Equal(dve.VoteB.ValidatorAddress) ||
+		dve.VoteA.ValidatorIndex != dve.VoteB.ValidatorIndex {
+		return fmt.Errorf("validators do not match. Got %X/%d and %X/%d",
+			dve.VoteA.ValidatorAddress, dve.VoteA.ValidatorIndex,
+			dve.VoteB.ValidatorAddress, dve.VoteB.ValidatorIndex)
+	}
+	if dve.VoteA.BlockID.Equal(dve.VoteB.BlockID) {
+		return fmt.Errorf("BlockIDs are the same (%v) - not a real duplicate vote", dve.VoteA.BlockID)
+	}
+	if dve.ValidatorPower <= 0 || dve.TotalVotingPower < dve.ValidatorPower {
+		return fmt.Errorf("invalid voting power: validator %d, total %d", dve.ValidatorPower, dve.TotalVotingPower)
+	}
+	return nil
 }
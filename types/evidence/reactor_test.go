@@ -21,6 +21,7 @@ package evidence
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,8 +31,13 @@ import (
 	"github.com/kardiachain/go-kardia/types/evidence/mocks"
 
 	"github.com/kardiachain/go-kardia/kai/kaidb/memorydb"
+	"github.com/kardiachain/go-kardia/lib/clist"
+	"github.com/kardiachain/go-kardia/lib/crypto"
 	"github.com/kardiachain/go-kardia/lib/log"
 	"github.com/kardiachain/go-kardia/lib/p2p"
+	"github.com/kardiachain/go-kardia/lib/p2p/conn"
+	p2pmock "github.com/kardiachain/go-kardia/lib/p2p/mock"
+	ep "github.com/kardiachain/go-kardia/proto/kardiachain/evidence"
 	"github.com/kardiachain/go-kardia/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -302,3 +308,904 @@ func sendEvidence(t *testing.T, evpool *Pool, privVal types.PrivValidator, n int
 	}
 	return evList
 }
+
+func TestDecodeMsgEvidenceListSizeBound(t *testing.T) {
+	val := types.NewMockPV()
+	evis := make([]types.Evidence, 3)
+	for i := range evis {
+		evis[i] = types.NewMockDuplicateVoteEvidenceWithValidator(uint64(i+1),
+			time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	}
+	bz, err := encodeMsg(evis)
+	require.NoError(t, err)
+
+	_, err = decodeMsg(bz, len(evis)-1)
+	assert.Equal(t, ErrEvidenceListTooLarge{Got: len(evis), Max: len(evis) - 1}, err)
+
+	decoded, err := decodeMsg(bz, len(evis))
+	require.NoError(t, err)
+	assert.Len(t, decoded, len(evis))
+}
+
+func TestValidateEvidenceByType(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(1,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	assert.NoError(t, validateEvidenceByType(ev))
+
+	identical := *ev
+	identical.VoteB = ev.VoteA.Copy()
+	assert.Error(t, validateEvidenceByType(&identical))
+
+	otherRound := *ev
+	otherRound.VoteB = ev.VoteB.Copy()
+	otherRound.VoteB.Round++
+	assert.Error(t, validateEvidenceByType(&otherRound))
+}
+
+// mockCodec is an EvidenceCodec recording what it encoded and decoded.
+type mockCodec struct {
+	mtx     sync.Mutex
+	encoded [][]types.Evidence
+	decoded [][]byte
+	evis    []types.Evidence
+}
+
+func (c *mockCodec) Encode(evis []types.Evidence) ([]byte, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.encoded = append(c.encoded, evis)
+	return []byte("mock"), nil
+}
+
+func (c *mockCodec) Decode(bz []byte) ([]types.Evidence, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.decoded = append(c.decoded, bz)
+	return c.evis, nil
+}
+
+// capturePeer is a mock peer recording the messages sent to it.
+type capturePeer struct {
+	*p2pmock.Peer
+	sent [][]byte
+}
+
+func (p *capturePeer) Send(chID byte, msgBytes []byte) bool {
+	p.sent = append(p.sent, msgBytes)
+	return true
+}
+
+func (p *capturePeer) TrySend(chID byte, msgBytes []byte) bool {
+	return p.Send(chID, msgBytes)
+}
+
+func TestReactorUsesEvidenceCodec(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(1,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	codec := &mockCodec{evis: []types.Evidence{ev, ev}}
+
+	evR := NewReactor(nil, WithEvidenceCodec(codec), WithMaxEvidenceListSize(1))
+	evR.Logger = log.TestingLogger()
+	priv, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	transport := p2p.NewMultiplexTransport(p2p.DefaultNodeInfo{}, p2p.NodeKey{PrivKey: priv}, conn.DefaulKAIConnConfig())
+	evR.SetSwitch(p2p.NewSwitch(configs.DefaultP2PConfig(), transport))
+
+	// Sending goes through the codec.
+	peer := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+	require.True(t, evR.sendEvidence(peer, []types.Evidence{ev}))
+	assert.Equal(t, [][]types.Evidence{{ev}}, codec.encoded)
+	assert.Equal(t, [][]byte{[]byte("mock")}, peer.sent)
+
+	// Receiving goes through the codec, and its output is still bounded.
+	evR.Receive(EvidenceChannel, peer, []byte("incoming"))
+	assert.Equal(t, [][]byte{[]byte("incoming")}, codec.decoded)
+	assert.False(t, peer.IsRunning())
+}
+
+func TestReactorStrictEvidenceValidation(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(1,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	ev.VoteB = ev.VoteA // not a duplicate vote
+	priv, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		strict  bool
+		stopped bool
+	}{
+		{"strict", true, true},
+		{"lenient", false, false},
+	}
+	for _, tc := range testCases {
+		codec := &mockCodec{evis: []types.Evidence{ev}}
+		evR := NewReactor(nil, WithEvidenceCodec(codec), WithStrictEvidenceValidation(tc.strict))
+		evR.Logger = log.TestingLogger()
+		transport := p2p.NewMultiplexTransport(p2p.DefaultNodeInfo{}, p2p.NodeKey{PrivKey: priv}, conn.DefaulKAIConnConfig())
+		evR.SetSwitch(p2p.NewSwitch(configs.DefaultP2PConfig(), transport))
+
+		peer := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+		evR.Receive(EvidenceChannel, peer, []byte("incoming"))
+		assert.Equal(t, [][]byte{[]byte("incoming")}, codec.decoded, tc.name)
+		assert.Equal(t, !tc.stopped, peer.IsRunning(), tc.name)
+	}
+
+	// Too much evidence stops the peer even when lenient.
+	codec := &mockCodec{evis: []types.Evidence{ev, ev}}
+	evR := NewReactor(nil, WithEvidenceCodec(codec), WithMaxEvidenceListSize(1), WithStrictEvidenceValidation(false))
+	evR.Logger = log.TestingLogger()
+	transport := p2p.NewMultiplexTransport(p2p.DefaultNodeInfo{}, p2p.NodeKey{PrivKey: priv}, conn.DefaulKAIConnConfig())
+	evR.SetSwitch(p2p.NewSwitch(configs.DefaultP2PConfig(), transport))
+	peer := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+	evR.Receive(EvidenceChannel, peer, []byte("incoming"))
+	assert.False(t, peer.IsRunning())
+}
+
+func TestReactorChannelConfig(t *testing.T) {
+	chDesc := NewReactor(nil).GetChannels()[0]
+	assert.Equal(t, EvidenceChannel, chDesc.ID)
+	assert.Equal(t, defaultChannelPriority, chDesc.Priority)
+	assert.Equal(t, maxMsgSize, chDesc.RecvMessageCapacity)
+
+	chDesc = NewReactor(nil, WithChannelPriority(2), WithRecvMessageCapacity(4*maxMsgSize)).GetChannels()[0]
+	assert.Equal(t, 2, chDesc.Priority)
+	assert.Equal(t, 4*maxMsgSize, chDesc.RecvMessageCapacity)
+
+	assert.Panics(t, func() { NewReactor(nil, WithChannelPriority(0)) })
+	assert.Panics(t, func() { NewReactor(nil, WithChannelPriority(maxChannelPriority+1)) })
+	assert.Panics(t, func() { NewReactor(nil, WithRecvMessageCapacity(minRecvMessageCapacity-1)) })
+	assert.Panics(t, func() { NewReactor(nil, WithRecvMessageCapacity(maxRecvMessageCapacity+1)) })
+}
+
+func TestReactorReceiveIgnoresOtherChannels(t *testing.T) {
+	codec := &mockCodec{}
+	evR := NewReactor(nil, WithEvidenceCodec(codec))
+	evR.Logger = log.TestingLogger()
+
+	peer := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+	evR.Receive(EvidenceRequestChannel+1, peer, []byte("not evidence"))
+	assert.Empty(t, codec.decoded)
+	assert.True(t, peer.IsRunning())
+}
+
+// flakyPeer is a mock peer failing its first send and recording the others.
+type flakyPeer struct {
+	*p2pmock.Peer
+
+	mtx      sync.Mutex
+	attempts int
+	sent     [][]byte
+}
+
+func (p *flakyPeer) Send(chID byte, msgBytes []byte) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.attempts++
+	if p.attempts == 1 {
+		return false
+	}
+	p.sent = append(p.sent, msgBytes)
+	return true
+}
+
+func (p *flakyPeer) counts() (attempts, sent int) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.attempts, len(p.sent)
+}
+
+type peerHeight uint64
+
+func (h peerHeight) GetHeight() uint64 { return uint64(h) }
+
+func TestReactorRetriesFailedEvidenceSend(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(1,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 10
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	codec := &mockCodec{}
+
+	evR := NewReactor(evpool, WithEvidenceCodec(codec))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	peer := &flakyPeer{Peer: p2pmock.NewPeer(nil)}
+	peer.Set(types.PeerStateKey, peerHeight(10))
+	defer func() { _ = peer.Stop() }()
+	el := evpool.evidenceList.PushBack(ev)
+	go evR.broadcastEvidenceRoutine(peer)
+
+	waitFor := func(cond func() bool) {
+		deadline := time.Now().Add(Timeout)
+		for !cond() {
+			require.True(t, time.Now().Before(deadline), "timed out")
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	// The first send fails, then the evidence leaves the list.
+	waitFor(func() bool { attempts, _ := peer.counts(); return attempts >= 1 })
+	evpool.evidenceList.Remove(el)
+	el.DetachPrev()
+
+	// It is still delivered from the pending buffer.
+	waitFor(func() bool { _, sent := peer.counts(); return sent >= 1 })
+	codec.mtx.Lock()
+	defer codec.mtx.Unlock()
+	for _, evis := range codec.encoded {
+		assert.Equal(t, []types.Evidence{ev}, evis)
+	}
+}
+
+func TestReactorStopWaitsForBroadcastRoutines(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(1,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 10
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	evpool.evidenceList.PushBack(ev)
+
+	evR := NewReactor(evpool, WithEvidenceCodec(&mockCodec{}))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+
+	// One peer waits for its height to catch up, one has the evidence sent
+	// and waits for more, one has its first send fail.
+	peers := []p2p.Peer{
+		p2pmock.NewPeer(nil),
+		&capturePeer{Peer: p2pmock.NewPeer(nil)},
+		&flakyPeer{Peer: p2pmock.NewPeer(nil)},
+	}
+	for i, peer := range peers {
+		if i == 0 {
+			peer.Set(types.PeerStateKey, peerHeight(1))
+		} else {
+			peer.Set(types.PeerStateKey, peerHeight(10))
+		}
+		evR.AddPeer(peer)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		_ = evR.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(Timeout):
+		t.Fatal("broadcast routines did not return")
+	}
+	for _, peer := range peers {
+		assert.True(t, peer.IsRunning())
+	}
+}
+
+func TestReactorWithholdsEvidenceAboveOwnHeight(t *testing.T) {
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 5
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	evR := NewReactor(evpool)
+	evR.SetLogger(log.TestingLogger())
+
+	peer := p2pmock.NewPeer(nil)
+	peer.Set(types.PeerStateKey, peerHeight(20))
+
+	ahead := types.NewMockDuplicateVoteEvidenceWithValidator(10, evidenceTime, val, "kai")
+	assert.Nil(t, evR.prepareEvidenceMessage(peer, ahead))
+
+	committed := types.NewMockDuplicateVoteEvidenceWithValidator(5, evidenceTime, val, "kai")
+	assert.Equal(t, []types.Evidence{committed}, evR.prepareEvidenceMessage(peer, committed))
+}
+
+// detachedPeer is a mock peer that can report itself as no longer running
+// without its quit channel being closed.
+type detachedPeer struct {
+	*p2pmock.Peer
+
+	mtx      sync.Mutex
+	detached bool
+}
+
+func (p *detachedPeer) IsRunning() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return !p.detached && p.Peer.IsRunning()
+}
+
+func (p *detachedPeer) detach() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.detached = true
+}
+
+func TestReactorBroadcastRoutineExitsOnEmptyPool(t *testing.T) {
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evR := NewReactor(evpool, WithEvidenceCodec(&mockCodec{}))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+
+	// A peer that goes away while the pool is empty is noticed on the next
+	// re-check, even though nothing wakes the routine up.
+	peer := &detachedPeer{Peer: p2pmock.NewPeer(nil)}
+	peer.Set(types.PeerStateKey, peerHeight(10))
+	evR.AddPeer(peer)
+	time.Sleep(50 * time.Millisecond)
+	peer.detach()
+
+	exited := make(chan struct{})
+	go func() {
+		evR.routines.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(Timeout):
+		t.Fatal("broadcast routine did not return")
+	}
+
+	// Stopping the reactor while a routine waits on the empty pool returns
+	// promptly as well.
+	evR.AddPeer(p2pmock.NewPeer(nil))
+	time.Sleep(50 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		_ = evR.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(Timeout):
+		t.Fatal("reactor did not stop")
+	}
+}
+
+func TestEvidenceRequestMessageEncoding(t *testing.T) {
+	for _, msg := range []EvidenceRequestMessage{{}, {FromHeight: 0, ToHeight: 1}, {FromHeight: 300, ToHeight: 1 << 63}} {
+		bz, err := msg.ToProto().Marshal()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(bz), maxRequestMsgSize)
+		pb := &ep.EvidenceRequest{}
+		require.NoError(t, pb.Unmarshal(bz))
+		assert.Equal(t, &msg, EvidenceRequestMessageFromProto(pb))
+	}
+
+	assert.Error(t, (&ep.EvidenceRequest{}).Unmarshal([]byte{0x0a, 0x01}), "wrong wire type")
+	assert.Error(t, (&ep.EvidenceRequest{}).Unmarshal([]byte{0x08, 0x80}), "truncated varint")
+	assert.Error(t, (&EvidenceRequestMessage{FromHeight: 2, ToHeight: 1}).ValidateBasic())
+}
+
+func TestReactorAnswersEvidenceRequest(t *testing.T) {
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	for _, height := range []uint64{2, 4, 5, 6, 8} {
+		evpool.evidenceList.PushBack(types.NewMockDuplicateVoteEvidenceWithValidator(height, evidenceTime, val, "kai"))
+	}
+	codec := &mockCodec{}
+	evR := NewReactor(evpool, WithEvidenceCodec(codec), WithMaxEvidenceListSize(2))
+	evR.SetLogger(log.TestingLogger())
+
+	requester := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+	require.True(t, evR.RequestEvidence(requester, 4, 6))
+	require.Len(t, requester.sent, 1)
+	assert.False(t, evR.RequestEvidence(requester, 6, 4))
+
+	responder := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+	evR.Receive(EvidenceRequestChannel, responder, requester.sent[0])
+	require.Len(t, codec.encoded, 2)
+	var heights []uint64
+	for _, evis := range codec.encoded {
+		assert.LessOrEqual(t, len(evis), 2)
+		for _, ev := range evis {
+			heights = append(heights, ev.Height())
+		}
+	}
+	assert.Equal(t, []uint64{4, 5, 6}, heights)
+	assert.Len(t, responder.sent, 2)
+}
+
+func TestReactorThrottlesEvidenceRequests(t *testing.T) {
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	for height := uint64(1); height <= maxRequestedEvidence+10; height++ {
+		evpool.evidenceList.PushBack(types.NewMockDuplicateVoteEvidenceWithValidator(height, evidenceTime, val, "kai"))
+	}
+	codec := &mockCodec{}
+	evR := NewReactor(evpool, WithEvidenceCodec(codec))
+	evR.SetLogger(log.TestingLogger())
+
+	request, err := (&EvidenceRequestMessage{FromHeight: 1, ToHeight: maxRequestedEvidence + 10}).ToProto().Marshal()
+	require.NoError(t, err)
+
+	// The answer is capped.
+	responder := &capturePeer{Peer: p2pmock.NewPeer(nil)}
+	evR.Receive(EvidenceRequestChannel, responder, request)
+	var answered int
+	for _, evis := range codec.encoded {
+		answered += len(evis)
+	}
+	assert.Equal(t, maxRequestedEvidence, answered)
+	sent := len(responder.sent)
+
+	// A second request right away is ignored.
+	evR.Receive(EvidenceRequestChannel, responder, request)
+	assert.Len(t, responder.sent, sent)
+
+	// Until the interval passed, or the peer reconnected.
+	now := time.Now()
+	assert.False(t, evR.allowRequest(responder.ID(), now))
+	assert.True(t, evR.allowRequest(responder.ID(), now.Add(evidenceRequestIntervalS*time.Second)))
+	evR.RemovePeer(responder, nil)
+	assert.True(t, evR.allowRequest(responder.ID(), now))
+}
+
+func TestGossipBudget(t *testing.T) {
+	const (
+		limit    = 100
+		msgSize  = 30
+		interval = time.Second
+	)
+	budget := &gossipBudget{limit: limit, interval: interval}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Offer a message every 10ms for 5 intervals, and tally what goes out.
+	perInterval := make(map[int64]int)
+	for now := start; now.Before(start.Add(5 * interval)); now = now.Add(10 * time.Millisecond) {
+		wait, ok := budget.reserve(msgSize, now)
+		if !ok {
+			assert.True(t, wait > 0 && wait <= interval, "wait %v", wait)
+			continue
+		}
+		perInterval[int64(now.Sub(start)/interval)] += msgSize
+	}
+	assert.Len(t, perInterval, 5)
+	for i, sent := range perInterval {
+		assert.Equal(t, 3*msgSize, sent, "interval %d", i)
+	}
+
+	// A message larger than the budget goes out alone.
+	budget = &gossipBudget{limit: limit, interval: interval}
+	_, ok := budget.reserve(2*limit, start)
+	assert.True(t, ok)
+	_, ok = budget.reserve(1, start)
+	assert.False(t, ok)
+
+	// Released reservations can be used again.
+	budget.release(2 * limit)
+	_, ok = budget.reserve(limit, start)
+	assert.True(t, ok)
+
+	// Without a limit everything goes out.
+	budget = &gossipBudget{}
+	_, ok = budget.reserve(1<<30, start)
+	assert.True(t, ok)
+}
+
+// meteredPeer is a mock peer counting the messages and bytes sent to it.
+type meteredPeer struct {
+	*p2pmock.Peer
+
+	mtx   sync.Mutex
+	msgs  int
+	bytes int
+}
+
+func (p *meteredPeer) Send(chID byte, msgBytes []byte) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.msgs++
+	p.bytes += len(msgBytes)
+	return true
+}
+
+func (p *meteredPeer) counts() (msgs, bytes int) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.msgs, p.bytes
+}
+
+func TestReactorGossipBudget(t *testing.T) {
+	const numEvidence = 10
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 20
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	var msgSize int
+	for height := uint64(1); height <= numEvidence; height++ {
+		ev := types.NewMockDuplicateVoteEvidenceWithValidator(height, evidenceTime, val, "kai")
+		evpool.evidenceList.PushBack(ev)
+		bz, err := encodeMsg([]types.Evidence{ev})
+		require.NoError(t, err)
+		msgSize = len(bz)
+	}
+
+	// Room for three messages per interval.
+	const interval = 300 * time.Millisecond
+	budget := 3*msgSize + msgSize/2
+	evR := NewReactor(evpool, WithGossipBudget(budget, interval))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	peer := &meteredPeer{Peer: p2pmock.NewPeer(nil)}
+	peer.Set(types.PeerStateKey, peerHeight(20))
+	defer func() { _ = peer.Stop() }()
+	evR.AddPeer(peer)
+
+	// Gossip pauses once the first interval's budget is spent...
+	time.Sleep(interval / 2)
+	msgs, bytes := peer.counts()
+	assert.Equal(t, 3, msgs)
+	assert.LessOrEqual(t, bytes, budget)
+
+	// ...and resumes in the following ones until everything is sent.
+	deadline := time.Now().Add(Timeout)
+	for msgs < numEvidence {
+		require.True(t, time.Now().Before(deadline), "timed out with %d messages sent", msgs)
+		time.Sleep(10 * time.Millisecond)
+		msgs, _ = peer.counts()
+	}
+}
+
+func TestReactorMaxBroadcastEvidence(t *testing.T) {
+	const maxEvidence = 3
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 20
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	for height := uint64(1); height <= 3*maxEvidence; height++ {
+		evpool.evidenceList.PushBack(types.NewMockDuplicateVoteEvidenceWithValidator(height, evidenceTime, val, "kai"))
+	}
+
+	assert.Panics(t, func() { NewReactor(evpool, WithMaxBroadcastEvidence(0)) })
+	evR := NewReactor(evpool, WithEvidenceCodec(&mockCodec{}), WithMaxBroadcastEvidence(maxEvidence))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	peer := &meteredPeer{Peer: p2pmock.NewPeer(nil)}
+	peer.Set(types.PeerStateKey, peerHeight(20))
+	defer func() { _ = peer.Stop() }()
+	evR.AddPeer(peer)
+
+	// The rest waits for the next broadcast interval.
+	time.Sleep(200 * time.Millisecond)
+	msgs, _ := peer.counts()
+	assert.Equal(t, maxEvidence, msgs)
+}
+
+func TestReactorGossipFanout(t *testing.T) {
+	const (
+		fanout   = 3
+		numPeers = 10
+	)
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 20
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	for height := uint64(1); height <= 2; height++ {
+		evpool.evidenceList.PushBack(types.NewMockDuplicateVoteEvidenceWithValidator(height, evidenceTime, val, "kai"))
+	}
+
+	assert.Panics(t, func() { NewReactor(evpool, WithGossipFanout(-1)) })
+	evR := NewReactor(evpool, WithEvidenceCodec(&mockCodec{}), WithGossipFanout(fanout))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	peers := make([]*meteredPeer, numPeers)
+	for i := range peers {
+		peers[i] = &meteredPeer{Peer: p2pmock.NewPeer(nil)}
+		peers[i].Set(types.PeerStateKey, peerHeight(20))
+		defer func(peer *meteredPeer) { _ = peer.Stop() }(peers[i])
+		evR.AddPeer(peers[i])
+	}
+
+	// Each evidence goes to fanout peers only.
+	time.Sleep(200 * time.Millisecond)
+	total := 0
+	for _, peer := range peers {
+		msgs, _ := peer.counts()
+		total += msgs
+	}
+	assert.Equal(t, 2*fanout, total)
+
+	// A removed peer frees its slots.
+	ev := evpool.EvidenceFront().Value.(types.Evidence)
+	var pushedTo, other p2p.Peer
+	for _, peer := range peers {
+		if msgs, _ := peer.counts(); msgs > 0 && pushedTo == nil {
+			pushedTo = peer
+		} else if msgs == 0 && other == nil {
+			other = peer
+		}
+	}
+	require.NotNil(t, pushedTo)
+	require.NotNil(t, other)
+	assert.False(t, evR.claimPush(other, ev))
+	evR.RemovePeer(pushedTo, nil)
+	assert.True(t, evR.claimPush(other, ev))
+	assert.False(t, evR.claimPush(pushedTo, ev))
+}
+
+// mockPeerState is a PeerState whose height can be changed while the peer is
+// being gossiped to.
+type mockPeerState struct {
+	mtx    sync.Mutex
+	height uint64
+}
+
+func (ps *mockPeerState) GetHeight() uint64 {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	return ps.height
+}
+
+func (ps *mockPeerState) SetHeight(height uint64) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	ps.height = height
+}
+
+func TestReactorPrepareEvidenceMessage(t *testing.T) {
+	val := types.NewMockPV()
+	lastBlockTime := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 100
+	evpool.state.LastBlockTime = lastBlockTime
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 20
+	evpool.state.ConsensusParams.Evidence.MaxAgeDuration = 24 * time.Hour
+	evR := NewReactor(evpool)
+	evR.SetLogger(log.TestingLogger())
+
+	ps := &mockPeerState{}
+	peer := p2pmock.NewPeer(nil)
+	peer.Set(types.PeerStateKey, ps)
+
+	testCases := []struct {
+		name       string
+		peerHeight uint64
+		evHeight   uint64
+		evTime     time.Time
+		sent       bool
+	}{
+		{"peer behind", 90, 90, lastBlockTime, false},
+		{"peer far behind", 50, 90, lastBlockTime, false},
+		{"above our height", 110, 101, lastBlockTime, false},
+		{"too old by blocks", 100, 79, lastBlockTime, false},
+		{"oldest by blocks", 100, 80, lastBlockTime, true},
+		// Evidence only expires once it is too old both in blocks and in
+		// time, so its age in time alone does not withhold it.
+		{"old by duration", 100, 90, lastBlockTime.Add(-48 * time.Hour), true},
+		{"eligible", 100, 99, lastBlockTime, true},
+		{"eligible for peer ahead", 120, 100, lastBlockTime, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ps.SetHeight(tc.peerHeight)
+			ev := types.NewMockDuplicateVoteEvidenceWithValidator(tc.evHeight, tc.evTime, val, "kai")
+			evis := evR.prepareEvidenceMessage(peer, ev)
+			if tc.sent {
+				assert.Equal(t, []types.Evidence{ev}, evis)
+			} else {
+				assert.Nil(t, evis)
+			}
+		})
+	}
+
+	// Without a peer state nothing is sent.
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(99, lastBlockTime, val, "kai")
+	assert.Nil(t, evR.prepareEvidenceMessage(p2pmock.NewPeer(nil), ev))
+}
+
+func TestReactorBroadcastsOldestEvidenceFirst(t *testing.T) {
+	val := types.NewMockPV()
+	evidenceTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 20
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 10
+	var (
+		tooOld = types.NewMockDuplicateVoteEvidenceWithValidator(1, evidenceTime, val, "kai")
+		oldest = types.NewMockDuplicateVoteEvidenceWithValidator(12, evidenceTime.Add(time.Minute), val, "kai")
+		older  = types.NewMockDuplicateVoteEvidenceWithValidator(11, evidenceTime.Add(2*time.Minute), val, "kai")
+		newest = types.NewMockDuplicateVoteEvidenceWithValidator(15, evidenceTime.Add(3*time.Minute), val, "kai")
+	)
+	for _, ev := range []types.Evidence{newest, older, tooOld, oldest} {
+		evpool.evidenceList.PushBack(ev)
+	}
+
+	codec := &mockCodec{}
+	evR := NewReactor(evpool, WithEvidenceCodec(codec))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	peer := &meteredPeer{Peer: p2pmock.NewPeer(nil)}
+	peer.Set(types.PeerStateKey, peerHeight(20))
+	defer func() { _ = peer.Stop() }()
+	evR.AddPeer(peer)
+
+	time.Sleep(200 * time.Millisecond)
+	codec.mtx.Lock()
+	defer codec.mtx.Unlock()
+	assert.Equal(t, [][]types.Evidence{{oldest}, {older}, {newest}}, codec.encoded)
+
+	// The routine then waits on the last element for evidence added after it.
+	queue, last := evidenceByAge(evpool.EvidenceFront())
+	assert.Equal(t, []types.Evidence{tooOld, oldest, older, newest}, queue)
+	assert.Equal(t, oldest, last.Value)
+}
+
+// risingPeerHeight is a peer state whose height changes over time.
+type risingPeerHeight struct {
+	height uint64
+}
+
+func (h *risingPeerHeight) GetHeight() uint64 { return atomic.LoadUint64(&h.height) }
+
+func TestReactorCatchesUpNewPeer(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(5,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 10
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	evpool.evidenceList.PushBack(ev)
+
+	codec := &mockCodec{}
+	evR := NewReactor(evpool, WithEvidenceCodec(codec))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	// The peer has not reported its height when it is added.
+	peer := &meteredPeer{Peer: p2pmock.NewPeer(nil)}
+	height := &risingPeerHeight{}
+	peer.Set(types.PeerStateKey, height)
+	defer func() { _ = peer.Stop() }()
+	evR.AddPeer(peer)
+	time.Sleep(100 * time.Millisecond)
+	msgs, _ := peer.counts()
+	require.Zero(t, msgs)
+
+	// Once it does, it gets the evidence well before the next tick.
+	atomic.StoreUint64(&height.height, 10)
+	deadline := time.Now().Add(broadcastEvidenceIntervalS * time.Second / 2)
+	for msgs, _ = peer.counts(); msgs == 0; msgs, _ = peer.counts() {
+		require.True(t, time.Now().Before(deadline), "evidence not sent")
+		time.Sleep(10 * time.Millisecond)
+	}
+	codec.mtx.Lock()
+	defer codec.mtx.Unlock()
+	assert.Equal(t, [][]types.Evidence{{ev}}, codec.encoded)
+}
+
+// lateStatePeer is a metered peer whose peer state may be set while the
+// reactor reads it.
+type lateStatePeer struct {
+	*meteredPeer
+
+	stateMtx sync.Mutex
+	state    interface{}
+}
+
+func (p *lateStatePeer) Get(key string) interface{} {
+	p.stateMtx.Lock()
+	defer p.stateMtx.Unlock()
+	return p.state
+}
+
+func (p *lateStatePeer) Set(key string, value interface{}) {
+	p.stateMtx.Lock()
+	defer p.stateMtx.Unlock()
+	p.state = value
+}
+
+func TestReactorWaitsForPeerState(t *testing.T) {
+	val := types.NewMockPV()
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(5,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), val, "kai")
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 10
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 100
+	evpool.evidenceList.PushBack(ev)
+
+	codec := &mockCodec{}
+	evR := NewReactor(evpool, WithEvidenceCodec(codec))
+	evR.SetLogger(log.TestingLogger())
+	require.NoError(t, evR.Start())
+	defer func() { _ = evR.Stop() }()
+
+	// The peer has no peer state yet when it is added.
+	peer := &lateStatePeer{meteredPeer: &meteredPeer{Peer: p2pmock.NewPeer(nil)}}
+	defer func() { _ = peer.Stop() }()
+	evR.AddPeer(peer)
+	time.Sleep(100 * time.Millisecond)
+	msgs, _ := peer.counts()
+	require.Zero(t, msgs)
+
+	// Once it has one, the evidence is sent rather than skipped.
+	peer.Set(types.PeerStateKey, peerHeight(10))
+	deadline := time.Now().Add(broadcastEvidenceIntervalS * time.Second / 2)
+	for msgs, _ = peer.counts(); msgs == 0; msgs, _ = peer.counts() {
+		require.True(t, time.Now().Before(deadline), "evidence not sent")
+		time.Sleep(10 * time.Millisecond)
+	}
+	codec.mtx.Lock()
+	defer codec.mtx.Unlock()
+	assert.Equal(t, [][]types.Evidence{{ev}}, codec.encoded)
+}
+
+// peerStateReactor sets the peer state in InitPeer, as the consensus reactor
+// does.
+type peerStateReactor struct {
+	p2p.BaseReactor
+	height uint64
+}
+
+func (r *peerStateReactor) InitPeer(peer p2p.Peer) p2p.Peer {
+	peer.Set(types.PeerStateKey, peerState{r.height})
+	return peer
+}
+
+// eligibilityProbe records in AddPeer whether evidence can be sent to the peer.
+type eligibilityProbe struct {
+	p2p.BaseReactor
+	evR *Reactor
+	ev  types.Evidence
+
+	mtx      sync.Mutex
+	eligible []bool
+}
+
+func (p *eligibilityProbe) AddPeer(peer p2p.Peer) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.eligible = append(p.eligible, p.evR.prepareEvidenceMessage(peer, p.ev) != nil)
+}
+
+func TestReactorPeerStateSetBeforeAddPeer(t *testing.T) {
+	val := types.NewMockPV()
+	lastBlockTime := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	evpool := &Pool{logger: log.TestingLogger(), evidenceList: clist.New()}
+	evpool.state.LastBlockHeight = 100
+	evpool.state.LastBlockTime = lastBlockTime
+	evpool.state.ConsensusParams.Evidence.MaxAgeNumBlocks = 20
+	evR := NewReactor(evpool)
+	evR.SetLogger(log.TestingLogger())
+	ev := types.NewMockDuplicateVoteEvidenceWithValidator(99, lastBlockTime, val, "kai")
+
+	// Reactors are called in no particular order, so try a few times.
+	for n := 0; n < 3; n++ {
+		probes := make([]*eligibilityProbe, 2)
+		switches := p2p.MakeConnectedSwitches(configs.DefaultP2PConfig(), 2, func(i int, sw *p2p.Switch) *p2p.Switch {
+			state := &peerStateReactor{height: 100}
+			state.BaseReactor = *p2p.NewBaseReactor("State", state)
+			probes[i] = &eligibilityProbe{evR: evR, ev: ev}
+			probes[i].BaseReactor = *p2p.NewBaseReactor("Probe", probes[i])
+			sw.AddReactor("PROBE", probes[i])
+			sw.AddReactor("STATE", state)
+			return sw
+		}, p2p.Connect2Switches)
+		for _, sw := range switches {
+			require.NoError(t, sw.Stop())
+		}
+		for _, probe := range probes {
+			assert.Equal(t, []bool{true}, probe.eligible)
+		}
+	}
+}
@@ -12,17 +12,18 @@ func (obj *Header) EncodeRLP(_w io.Writer) error {
 	w := rlp.NewEncoderBuffer(_w)
 	_tmp0 := w.List()
 	w.WriteUint64(obj.Height)
-	_tmp1 := w.List()
-	w.ListEnd(_tmp1)
+	if err := w.WriteTime(obj.Time); err != nil {
+		return err
+	}
 	w.WriteUint64(obj.NumTxs)
 	w.WriteUint64(obj.GasLimit)
-	_tmp2 := w.List()
+	_tmp1 := w.List()
 	w.WriteBytes(obj.LastBlockID.Hash[:])
-	_tmp3 := w.List()
+	_tmp2 := w.List()
 	w.WriteUint64(uint64(obj.LastBlockID.PartsHeader.Total))
 	w.WriteBytes(obj.LastBlockID.PartsHeader.Hash[:])
-	w.ListEnd(_tmp3)
 	w.ListEnd(_tmp2)
+	w.ListEnd(_tmp1)
 	w.WriteBytes(obj.ProposerAddress[:])
 	w.WriteBytes(obj.LastCommitHash[:])
 	w.WriteBytes(obj.TxHash[:])
@@ -95,7 +95,11 @@ func (privVal *DefaultPrivValidator) SignVote(chainID string, vote *kproto.Vote)
 }
 
 func (privVal *DefaultPrivValidator) SignProposal(chainID string, proposal *kproto.Proposal) error {
-	signBytes := ProposalSignBytes(chainID, proposal)
+	signBytes, err := ProposalSignBytes(chainID, proposal)
+	if err != nil {
+		log.Trace("Signing proposal failed", "err", err)
+		return err
+	}
 	sig, err := crypto.Sign(crypto.Keccak256(signBytes), privVal.privKey)
 	if err != nil {
 		log.Trace("Signing proposal failed", "err", err)
@@ -174,7 +178,10 @@ func (pv *MockPV) SignProposal(chainID string, proposal *kproto.Proposal) error
 	if pv.breakProposalSigning {
 		chainID = "1000"
 	}
-	signBytes := ProposalSignBytes(chainID, proposal)
+	signBytes, err := ProposalSignBytes(chainID, proposal)
+	if err != nil {
+		return err
+	}
 	sig, err := crypto.Sign(crypto.Keccak256(signBytes), pv.privKey)
 	if err != nil {
 		return err
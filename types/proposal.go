@@ -24,6 +24,7 @@ import (
 	"time"
 
 	cmn "github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/crypto"
 	"github.com/kardiachain/go-kardia/lib/protoio"
 	kproto "github.com/kardiachain/go-kardia/proto/kardiachain/types"
 )
@@ -35,14 +36,32 @@ import (
 type Proposal struct {
 	Height     uint64    `json:"height"`
 	Round      uint32    `json:"round"`
-	POLRound   uint32    `json:"pol_round"`
-	Timestamp  time.Time `json:"timestamp"`    // -1 if null.
+	POLRound   uint32    `json:"pol_round"` // NoPOLRound if null, rounds start at 1.
+	Timestamp  time.Time `json:"timestamp"`
 	POLBlockID BlockID   `json:"pol_block_id"` // zero if null.
 	Signature  []byte    `json:"signature"`
+	Version    uint32    `json:"version"`
+	// Signatures optionally carries the signatures of several proposers, in
+	// addition to Signature, for schemes requiring more than one. Consensus
+	// does not check them; see VerifyAggregate.
+	Signatures [][]byte `json:"signatures,omitempty"`
 }
 
+// NoPOLRound is the POLRound of a proposal without a proof-of-lock.
+// Rounds start at 1, so it can never be a real round.
+const NoPOLRound uint32 = 0
+
+// ProposalVersion is the highest proposal version this node understands.
+// The version is part of the sign bytes, so a proposal can only be
+// reinterpreted by nodes that agree on its format.
+const ProposalVersion uint32 = 0
+
+// ErrUnsupportedProposalVersion is returned when a proposal carries a version
+// newer than ProposalVersion.
+var ErrUnsupportedProposalVersion = errors.New("unsupported proposal version")
+
 // NewProposal returns a new Proposal.
-// If there is no POLRound, polRound should be -1.
+// If there is no POLRound, polRound should be NoPOLRound.
 func NewProposal(height uint64, round uint32, polRound uint32, polBlockID BlockID) *Proposal {
 	return &Proposal{
 		Height:     height,
@@ -50,25 +69,113 @@ func NewProposal(height uint64, round uint32, polRound uint32, polBlockID BlockI
 		Timestamp:  time.Now(),
 		POLRound:   polRound,
 		POLBlockID: polBlockID,
+		Version:    ProposalVersion,
 	}
 }
 
+// HasPOLRound reports whether the proposal carries a proof-of-lock round.
+func (p *Proposal) HasPOLRound() bool {
+	return p.POLRound != NoPOLRound
+}
+
+// Copy returns a deep copy of the proposal, safe to mutate without affecting p.
+func (p *Proposal) Copy() *Proposal {
+	proposalCopy := *p
+	if p.Signature != nil {
+		proposalCopy.Signature = make([]byte, len(p.Signature))
+		copy(proposalCopy.Signature, p.Signature)
+	}
+	if p.Signatures != nil {
+		proposalCopy.Signatures = make([][]byte, len(p.Signatures))
+		for i, sig := range p.Signatures {
+			proposalCopy.Signatures[i] = append([]byte(nil), sig...)
+		}
+	}
+	return &proposalCopy
+}
+
+// SignatureScheme builds the bytes that are signed for a proposal, so the
+// canonical form can be swapped without touching the consensus code.
+type SignatureScheme interface {
+	ProposalSignBytes(chainID string, p *kproto.Proposal) ([]byte, error)
+}
+
+// signatureScheme is the scheme used by ProposalSignBytes.
+var signatureScheme SignatureScheme = ProtoSignatureScheme{}
+
+// SetSignatureScheme replaces the scheme used by ProposalSignBytes and returns
+// the previous one. It is not safe to call while proposals are being signed or
+// verified, and all nodes of a network must use the same scheme.
+func SetSignatureScheme(scheme SignatureScheme) SignatureScheme {
+	prev := signatureScheme
+	signatureScheme = scheme
+	return prev
+}
+
+// ProtoSignatureScheme is the default SignatureScheme, signing the
+// proto-encoding of the canonicalized Proposal.
+type ProtoSignatureScheme struct{}
+
 // ProposalSignBytes returns the proto-encoding of the canonicalized Proposal,
-// for signing. Panics if the marshaling fails.
+// or an error if the marshaling fails, e.g. for an out of range timestamp.
 //
 // The encoded Protobuf message is varint length-prefixed (using MarshalDelimited)
 // for backwards-compatibility with the Amino encoding, due to e.g. hardware
 // devices that rely on this encoding.
 //
 // See CanonicalizeProposal
-func ProposalSignBytes(chainID string, p *kproto.Proposal) []byte {
+func (ProtoSignatureScheme) ProposalSignBytes(chainID string, p *kproto.Proposal) ([]byte, error) {
 	pb := CreateCanonicalProposal(chainID, p)
 	bz, err := protoio.MarshalDelimited(&pb)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to encode canonical proposal: %w", err)
 	}
 
-	return bz
+	return bz, nil
+}
+
+// ProposalSignBytes returns the bytes of the Proposal to sign, as built by the
+// current SignatureScheme.
+func ProposalSignBytes(chainID string, p *kproto.Proposal) ([]byte, error) {
+	return signatureScheme.ProposalSignBytes(chainID, p)
+}
+
+// VerifyAggregate checks that at least threshold of the proposal's Signatures
+// are valid signatures of its sign bytes by distinct addresses in signers.
+// The single Signature is not taken into account.
+func (p *Proposal) VerifyAggregate(chainID string, signers []cmn.Address, threshold int) error {
+	if threshold < 1 {
+		return fmt.Errorf("invalid signature threshold %d", threshold)
+	}
+	signBytes, err := ProposalSignBytes(chainID, p.ToProto())
+	if err != nil {
+		return err
+	}
+	hash := crypto.Keccak256(signBytes)
+
+	allowed := make(map[cmn.Address]struct{}, len(signers))
+	for _, addr := range signers {
+		allowed[addr] = struct{}{}
+	}
+	signed := make(map[cmn.Address]struct{}, len(p.Signatures))
+	for i, sig := range p.Signatures {
+		pubKey, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			return fmt.Errorf("%w: signature %d: %v", ErrProposalInvalidSignature, i, err)
+		}
+		addr := crypto.PubkeyToAddress(*pubKey)
+		if _, ok := allowed[addr]; !ok {
+			return fmt.Errorf("%w: signature %d by unexpected signer %v", ErrProposalInvalidSignature, i, addr.Hex())
+		}
+		if _, ok := signed[addr]; ok {
+			return fmt.Errorf("%w: duplicate signature by %v", ErrProposalInvalidSignature, addr.Hex())
+		}
+		signed[addr] = struct{}{}
+	}
+	if len(signed) < threshold {
+		return fmt.Errorf("%w: got %d, need %d", ErrProposalNotEnoughSignatures, len(signed), threshold)
+	}
+	return nil
 }
 
 // String returns a short string representing the Proposal
@@ -82,6 +189,9 @@ func (p *Proposal) String() string {
 
 // ValidateBasic performs basic validation.
 func (p *Proposal) ValidateBasic() error {
+	if p.Version > ProposalVersion {
+		return fmt.Errorf("%w: %d, max: %d", ErrUnsupportedProposalVersion, p.Version, ProposalVersion)
+	}
 	if err := p.POLBlockID.ValidateBasic(); err != nil {
 		return fmt.Errorf("wrong BlockID: %v", err)
 	}
@@ -92,9 +202,19 @@ func (p *Proposal) ValidateBasic() error {
 
 	// NOTE: Timestamp validation is subtle and handled elsewhere.
 
+	// The proposer's Signature is what consensus verifies, so it is required
+	// even when the proposal carries aggregate Signatures.
 	if len(p.Signature) == 0 {
 		return errors.New("signature is missing")
 	}
+	if len(p.Signatures) > MaxVotesCount {
+		return fmt.Errorf("too many signatures: %d, max: %d", len(p.Signatures), MaxVotesCount)
+	}
+	for i, sig := range p.Signatures {
+		if len(sig) == 0 {
+			return fmt.Errorf("signature %d is empty", i)
+		}
+	}
 
 	return nil
 }
@@ -113,6 +233,8 @@ func (p *Proposal) ToProto() *kproto.Proposal {
 	pb.PolRound = p.POLRound
 	pb.Timestamp = p.Timestamp
 	pb.Signature = p.Signature
+	pb.Version = p.Version
+	pb.Signatures = p.Signatures
 
 	return pb
 }
@@ -138,6 +260,8 @@ func ProposalFromProto(pp *kproto.Proposal) (*Proposal, error) {
 	p.POLRound = pp.PolRound
 	p.Timestamp = pp.Timestamp
 	p.Signature = pp.Signature
+	p.Version = pp.Version
+	p.Signatures = pp.Signatures
 
 	return p, p.ValidateBasic()
 }
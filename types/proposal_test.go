@@ -19,7 +19,19 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kardiachain/go-kardia/lib/common"
+	"github.com/kardiachain/go-kardia/lib/crypto"
+	kproto "github.com/kardiachain/go-kardia/proto/kardiachain/types"
 )
 
 func TestProposalCreation(t *testing.T) {
@@ -33,10 +45,273 @@ func TestProposalCreation(t *testing.T) {
 
 }
 
+func TestProposalHasPOLRound(t *testing.T) {
+	proposal := NewProposal(1, 2, NoPOLRound, createBlockIDRandom())
+	assert.False(t, proposal.HasPOLRound())
+	assert.Equal(t, NoPOLRound, proposal.ToProto().PolRound)
+
+	proposal = NewProposal(1, 2, 1, createBlockIDRandom())
+	assert.True(t, proposal.HasPOLRound())
+}
+
+func TestProposalCopy(t *testing.T) {
+	proposal := NewProposal(1, 2, 1, createBlockIDRandom())
+	proposal.Signature = []byte{0x01, 0x02, 0x03}
+	original := *proposal
+	original.Signature = []byte{0x01, 0x02, 0x03}
+
+	proposalCopy := proposal.Copy()
+	assert.Equal(t, proposal, proposalCopy)
+
+	proposalCopy.Signature[0] = 0xff
+	proposalCopy.POLBlockID.Hash[0] ^= 0xff
+	proposalCopy.POLBlockID.PartsHeader.Total++
+	proposalCopy.Height++
+	assert.Equal(t, &original, proposal)
+}
+
+func TestProposalPOLBlockID(t *testing.T) {
+	proposal := NewProposal(1, 2, NoPOLRound, BlockID{})
+	assert.True(t, proposal.POLBlockID.IsZero())
+	assert.True(t, proposal.POLBlockID.Equal(NewZeroBlockID()))
+
+	blockID := createBlockIDRandom()
+	assert.False(t, blockID.IsZero())
+	assert.True(t, blockID.Equal(blockID))
+	assert.False(t, blockID.Equal(BlockID{}))
+
+	// Only the parts header is set.
+	partsOnly := BlockID{PartsHeader: blockID.PartsHeader}
+	assert.False(t, partsOnly.IsZero())
+
+	// Same hash, different parts header.
+	otherParts := blockID
+	otherParts.PartsHeader.Total++
+	assert.False(t, blockID.Equal(otherParts))
+	otherParts = blockID
+	otherParts.PartsHeader.Hash = common.BytesToHash(common.RandBytes(32))
+	assert.False(t, blockID.Equal(otherParts))
+
+	// Different hash, same parts header.
+	otherHash := blockID
+	otherHash.Hash = common.BytesToHash(common.RandBytes(32))
+	assert.False(t, blockID.Equal(otherHash))
+}
+
 func TestProposalSignBytes(t *testing.T) {
 	proposal := NewProposal(1, 2, 3, createBlockIDRandom())
-	signedByte := ProposalSignBytes("KAI", proposal.ToProto())
+	signedByte, err := ProposalSignBytes("KAI", proposal.ToProto())
+	require.NoError(t, err)
 	if signedByte == nil {
 		t.Error("Proposal's SignBytes returned nil")
 	}
 }
+
+func mustProposalSignBytes(t *testing.T, chainID string, p *kproto.Proposal) []byte {
+	signBytes, err := ProposalSignBytes(chainID, p)
+	require.NoError(t, err)
+	return signBytes
+}
+
+func TestProposalSignBytesExcludesSignature(t *testing.T) {
+	proposal := NewProposal(1, 2, 3, createBlockIDRandom())
+	signBytes := mustProposalSignBytes(t, "KAI", proposal.ToProto())
+
+	proposal.Signature = []byte{0x01, 0x02, 0x03}
+	assert.Equal(t, signBytes, mustProposalSignBytes(t, "KAI", proposal.ToProto()))
+	proposal.Signature = bytes.Repeat([]byte{0xff}, 65)
+	assert.Equal(t, signBytes, mustProposalSignBytes(t, "KAI", proposal.ToProto()))
+
+	// Signed fields do change the sign bytes.
+	proposal.Round++
+	assert.NotEqual(t, signBytes, mustProposalSignBytes(t, "KAI", proposal.ToProto()))
+}
+
+func TestProposalSignatureBoundToRound(t *testing.T) {
+	privVal := NewMockPV()
+	pb := NewProposal(1, 0, NoPOLRound, createBlockIDRandom()).ToProto()
+	require.NoError(t, privVal.SignProposal("KAI", pb))
+	assert.True(t, VerifySignature(privVal.GetAddress(), crypto.Keccak256(mustProposalSignBytes(t, "KAI", pb)), pb.Signature))
+
+	// The same proposal replayed in the next round does not verify.
+	pb.Round = 1
+	assert.False(t, VerifySignature(privVal.GetAddress(), crypto.Keccak256(mustProposalSignBytes(t, "KAI", pb)), pb.Signature))
+}
+
+func TestProposalSignBytesEncodeError(t *testing.T) {
+	proposal := NewProposal(1, 2, 3, createBlockIDRandom())
+	// Timestamps past year 9999 cannot be proto-encoded.
+	proposal.Timestamp = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NotPanics(t, func() {
+		_, err := ProposalSignBytes("KAI", proposal.ToProto())
+		assert.Error(t, err)
+	})
+	pb := proposal.ToProto()
+	assert.Error(t, NewMockPV().SignProposal("KAI", pb))
+	assert.Empty(t, pb.Signature)
+}
+
+func TestProposalVersion(t *testing.T) {
+	proposal := NewProposal(1, 2, 1, createBlockIDRandom())
+	proposal.Signature = []byte{0x01, 0x02, 0x03}
+	assert.Equal(t, ProposalVersion, proposal.Version)
+	require.NoError(t, proposal.ValidateBasic())
+
+	// The version is covered by the sign bytes and survives the wire.
+	signBytes := mustProposalSignBytes(t, "KAI", proposal.ToProto())
+	proposal.Version = ProposalVersion + 1
+	assert.NotEqual(t, signBytes, mustProposalSignBytes(t, "KAI", proposal.ToProto()))
+
+	bz, err := proposal.ToProto().Marshal()
+	require.NoError(t, err)
+	var pb kproto.Proposal
+	require.NoError(t, pb.Unmarshal(bz))
+	assert.Equal(t, ProposalVersion+1, pb.Version)
+
+	// Versions newer than ours are rejected.
+	assert.True(t, errors.Is(proposal.ValidateBasic(), ErrUnsupportedProposalVersion))
+	_, err = ProposalFromProto(&pb)
+	assert.True(t, errors.Is(err, ErrUnsupportedProposalVersion))
+}
+
+// fakeSignatureScheme signs the chain ID and proposal height only.
+type fakeSignatureScheme struct {
+	calls int
+}
+
+func (s *fakeSignatureScheme) ProposalSignBytes(chainID string, p *kproto.Proposal) ([]byte, error) {
+	s.calls++
+	return []byte(fmt.Sprintf("%s/%d", chainID, p.Height)), nil
+}
+
+func TestProposalSignatureScheme(t *testing.T) {
+	proposal := NewProposal(1, 2, 3, createBlockIDRandom())
+	protoBytes := mustProposalSignBytes(t, "KAI", proposal.ToProto())
+	schemeBytes, err := ProtoSignatureScheme{}.ProposalSignBytes("KAI", proposal.ToProto())
+	require.NoError(t, err)
+	assert.Equal(t, schemeBytes, protoBytes)
+
+	scheme := &fakeSignatureScheme{}
+	prev := SetSignatureScheme(scheme)
+	defer SetSignatureScheme(prev)
+	assert.Equal(t, ProtoSignatureScheme{}, prev)
+
+	assert.Equal(t, []byte("KAI/1"), mustProposalSignBytes(t, "KAI", proposal.ToProto()))
+	assert.Equal(t, 1, scheme.calls)
+
+	// Signing goes through the scheme as well.
+	privVal := NewMockPV()
+	pb := proposal.ToProto()
+	require.NoError(t, privVal.SignProposal("KAI", pb))
+	assert.Equal(t, 2, scheme.calls)
+	assert.True(t, VerifySignature(privVal.GetAddress(), crypto.Keccak256([]byte("KAI/1")), pb.Signature))
+}
+
+func TestProposalJSONRoundTrip(t *testing.T) {
+	timestamp := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		proposal *Proposal
+	}{
+		{"with POL", &Proposal{
+			Height:     1,
+			Round:      2,
+			POLRound:   1,
+			Timestamp:  timestamp,
+			POLBlockID: createBlockIDRandom(),
+			Signature:  []byte{0x01, 0x02, 0xff},
+		}},
+		{"zero POL", &Proposal{
+			Height:    1,
+			Round:     1,
+			Timestamp: timestamp,
+		}},
+	}
+	for _, tc := range testCases {
+		bz, err := json.Marshal(tc.proposal)
+		require.NoError(t, err, tc.name)
+
+		var fields struct {
+			POLBlockID struct {
+				Hash string `json:"hash"`
+			} `json:"pol_block_id"`
+		}
+		require.NoError(t, json.Unmarshal(bz, &fields), tc.name)
+		assert.Equal(t, tc.proposal.POLBlockID.Hash.Hex(), fields.POLBlockID.Hash, tc.name)
+
+		decoded := new(Proposal)
+		require.NoError(t, json.Unmarshal(bz, decoded), tc.name)
+		assert.Equal(t, tc.proposal.Height, decoded.Height, tc.name)
+		assert.Equal(t, tc.proposal.Round, decoded.Round, tc.name)
+		assert.Equal(t, tc.proposal.POLRound, decoded.POLRound, tc.name)
+		assert.True(t, tc.proposal.Timestamp.Equal(decoded.Timestamp), tc.name)
+		assert.Equal(t, tc.proposal.POLBlockID, decoded.POLBlockID, tc.name)
+		assert.True(t, bytes.Equal(tc.proposal.Signature, decoded.Signature), tc.name)
+	}
+}
+
+func TestProposalMultipleSignatures(t *testing.T) {
+	proposal := NewProposal(1, 2, 1, createBlockIDRandom())
+	signBytes := mustProposalSignBytes(t, "KAI", proposal.ToProto())
+
+	privVals := []PrivValidator{NewMockPV(), NewMockPV(), NewMockPV()}
+	signers := make([]common.Address, len(privVals))
+	for i, privVal := range privVals {
+		pb := proposal.ToProto()
+		require.NoError(t, privVal.SignProposal("KAI", pb))
+		proposal.Signatures = append(proposal.Signatures, pb.Signature)
+		signers[i] = privVal.GetAddress()
+	}
+	// The proposer's own signature is still required.
+	require.Error(t, proposal.ValidateBasic())
+	proposal.Signature = proposal.Signatures[0]
+	require.NoError(t, proposal.ValidateBasic())
+	// None of the signatures are part of the sign bytes.
+	assert.Equal(t, signBytes, mustProposalSignBytes(t, "KAI", proposal.ToProto()))
+
+	// The signatures survive the wire.
+	bz, err := proposal.ToProto().Marshal()
+	require.NoError(t, err)
+	var pb kproto.Proposal
+	require.NoError(t, pb.Unmarshal(bz))
+	decoded, err := ProposalFromProto(&pb)
+	require.NoError(t, err)
+	assert.Equal(t, proposal.Signatures, decoded.Signatures)
+	assert.Equal(t, proposal.Signature, decoded.Signature)
+
+	jsonBz, err := json.Marshal(proposal)
+	require.NoError(t, err)
+	fromJSON := new(Proposal)
+	require.NoError(t, json.Unmarshal(jsonBz, fromJSON))
+	assert.Equal(t, proposal.Signatures, fromJSON.Signatures)
+
+	require.NoError(t, decoded.VerifyAggregate("KAI", signers, 3))
+	require.NoError(t, decoded.VerifyAggregate("KAI", append(signers, NewMockPV().GetAddress()), 2))
+	assert.True(t, errors.Is(decoded.VerifyAggregate("KAI", signers, 4), ErrProposalNotEnoughSignatures))
+	assert.True(t, errors.Is(decoded.VerifyAggregate("KAI", signers[:2], 2), ErrProposalInvalidSignature))
+	assert.True(t, errors.Is(decoded.VerifyAggregate("OTHER", signers, 1), ErrProposalInvalidSignature))
+	assert.Error(t, decoded.VerifyAggregate("KAI", signers, 0))
+
+	// The same signer is only counted once.
+	duplicated := decoded.Copy()
+	duplicated.Signatures = append(duplicated.Signatures, duplicated.Signatures[0])
+	assert.True(t, errors.Is(duplicated.VerifyAggregate("KAI", signers, 3), ErrProposalInvalidSignature))
+
+	// Copies do not share signatures.
+	duplicated.Signatures[0][0] ^= 0xff
+	assert.NotEqual(t, duplicated.Signatures[0], decoded.Signatures[0])
+
+	// Signatures must not be empty.
+	decoded.Signatures = append(decoded.Signatures, nil)
+	assert.Error(t, decoded.ValidateBasic())
+	decoded.Signatures = nil
+	require.NoError(t, decoded.ValidateBasic())
+
+	// Nor more than a vote set can hold.
+	decoded.Signatures = make([][]byte, MaxVotesCount+1)
+	for i := range decoded.Signatures {
+		decoded.Signatures[i] = decoded.Signature
+	}
+	assert.Error(t, decoded.ValidateBasic())
+}
@@ -1,8 +1,11 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/kardiachain/go-kardia/lib/common"
 	"github.com/kardiachain/go-kardia/lib/crypto"
@@ -89,3 +92,51 @@ func benchRLP(b *testing.B, encode bool) {
 		}
 	}
 }
+
+// Tests that the generated Header encoder, used unless built with the norlpgen
+// tag, matches the reflection based encoding used with it.
+func TestHeaderEncodeRLPMatchesReflection(t *testing.T) {
+	// reflectedHeader has Header's fields without its EncodeRLP method.
+	type reflectedHeader Header
+	for _, h := range []*Header{
+		{},
+		{Height: 10, Time: time.Unix(1600000000, 5), NumTxs: 2, GasLimit: 1000,
+			ProposerAddress: common.HexToAddress("0x00000000000000000000000000000000deadbeef")},
+	} {
+		generated, err := rlp.EncodeToBytes(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reflected, err := rlp.EncodeToBytes((*reflectedHeader)(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(generated, reflected) {
+			t.Fatalf("encoding mismatch: generated %x, reflected %x", generated, reflected)
+		}
+	}
+}
+
+func TestBlockIDJSON(t *testing.T) {
+	for _, blockID := range []BlockID{createBlockIDRandom(), {}} {
+		bz, err := json.Marshal(blockID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded BlockID
+		if err := json.Unmarshal(bz, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(blockID) {
+			t.Fatalf("decoded %v from %s, want %v", decoded, bz, blockID)
+		}
+	}
+
+	decoded := createBlockIDRandom()
+	if err := json.Unmarshal([]byte("null"), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsZero() {
+		t.Fatalf("null decoded to %v, want the zero BlockID", decoded)
+	}
+}